@@ -0,0 +1,304 @@
+package main
+
+import (
+	"flag"
+	"iter"
+	"maps"
+	"math"
+	"regexp/syntax"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-playground/validator/v10"
+	"github.com/hadrienk/oapi-codegen-validator/internal/patterns"
+	"github.com/hadrienk/oapi-codegen-validator/internal/tree"
+	"github.com/hadrienk/oapi-codegen-validator/internal/yaml11"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/enrich"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/middleware"
+)
+
+// examplesCommand writes the spec with synthesized examples.
+func examplesCommand(args []string) {
+	fs := flag.NewFlagSet("examples", flag.ExitOnError)
+	run(fs, args, func(doc *openapi3.T) ([]byte, error) {
+		for _, name := range synthesizeExamples(doc) {
+			logger.Warn("No example satisfies the rules", "schema", name)
+		}
+		return yaml11.Marshal(doc)
+	})
+}
+
+// synthesizeExamples sets the example of the component schemas, and of their properties,
+// that have none to a value satisfying both the schema and the validate rules the
+// enricher generates for it, so that documentation shows valid values. Objects are left
+// to their properties, and items and additional properties to the example of their array
+// or map. It returns the schemas no example could be synthesized for, e.g. those with a
+// pattern that is not RE2.
+func synthesizeExamples(doc *openapi3.T) (skipped []string) {
+	if doc.Components == nil {
+		return nil
+	}
+	v := validator.New()
+	// The middleware registers the validations the generated rules use, such as regex.
+	middleware.NewValidator(middleware.WithValidator(v))
+	for ctx := range tree.PreOrderUnique(componentSchemas(doc.Components.Schemas), enrich.Children, keyOf) {
+		s := ctx.Schema
+		if s.Example != nil || isObjectSchema(s) || isElement(ctx) {
+			continue
+		}
+		if example, ok := synthesize(v, s); ok {
+			s.Example = example
+		} else {
+			skipped = append(skipped, ctx.Name)
+		}
+	}
+	slices.Sort(skipped)
+	return skipped
+}
+
+// componentSchemas yields the component schemas in name order, leaving the references
+// between them in place, unlike the walk of enrich.Spec.
+func componentSchemas(schemas openapi3.Schemas) iter.Seq[enrich.SchemaContext] {
+	return func(yield func(enrich.SchemaContext) bool) {
+		for _, name := range slices.Sorted(maps.Keys(schemas)) {
+			if ref := schemas[name]; ref.Ref == "" && ref.Value != nil {
+				if !yield(enrich.SchemaContext{Schema: ref.Value, Name: name}) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// exampleKey tells the schemas of the walk of synthesizeExamples apart: a schema reached
+// as an element, skipped, is visited again when reached otherwise.
+type exampleKey struct {
+	schema  *openapi3.Schema
+	element bool
+}
+
+func keyOf(ctx enrich.SchemaContext) exampleKey {
+	return exampleKey{ctx.Schema, isElement(ctx)}
+}
+
+// isElement reports whether ctx is the items of an array or the additional properties of
+// a map, whose example is that of their parent.
+func isElement(ctx enrich.SchemaContext) bool {
+	return strings.HasSuffix(ctx.Name, "[]") || strings.HasSuffix(ctx.Name, "{}")
+}
+
+func isObjectSchema(s *openapi3.Schema) bool {
+	return s.Type.Is(openapi3.TypeObject) || len(s.Properties) > 0
+}
+
+// synthesize returns a value of s satisfying its schema and validate rules.
+func synthesize(v *validator.Validate, s *openapi3.Schema) (any, bool) {
+	value, ok := candidate(v, s)
+	if !ok || s.VisitJSON(value) != nil {
+		return nil, false
+	}
+	return value, satisfiesRules(v, s, value)
+}
+
+// satisfiesRules reports whether value satisfies the validate rules of s: those already
+// injected by the enricher or, on a spec not enriched yet, the ones it would generate.
+func satisfiesRules(v *validator.Validate, s *openapi3.Schema, value any) (ok bool) {
+	tag := enrich.Tag(s)
+	if tag == "" {
+		rules, err := enrich.Rules(s)
+		if err != nil {
+			return false
+		}
+		tag = strings.Join(rules, ",")
+	}
+	defer func() {
+		// Custom validations are unknown to the validator: the example cannot be checked.
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	return v.Var(value, tag) == nil
+}
+
+// formatExamples holds, by format, a value of the format.
+var formatExamples = map[string]string{
+	"email":        "user@example.com",
+	"uuid":         "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"date-time":    "2024-01-01T00:00:00Z",
+	"date":         "2024-01-01",
+	"time":         "12:00:00Z",
+	"duration":     "P1D",
+	"ipv4":         "192.0.2.1",
+	"ipv6":         "2001:db8::1",
+	"uri":          "https://example.com",
+	"url":          "https://example.com",
+	"hostname":     "example.com",
+	"idn-hostname": "example.com",
+	"byte":         "ZXhhbXBsZQ==",
+}
+
+// candidate returns a value of s meant to satisfy its constraints, to be checked by the
+// caller.
+func candidate(v *validator.Validate, s *openapi3.Schema) (any, bool) {
+	switch {
+	case s.Default != nil:
+		return s.Default, true
+	case len(s.Enum) > 0:
+		return s.Enum[0], true
+	case s.Type.Is(openapi3.TypeString):
+		return stringExample(s)
+	case s.Type.Is(openapi3.TypeInteger):
+		return int64(numberExample(s, true)), true
+	case s.Type.Is(openapi3.TypeNumber):
+		return numberExample(s, false), true
+	case s.Type.Is(openapi3.TypeBoolean):
+		return true, true
+	case s.Type.Is(openapi3.TypeArray):
+		return arrayExample(v, s)
+	}
+	return nil, false
+}
+
+func stringExample(s *openapi3.Schema) (string, bool) {
+	if example, ok := formatExamples[s.Format]; ok {
+		return example, true
+	}
+	if s.Pattern != "" {
+		return patternExample(s.Pattern)
+	}
+	example := "example"
+	if n := int(s.MinLength); len(example) < n {
+		example += strings.Repeat("x", n-len(example))
+	}
+	if s.MaxLength != nil && uint64(len(example)) > *s.MaxLength {
+		example = example[:*s.MaxLength]
+	}
+	return example, true
+}
+
+// numberExample returns the value closest to 1 within the bounds of s, integral when
+// integer is set, rounded up to the multipleOf of s. The caller checks the bounds.
+func numberExample(s *openapi3.Schema, integer bool) float64 {
+	x := boundedExample(s, integer)
+	if n := s.MultipleOf; n != nil && *n > 0 {
+		x = math.Ceil(x / *n) * *n
+	}
+	return x
+}
+
+func boundedExample(s *openapi3.Schema, integer bool) float64 {
+	step := 1.0
+	x := 1.0
+	if s.Min != nil {
+		x = *s.Min
+		if integer {
+			x = math.Ceil(x)
+		}
+		if s.ExclusiveMin && x <= *s.Min {
+			x += step
+		}
+	}
+	if s.Max != nil && (x > *s.Max || s.ExclusiveMax && x >= *s.Max) {
+		switch {
+		case integer:
+			x = math.Floor(*s.Max)
+			if s.ExclusiveMax && x >= *s.Max {
+				x -= step
+			}
+		case s.Min != nil:
+			x = (*s.Min + *s.Max) / 2
+		default:
+			x = *s.Max - step
+		}
+	}
+	return x
+}
+
+func arrayExample(v *validator.Validate, s *openapi3.Schema) ([]any, bool) {
+	if s.Items == nil || s.Items.Value == nil {
+		return nil, false
+	}
+	n := max(s.MinItems, 1)
+	if s.MaxItems != nil {
+		n = min(n, *s.MaxItems)
+	}
+	items := s.Items.Value
+	if s.UniqueItems && n > 1 {
+		if uint64(len(items.Enum)) < n {
+			return nil, false
+		}
+		return items.Enum[:n], true
+	}
+	item := items.Example
+	if item == nil {
+		var ok bool
+		if isObjectSchema(items) {
+			return nil, false
+		}
+		if item, ok = synthesize(v, items); !ok {
+			return nil, false
+		}
+	}
+	example := make([]any, n)
+	for i := range example {
+		example[i] = item
+	}
+	return example, true
+}
+
+// patternExample returns the shortest string matching pattern, taking the first branch of
+// alternations and the first character of classes.
+func patternExample(pattern string) (string, bool) {
+	pattern, err := patterns.Translate(pattern)
+	if err != nil {
+		return "", false
+	}
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var b strings.Builder
+	if !writeMatch(&b, re.Simplify()) {
+		return "", false
+	}
+	if compiled, err := patterns.Compile(pattern); err != nil || !compiled.MatchString(b.String()) {
+		return "", false
+	}
+	return b.String(), true
+}
+
+func writeMatch(b *strings.Builder, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary, syntax.OpStar, syntax.OpQuest:
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return false
+		}
+		b.WriteRune(re.Rune[0])
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte('a')
+	case syntax.OpCapture, syntax.OpPlus:
+		return writeMatch(b, re.Sub[0])
+	case syntax.OpRepeat:
+		for range re.Min {
+			if !writeMatch(b, re.Sub[0]) {
+				return false
+			}
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !writeMatch(b, sub) {
+				return false
+			}
+		}
+	case syntax.OpAlternate:
+		return writeMatch(b, re.Sub[0])
+	default:
+		return false
+	}
+	return true
+}
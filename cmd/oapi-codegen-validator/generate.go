@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/codegen"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/enrich"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/middleware"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/oapicodegen"
+)
+
+// generateCommand returns a command writing the Go file generated by gen from a spec.
+func generateCommand(name string, gen func(doc *openapi3.T, pkg string) ([]byte, error)) func(args []string) {
+	return func(args []string) {
+		fs := flag.NewFlagSet(name, flag.ExitOnError)
+		pkg := fs.String("package", "api", "Package of the generated file, the one of the oapi-codegen output")
+		run(fs, args, func(doc *openapi3.T) ([]byte, error) {
+			return gen(doc, *pkg)
+		})
+	}
+}
+
+// manifestCommand writes the constraint manifest of a spec.
+func manifestCommand(args []string) {
+	fs := flag.NewFlagSet("manifest", flag.ExitOnError)
+	format := fs.String("format", "json", "Output format: json or ts")
+	run(fs, args, func(doc *openapi3.T) ([]byte, error) {
+		return codegen.Manifest(doc, *format)
+	})
+}
+
+// artifactCommand writes the metadata of a spec loaded by middleware.ReadArtifact, for
+// services to apply it with middleware.WithArtifact without parsing the spec at startup.
+func artifactCommand(args []string) {
+	run(flag.NewFlagSet("artifact", flag.ExitOnError), args, func(doc *openapi3.T) ([]byte, error) {
+		var buf bytes.Buffer
+		err := middleware.WriteArtifact(&buf, middleware.NewArtifact(doc))
+		return buf.Bytes(), err
+	})
+}
+
+// overlayCommand writes the OpenAPI Overlay setting the validate tags of a spec, for
+// oapi-codegen to apply with its output-options.overlay option.
+func overlayCommand(args []string) {
+	run(flag.NewFlagSet("overlay", flag.ExitOnError), args, oapicodegen.MarshalOverlay)
+}
+
+// generateStructs generates the structs of the enriched doc.
+func generateStructs(doc *openapi3.T, pkg string) ([]byte, error) {
+	// Enrichment inlines the component schemas, aliases of other components included.
+	aliases := make(map[string]string)
+	for name, ref := range doc.Components.Schemas {
+		if ref.Ref != "" {
+			aliases[name] = ref.Ref
+		}
+	}
+	if err := enrich.Spec(doc); err != nil {
+		return nil, err
+	}
+	for name, ref := range aliases {
+		doc.Components.Schemas[name].Ref = ref
+	}
+	return codegen.Structs(doc, pkg)
+}
+
+// run parses the input and output flags, along with the flags already defined on fs, and
+// writes the output of gen for the input spec.
+func run(fs *flag.FlagSet, args []string, gen func(doc *openapi3.T) ([]byte, error)) {
+	input := fs.String("input", "", "Input OpenAPI file path")
+	output := fs.String("output", "", "Output file path")
+	_ = fs.Parse(args)
+	if *input == "" || *output == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	doc, err := loadSpec(*input)
+	if err != nil {
+		fatal("Failed to load OpenAPI spec", "input", *input, "error", err)
+	}
+
+	src, err := gen(doc)
+	if err != nil {
+		fatal("Generation failed", "error", err)
+	}
+
+	if err := writeBytes(*output, src); err != nil {
+		fatal("Failed to write output", "error", err)
+	}
+}
+
+// diffCommand prints the changes of the validation rules between two revisions of a spec,
+// both enriched first. With -fail-on-breaking, it exits with status 1 when a change is
+// breaking.
+func diffCommand(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	oldPath := fs.String("old", "", "Previous OpenAPI file path")
+	newPath := fs.String("new", "", "Current OpenAPI file path")
+	format := fs.String("format", "text", "Output format: text or json")
+	failOnBreaking := fs.Bool("fail-on-breaking", false, "Exit with status 1 when a change is breaking")
+	_ = fs.Parse(args)
+	if *oldPath == "" || *newPath == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	changes := codegen.Diff(loadRevisions(*oldPath, *newPath))
+	switch *format {
+	case "text":
+		for _, c := range changes {
+			fmt.Println(c)
+		}
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(changes); err != nil {
+			fatal("Failed to encode changes", "error", err)
+		}
+	default:
+		fatal("Unknown format", "format", *format)
+	}
+	if *failOnBreaking && slices.ContainsFunc(changes, func(c codegen.RuleChange) bool { return c.Breaking }) {
+		os.Exit(1)
+	}
+}
+
+// changelogCommand writes the changelog of the validation rules between two releases of a
+// spec, both enriched first.
+func changelogCommand(args []string) {
+	fs := flag.NewFlagSet("changelog", flag.ExitOnError)
+	oldPath := fs.String("old", "", "Previous OpenAPI file path")
+	newPath := fs.String("new", "", "Current OpenAPI file path")
+	format := fs.String("format", "markdown", "Output format: markdown or json")
+	output := fs.String("output", "", "Output file path, standard output by default")
+	_ = fs.Parse(args)
+	if *oldPath == "" || *newPath == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	oldDoc, newDoc := loadRevisions(*oldPath, *newPath)
+	src, err := codegen.Changelog(oldDoc, newDoc, *format)
+	if err != nil {
+		fatal("Generation failed", "error", err)
+	}
+	if *output == "" {
+		_, _ = os.Stdout.Write(src)
+		return
+	}
+	if err := writeBytes(*output, src); err != nil {
+		fatal("Failed to write output", "error", err)
+	}
+}
+
+// coverageCommand reports the validation coverage of a spec, enriched first. With -min,
+// it exits with status 1 when the score of the spec is below it, in percent.
+func coverageCommand(args []string) {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	input := fs.String("input", "", "Input OpenAPI file path")
+	format := fs.String("format", "text", "Output format: text or json")
+	minScore := fs.Float64("min", 0, "Minimum score of the spec, in percent")
+	_ = fs.Parse(args)
+	if *input == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	doc, err := loadSpec(*input)
+	if err != nil {
+		fatal("Failed to load OpenAPI spec", "input", *input, "error", err)
+	}
+	if err := enrich.Spec(doc); err != nil {
+		fatal("Enrichment failed", "input", *input, "error", err)
+	}
+	src, err := codegen.CoverageReport(doc, *format)
+	if err != nil {
+		fatal("Generation failed", "error", err)
+	}
+	_, _ = os.Stdout.Write(src)
+	if score := 100 * codegen.NewCoverage(doc).Score; score < *minScore {
+		fmt.Fprintf(os.Stderr, "coverage %.1f%% is below %.1f%%\n", score, *minScore)
+		os.Exit(1)
+	}
+}
+
+// lintCommand lists the properties whose validate tag does not suit the field oapi-codegen
+// generates for them, see oapicodegen.Lint, exiting with status 1 when there are any.
+func lintCommand(args []string) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	input := fs.String("input", "", "Input OpenAPI file path")
+	_ = fs.Parse(args)
+	if *input == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	doc, err := loadSpec(*input)
+	if err != nil {
+		fatal("Failed to load OpenAPI spec", "input", *input, "error", err)
+	}
+	mismatches, err := oapicodegen.Lint(doc)
+	if err != nil {
+		fatal("Enrichment failed", "input", *input, "error", err)
+	}
+	for _, m := range mismatches {
+		fmt.Println(m)
+	}
+	if len(mismatches) > 0 {
+		os.Exit(1)
+	}
+}
+
+// loadRevisions loads and enriches two revisions of a spec.
+func loadRevisions(oldPath, newPath string) (oldDoc, newDoc *openapi3.T) {
+	var docs []*openapi3.T
+	for _, path := range []string{oldPath, newPath} {
+		doc, err := loadSpec(path)
+		if err != nil {
+			fatal("Failed to load OpenAPI spec", "input", path, "error", err)
+		}
+		if err := enrich.Spec(doc); err != nil {
+			fatal("Enrichment failed", "input", path, "error", err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs[0], docs[1]
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+)
+
+// logger reports the progress and failures of the commands on standard error.
+var logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// fatal logs msg with the key-value pairs of args, as slog does, and exits with status 1.
+func fatal(msg string, args ...any) {
+	logger.Error(msg, args...)
+	os.Exit(1)
+}
+
+// warn logs the keywords of err, joined *rules.PropertyError, translated on a best-effort
+// basis that no rule expresses, one record each.
+func warn(err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			warn(err)
+		}
+		return
+	}
+	var located *rules.PropertyError
+	if errors.As(err, &located) {
+		logger.Warn("Keyword not translated", "pointer", located.Pointer, "keyword", located.Keyword, "reason", located.Err)
+		return
+	}
+	logger.Warn("Keyword not translated", "reason", err)
+}
+
+// batchError summarizes the inputs of a batch run that failed, the others having been
+// processed regardless.
+type batchError struct {
+	// failed are the inputs that failed, with their error, in the order of the batch.
+	failed []inputError
+	total  int
+}
+
+type inputError struct {
+	input string
+	err   error
+}
+
+// fail records and logs the failure of input.
+func (b *batchError) fail(input string, err error) {
+	logger.Error("Input failed", "input", input, "error", err)
+	b.failed = append(b.failed, inputError{input, err})
+}
+
+// orNil returns b when an input failed.
+func (b *batchError) orNil() error {
+	if len(b.failed) == 0 {
+		return nil
+	}
+	return b
+}
+
+func (b *batchError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d of %d inputs failed:", len(b.failed), b.total)
+	for _, f := range b.failed {
+		fmt.Fprintf(&sb, "\n  %s: %v", f.input, f.err)
+	}
+	return sb.String()
+}
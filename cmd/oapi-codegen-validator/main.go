@@ -1,264 +1,151 @@
 package main
 
 import (
-	"errors"
 	"flag"
-	"fmt"
-	"iter"
-	"log"
+	"io"
+	"net/http"
 	"os"
-	"regexp"
-	"slices"
-	"strings"
+	"runtime"
+	"runtime/debug"
 
 	"github.com/getkin/kin-openapi/openapi3"
-	"github.com/hadrienk/oapi-codegen-validator/internal/tree"
-	"gopkg.in/yaml.v3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/codegen"
+	"github.com/hadrienk/oapi-codegen-validator/internal/dialect"
+	"github.com/hadrienk/oapi-codegen-validator/internal/docstrip"
+	"github.com/hadrienk/oapi-codegen-validator/internal/libopenapi"
+	"github.com/hadrienk/oapi-codegen-validator/internal/refcache"
+	"github.com/hadrienk/oapi-codegen-validator/internal/swagger"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/enrich"
 )
 
 var (
-	input  = flag.String("input", "", "Input OpenAPI file path")
-	output = flag.String("output", "", "Output enriched OpenAPI file path")
+	input    = flag.String("input", "", "Input OpenAPI file path, Swagger 2.0 ones being converted to OpenAPI 3.0")
+	output   = flag.String("output", "", "Output enriched OpenAPI file path")
+	policies = flag.String("policy", "", "Policy config file the spec must comply with before enrichment")
+	backend  = flag.String("backend", "kin-openapi", "Spec backend: kin-openapi, or libopenapi for OpenAPI 3.1 and order and comment preserving output")
+	profile  = flag.String("profile", "default", "Enrichment preset: default, strict-internal or lenient-public")
+	refCache = flag.String("ref-cache", "", "Directory keeping the parsed referenced files across runs")
+	memLimit = flag.Int("memory-limit", 0, "Memory budget in MiB: trades speed for memory, collecting garbage eagerly and loading the spec without its descriptions and examples where they are not needed")
+	formats  = flag.String("formats", "", "YAML file mapping formats to validate rules, e.g. 'ksuid: ksuid', added to those of the profile")
+	anchor   = flag.Bool("pattern-anchor", false, "Make patterns match whole values, wrapping the unanchored ones in ^(?:...)$, except on properties with x-pattern-anchor: false")
+	dirTags  = flag.Bool("directional", false, "Add validateRequest and validateResponse tags, for middleware.WithDirectionalTags: readOnly fields are optional in requests and writeOnly fields unchecked in responses")
+	omitNil  = flag.Bool("omitnil", false, "Lead the tags of optional nullable fields, generated as pointers, with omitnil rather than omitempty, checking their explicit empty values")
+	parallel = flag.Bool("parallel", false, "Enrich independent component schemas concurrently, with GOMAXPROCS goroutines (kin-openapi backend)")
 )
 
-const (
-	tagKey   = "x-oapi-codegen-extra-tags"
-	validate = "validate"
-)
-
-func main() {
-	flag.Parse()
-	if *input == "" || *output == "" {
-		flag.Usage()
-		os.Exit(1)
-	}
-
-	loader := openapi3.NewLoader()
-	loader.IsExternalRefsAllowed = true
-
-	doc, err := loader.LoadFromFile(*input)
-	if err != nil {
-		log.Fatalf("Failed to load OpenAPI spec: %v", err)
-	}
-
-	if err := enrichSpec(doc); err != nil {
-		log.Fatalf("Enrichment failed: %v", err)
-	}
-
-	data, err := yaml.Marshal(doc)
-	if err != nil {
-		log.Fatalf("Failed to marshal enriched spec: %v", err)
-	}
-
-	if err := os.WriteFile(*output, data, 0644); err != nil {
-		log.Fatalf("Failed to write output: %v", err)
-	}
-}
-
-type SchemaContext struct {
-	Schema *openapi3.Schema
-	Name   string
-}
-
-func toSchemaContext(schemas openapi3.Schemas) iter.Seq[SchemaContext] {
-	return func(yield func(SchemaContext) bool) {
-		for name, ref := range schemas {
-			if ref.Value != nil {
-				ref.Ref = "" // Force inline so modifications persist
-				if !yield(SchemaContext{Schema: ref.Value, Name: name}) {
-					return
-				}
-			}
-		}
-	}
-}
+// refs caches the files read by loadSpec, which the specs of a batch often share.
+var refs = refcache.New("")
 
-func getChildren(ctx SchemaContext) iter.Seq[SchemaContext] {
-	return func(yield func(SchemaContext) bool) {
-		for propName, propRef := range ctx.Schema.Properties {
-			if propRef.Value != nil {
-				childCtx := SchemaContext{
-					Schema: propRef.Value,
-					Name:   ctx.Name + "." + propName,
-				}
-				if !yield(childCtx) {
-					return
-				}
-			}
-		}
-	}
+// backends write the spec at a path enriched with a profile, by name.
+var backends = map[string]func(w io.Writer, path string, profile enrich.Profile) error{
+	"kin-openapi": enrichFile,
+	"libopenapi":  libopenapi.EnrichFile,
 }
 
-func enrichSpec(doc *openapi3.T) (errs error) {
-	for ctx := range tree.PreOrder(toSchemaContext(doc.Components.Schemas), getChildren) {
-		if err := enrichNode(ctx); err != nil {
-			errs = errors.Join(errs, err)
-		}
-	}
-	return errs
+// commands are the subcommands of the tool. Without one, the input spec is enriched.
+var commands = map[string]func(args []string){
+	"validators":    generateCommand("validators", codegen.Operations),
+	"registrations": generateCommand("registrations", codegen.Registrations),
+	"models":        generateCommand("models", codegen.Models),
+	"tests":         generateCommand("tests", codegen.Tests),
+	"problems":      generateCommand("problems", codegen.Problems),
+	"manifest":      manifestCommand,
+	"structs":       generateCommand("structs", generateStructs),
+	"examples":      examplesCommand,
+	"contract":      generateCommand("contract", codegen.Contract),
+	"snapshot":      snapshotCommand,
+	"diff":          diffCommand,
+	"changelog":     changelogCommand,
+	"policy":        policyCommand,
+	"coverage":      coverageCommand,
+	"overlay":       overlayCommand,
+	"artifact":      artifactCommand,
+	"lint":          lintCommand,
 }
 
-func enrichNode(ctx SchemaContext) error {
-	// We iterate the properties of the current schema to calculate and inject tags.
-	for propName, propRef := range ctx.Schema.Properties {
-		if propRef.Value == nil {
-			continue
-		}
-
-		oapiRules, err := generateRules(propRef.Value)
-		if err != nil {
-			return fmt.Errorf("property %s.%s: %w", ctx.Name, propName, err)
-		}
-
-		validatorRules, extMap := extractAndResetValidateRules(propRef.Value)
-
-		rules, err := mergeRules(validatorRules, oapiRules)
-		if err != nil {
-			return fmt.Errorf("property %s.%s: %w", ctx.Name, propName, err)
-		}
-
-		// NB. Order is important for these tags, so we prepend them after generateTags & injectTags.
-		if slices.Contains(ctx.Schema.Required, propName) {
-			oapiRules = slices.Insert(rules, 0, "required")
-		} else if len(rules) > 0 {
-			oapiRules = slices.Insert(rules, 0, "omitempty")
-		} else {
-			// No rules and not required: nothing useful to emit.
-			delete(propRef.Value.Extensions, tagKey)
-			continue
+func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			cmd(os.Args[2:])
+			return
 		}
-
-		extMap[validate] = strings.Join(oapiRules, ",")
-		propRef.Value.Extensions[tagKey] = extMap
 	}
 
-	return nil
-}
-
-func extractAndResetValidateRules(s *openapi3.Schema) (rules []string, extMap map[string]any) {
-
-	if s.Extensions == nil {
-		s.Extensions = make(map[string]any)
+	flag.Parse()
+	if *input == "" || *output == "" {
+		flag.Usage()
+		os.Exit(1)
 	}
+	refs = refcache.New(*refCache)
 
-	extMap, ok := s.Extensions[tagKey].(map[string]any)
+	enrichWith, ok := backends[*backend]
 	if !ok {
-		extMap = make(map[string]any)
+		fatal("Unknown backend", "backend", *backend)
 	}
-	existingVal, _ := extMap[validate].(string)
-
-	// Reset the validate.
-	delete(extMap, validate)
-
-	// Parse the existing rules.
-	if existingVal != "" {
-		for part := range strings.SplitSeq(existingVal, ",") {
-			part = strings.TrimSpace(part)
-			if part == "" {
-				continue
-			}
-			rules = append(rules, part)
-		}
-	}
-
-	return rules, extMap
-}
-
-func generateRules(s *openapi3.Schema) ([]string, error) {
-	var tags []string
-
-	if s.MultipleOf != nil {
-		return nil, fmt.Errorf("validation keyword 'multipleOf' is not supported by auto-enricher")
+	preset, ok := enrich.Profiles[*profile]
+	if !ok {
+		fatal("Unknown profile", "profile", *profile)
 	}
-
-	if s.Pattern != "" {
-		if _, err := regexp.Compile(s.Pattern); err != nil {
-			return nil, fmt.Errorf("validation keyword 'pattern' '%s' is not a valid Go RE2 regex: %w", s.Pattern, err)
+	if *formats != "" {
+		extra, err := enrich.LoadFormats(*formats)
+		if err != nil {
+			fatal("Failed to load formats", "formats", *formats, "error", err)
 		}
-		tags = append(tags, fmt.Sprintf("regex=%s", s.Pattern))
+		preset = preset.WithFormats(extra)
 	}
-
-	if s.MinLength > 0 {
-		tags = append(tags, fmt.Sprintf("min=%d", s.MinLength))
+	preset.AnchorPatterns = *anchor
+	preset.OmitNil = *omitNil
+	preset.Directional = *dirTags
+	preset.Warn = warn
+	if *parallel {
+		preset.Workers = runtime.GOMAXPROCS(0)
 	}
 
-	if s.MaxLength != nil {
-		tags = append(tags, fmt.Sprintf("max=%d", *s.MaxLength))
+	load := loadSpec
+	if *memLimit > 0 {
+		debug.SetMemoryLimit(int64(*memLimit) << 20)
+		load = loadLeanSpec
 	}
 
-	if s.Min != nil {
-		op := "min"
-		if s.ExclusiveMin {
-			op = "gt"
+	if *policies != "" {
+		doc, err := load(*input)
+		if err != nil {
+			fatal("Failed to load OpenAPI spec", "input", *input, "error", err)
 		}
-		tags = append(tags, fmt.Sprintf("%s=%.0f", op, *s.Min))
-	}
-
-	if s.Max != nil {
-		op := "max"
-		if s.ExclusiveMax {
-			op = "lt"
+		if err := checkPolicies(doc, *policies, os.Stderr); err != nil {
+			fatal("Enrichment failed", "input", *input, "error", err)
+		}
+		if *memLimit > 0 {
+			// The spec is loaded again to be enriched: return this one to the OS first.
+			debug.FreeOSMemory()
 		}
-		tags = append(tags, fmt.Sprintf("%s=%.0f", op, *s.Max))
-	}
-
-	if s.MinItems > 0 {
-		tags = append(tags, fmt.Sprintf("min=%d", s.MinItems))
-	}
-	if s.MaxItems != nil {
-		tags = append(tags, fmt.Sprintf("max=%d", *s.MaxItems))
-	}
-	if s.UniqueItems {
-		tags = append(tags, "unique")
 	}
 
-	switch s.Format {
-	case "email":
-		tags = append(tags, "email")
-	case "uuid":
-		tags = append(tags, "uuid")
-	case "ipv4":
-		tags = append(tags, "ipv4")
-	case "ipv6":
-		tags = append(tags, "ipv6")
-	case "uri", "url":
-		tags = append(tags, "url")
+	if err := writeFile(*output, func(w io.Writer) error { return enrichWith(w, *input, preset) }); err != nil {
+		fatal("Enrichment failed", "input", *input, "error", err)
 	}
-
-	return tags, nil
 }
 
-func mergeRules(existingRules, newRules []string) (rules []string, err error) {
-	existingKeys := make(map[string]string)
-
-	for _, part := range existingRules {
-		part = strings.TrimSpace(part)
-		if part == "" {
-			continue
-		}
-		rules = append(rules, part)
-		key := getTagKey(part)
-		existingKeys[key] = part
-	}
+func loadSpec(path string) (*openapi3.T, error) {
+	return loadSpecWith(path, openapi3.ReadFromURIs(openapi3.ReadFromHTTP(http.DefaultClient), openapi3.ReadFromFile))
+}
 
-	for _, tag := range newRules {
-		key := getTagKey(tag)
-		if existingTag, exists := existingKeys[key]; exists {
-			// Conflict check
-			if existingTag != tag {
-				return nil, fmt.Errorf("conflict: manual tag '%s' differs from generated tag '%s'", existingTag, tag)
-			}
-		} else {
-			rules = append(rules, tag)
-			existingKeys[key] = tag
-		}
-	}
-	return rules, nil
+// loadLeanSpec loads the spec at path without its descriptions and examples, for the
+// checks not needing them.
+func loadLeanSpec(path string) (*openapi3.T, error) {
+	return loadSpecWith(path, docstrip.Reader(openapi3.ReadFromURIs(openapi3.ReadFromHTTP(http.DefaultClient), openapi3.ReadFromFile)))
 }
 
-func getTagKey(tag string) string {
-	if idx := strings.Index(tag, "="); idx != -1 {
-		return tag[:idx]
+func loadSpecWith(path string, read openapi3.ReadFromURIFunc) (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	// The default reader caches files for the life of the process, which would hide the
+	// changes of a spec loaded again, e.g. by snapshot: refs caches them by content.
+	loader.ReadFromURIFunc = openapi3.URIMapCache(refs.Reader(dialect.Reader(swagger.Reader(read))))
+	doc, err := loader.LoadFromFile(path)
+	if err != nil {
+		return nil, err
 	}
-	return tag
+	dialect.Restore(doc)
+	return doc, nil
 }
@@ -1,26 +1,94 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/enrich"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 )
 
 func TestGenerateRules(t *testing.T) {
-	runDir(t, "testdata/generate_rules")
+	runDir(t, "testdata/generate_rules", enrich.Spec)
+}
+
+func TestGenerateRules31(t *testing.T) {
+	runDir(t, "testdata/generate_rules_3.1", enrich.Spec)
 }
 
 func TestEnrichSpec(t *testing.T) {
-	runDir(t, "testdata/enrich_spec")
+	runDir(t, "testdata/enrich_spec", enrich.Spec)
+}
+
+func TestSynthesizeExamples(t *testing.T) {
+	runDir(t, "testdata/examples", func(doc *openapi3.T) error {
+		if skipped := synthesizeExamples(doc); len(skipped) > 0 {
+			return fmt.Errorf("no example for %s", strings.Join(skipped, ", "))
+		}
+		return nil
+	})
+}
+
+func TestSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "users.yaml")
+	spec, err := os.ReadFile("testdata/enrich_spec/required.input.yaml")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(input, spec, 0644))
+
+	var out strings.Builder
+	require.NoError(t, snapshot("init", []string{input}, &out))
+	assert.FileExists(t, filepath.Join(dir, "users.expected.yaml"))
+	require.NoError(t, snapshot("verify", []string{input}, &out))
+
+	changed := strings.Replace(string(spec), "type: string", "type: string\n          minLength: 3", 1)
+	require.NoError(t, os.WriteFile(input, []byte(changed), 0644))
+	out.Reset()
+	require.ErrorIs(t, snapshot("verify", []string{input}, &out), errSnapshotMismatch)
+	assert.Contains(t, out.String(), "+                        validate: required,min=3")
+
+	require.NoError(t, snapshot("update", []string{input}, &out))
+	require.NoError(t, snapshot("verify", []string{input}, &out))
+}
+
+func TestSnapshotPartialFailure(t *testing.T) {
+	dir := t.TempDir()
+	spec, err := os.ReadFile("testdata/enrich_spec/required.input.yaml")
+	require.NoError(t, err)
+	broken := filepath.Join(dir, "broken.yaml")
+	require.NoError(t, os.WriteFile(broken, []byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    User: {$ref: 'missing.yaml#/components/schemas/User'}
+`), 0644))
+	users := filepath.Join(dir, "users.yaml")
+	require.NoError(t, os.WriteFile(users, spec, 0644))
+
+	var out strings.Builder
+	err = snapshot("init", []string{broken, users}, &out)
+	var failures *batchError
+	require.ErrorAs(t, err, &failures)
+	assert.Equal(t, 2, failures.total)
+	require.Len(t, failures.failed, 1)
+	assert.Equal(t, broken, failures.failed[0].input)
+	assert.ErrorContains(t, err, "1 of 2 inputs failed")
+	assert.FileExists(t, filepath.Join(dir, "users.expected.yaml"), "the other inputs must be processed")
 }
 
-func runDir(t *testing.T, dir string) {
+// runDir applies transform to each *.input.yaml spec of dir, comparing the result with
+// the matching *.expected.yaml, or its error with the *.error file.
+func runDir(t *testing.T, dir string, transform func(*openapi3.T) error) {
 	t.Helper()
 	inputs, err := filepath.Glob(filepath.Join(dir, "*.input.yaml"))
 	require.NoError(t, err)
@@ -33,7 +101,7 @@ func runDir(t *testing.T, dir string) {
 			errorPath := filepath.Join(dir, base+".error")
 
 			if _, err := os.Stat(errorPath); err == nil {
-				err := enrichSpec(doc)
+				err := transform(doc)
 				require.Error(t, err)
 				if msg, _ := os.ReadFile(errorPath); len(strings.TrimSpace(string(msg))) > 0 {
 					assert.ErrorContains(t, err, strings.TrimSpace(string(msg)))
@@ -41,7 +109,7 @@ func runDir(t *testing.T, dir string) {
 				return
 			}
 
-			require.NoError(t, enrichSpec(doc))
+			require.NoError(t, transform(doc))
 
 			expectedPath := filepath.Join(dir, base+".expected.yaml")
 			assertMatchesFile(t, doc, expectedPath)
@@ -51,8 +119,8 @@ func runDir(t *testing.T, dir string) {
 
 func loadFile(t *testing.T, path string) *openapi3.T {
 	t.Helper()
-	loader := openapi3.NewLoader()
-	doc, err := loader.LoadFromFile(path)
+	// Loaded as by the command, which reads the keywords of 3.1 kin-openapi rejects.
+	doc, err := loadSpec(path)
 	require.NoError(t, err, "failed to load %s", path)
 	return doc
 }
@@ -67,3 +135,40 @@ func assertMatchesFile(t *testing.T, actual *openapi3.T, expectedPath string) {
 	require.NoError(t, err)
 	assert.Equal(t, string(expectedYAML), string(actualYAML))
 }
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.yaml")
+	write := func(content string) error {
+		return writeFile(path, func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		})
+	}
+	read := func() string {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	require.NoError(t, write("a: 1\n"))
+	assert.Equal(t, "a: 1\n", read())
+
+	// A failed write leaves the previous output.
+	assert.Error(t, writeFile(path, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "a:")
+		return errors.New("interrupted")
+	}))
+	assert.Equal(t, "a: 1\n", read())
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the temporary file must be removed")
+
+	// The permissions and line endings of the previous output are kept.
+	require.NoError(t, os.Chmod(path, 0600))
+	require.NoError(t, os.WriteFile(path, []byte("a: 1\r\n"), 0600))
+	require.NoError(t, write("a: 2\nb: |\n  x\r\n"))
+	assert.Equal(t, "a: 2\r\nb: |\r\n  x\r\n", read())
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
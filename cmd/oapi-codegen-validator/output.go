@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// writeFile streams the output of write to path through a temporary file of the same
+// directory, renamed over path once synced, so that an interrupted or failed run leaves
+// path untouched rather than truncated. An existing path keeps its permissions and, if
+// it was checked out with CRLF line endings, e.g. by Git on Windows, gets CRLF as well.
+func writeFile(path string, write func(w io.Writer) error) error {
+	mode, windows, err := existing(path)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+	var out io.Writer = w
+	if windows {
+		out = &crlfWriter{w: w}
+	}
+	err = write(out)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if err := errors.Join(err, f.Close()); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// writeBytes writes data to path like writeFile.
+func writeBytes(path string, data []byte) error {
+	return writeFile(path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// existing returns the permissions of the file at path, 0644 if there is none, and
+// whether its first lines end with CRLF.
+func existing(path string) (mode fs.FileMode, crlf bool, err error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0644, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, false, err
+	}
+	head := make([]byte, 4096)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return 0, false, err
+	}
+	head = head[:n]
+	if i := bytes.IndexByte(head, '\n'); i > 0 && head[i-1] == '\r' {
+		crlf = true
+	}
+	return info.Mode().Perm(), crlf, nil
+}
+
+// crlfWriter writes the line feeds of its output as CRLF, leaving those already
+// preceded by a carriage return alone.
+type crlfWriter struct {
+	w  io.Writer
+	cr bool // The last byte written was a carriage return.
+}
+
+var crlf, lf = []byte("\r\n"), []byte("\n")
+
+func (c *crlfWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			m, err := c.w.Write(p)
+			if m > 0 {
+				c.cr = p[m-1] == '\r'
+			}
+			return n + m, err
+		}
+		if i > 0 {
+			c.cr = p[i-1] == '\r'
+		}
+		eol := crlf
+		if c.cr {
+			eol = lf
+		}
+		if _, err := c.w.Write(p[:i]); err != nil {
+			return n, err
+		}
+		if _, err := c.w.Write(eol); err != nil {
+			return n, err
+		}
+		n += i + 1
+		p = p[i+1:]
+		c.cr = false
+	}
+	return n, nil
+}
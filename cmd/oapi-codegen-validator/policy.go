@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/policy"
+)
+
+// policyCommand checks the schemas of a spec against the policies of a config file,
+// listing the violations and exiting with status 1 when there are any, for CI. With
+// -opa-input, it writes the schemas as the input of an external policy engine instead.
+func policyCommand(args []string) {
+	fs := flag.NewFlagSet("policy", flag.ExitOnError)
+	input := fs.String("input", "", "Input OpenAPI file path")
+	config := fs.String("config", "", "Policy config file path")
+	opaInput := fs.String("opa-input", "", "Output file path of the schemas as Open Policy Agent input")
+	_ = fs.Parse(args)
+	if *input == "" || *config == "" && *opaInput == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	doc, err := loadSpec(*input)
+	if err != nil {
+		fatal("Failed to load OpenAPI spec", "input", *input, "error", err)
+	}
+	if *opaInput != "" {
+		data, err := policy.MarshalInput(doc)
+		if err != nil {
+			fatal("Failed to marshal policy input", "error", err)
+		}
+		if err := writeBytes(*opaInput, data); err != nil {
+			fatal("Failed to write output", "error", err)
+		}
+		return
+	}
+	if err := checkPolicies(doc, *config, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// checkPolicies checks doc against the policies of the config file at path, writing the
+// violations to w.
+func checkPolicies(doc *openapi3.T, path string, w io.Writer) error {
+	cfg, err := policy.LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	violations, err := policy.Check(doc, cfg)
+	if err != nil {
+		return err
+	}
+	for _, v := range violations {
+		fmt.Fprintln(w, v)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%d policy violations", len(violations))
+	}
+	return nil
+}
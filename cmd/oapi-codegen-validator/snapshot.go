@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hadrienk/oapi-codegen-validator/internal/refcache"
+	"github.com/hadrienk/oapi-codegen-validator/internal/yaml11"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/enrich"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// errSnapshotMismatch is reported by snapshot verify when an enriched spec differs from its
+// golden file.
+var errSnapshotMismatch = errors.New("enriched specs differ from their snapshots")
+
+// snapshotCommand maintains the golden enriched spec of each source spec given as argument:
+//
+//	oapi-codegen-validator snapshot init|update|verify api/users.yaml...
+//
+// init writes the missing golden files, update rewrites them all, and verify fails with a
+// diff of every enriched spec differing from its golden file. The golden file of
+// users.yaml is users.expected.yaml, next to it, the one of users.input.yaml as well.
+func snapshotCommand(args []string) {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: oapi-codegen-validator snapshot init|update|verify spec.yaml...")
+		fs.PrintDefaults()
+	}
+	cacheDir := fs.String("ref-cache", "", "Directory keeping the parsed referenced files across runs")
+	_ = fs.Parse(args)
+	refs = refcache.New(*cacheDir)
+	if fs.NArg() < 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if err := snapshot(fs.Arg(0), fs.Args()[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// snapshot runs the snapshot action on the source specs inputs, writing the diffs found
+// by verify to w. An input failing, e.g. on a broken external ref, does not stop the
+// others: the failures are summarized by a *batchError.
+func snapshot(action string, inputs []string, w io.Writer) error {
+	if !slices.Contains([]string{"init", "update", "verify"}, action) {
+		return fmt.Errorf("unknown snapshot action %q: want init, update or verify", action)
+	}
+	failures := &batchError{total: len(inputs)}
+	mismatch := false
+	for _, input := range inputs {
+		same, err := snapshotFile(action, input, w)
+		if err != nil {
+			failures.fail(input, err)
+		}
+		mismatch = mismatch || !same
+	}
+	var err error
+	if mismatch {
+		err = fmt.Errorf("%w: run snapshot update to accept the changes", errSnapshotMismatch)
+	}
+	return errors.Join(err, failures.orNil())
+}
+
+// snapshotFile runs the snapshot action on the source spec input, reporting whether
+// verify found it unchanged.
+func snapshotFile(action, input string, w io.Writer) (same bool, err error) {
+	golden := goldenPath(input)
+	var buf bytes.Buffer
+	if err := enrichFile(&buf, input, enrich.Default); err != nil {
+		return true, err
+	}
+	got := buf.Bytes()
+	want, err := os.ReadFile(golden)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return true, err
+	}
+	// Golden files checked out with CRLF line endings, e.g. by Git on Windows, match.
+	want = bytes.ReplaceAll(want, crlf, lf)
+
+	switch action {
+	case "init":
+		if exists {
+			fmt.Fprintf(w, "%s: kept\n", golden)
+			return true, nil
+		}
+	case "update":
+		if exists && string(want) == string(got) {
+			return true, nil
+		}
+	case "verify":
+		if !exists {
+			return true, errors.New("no snapshot, run snapshot init")
+		}
+		if string(want) == string(got) {
+			return true, nil
+		}
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(want)),
+			B:        difflib.SplitLines(string(got)),
+			FromFile: golden,
+			ToFile:   input + " (enriched)",
+			Context:  3,
+		})
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprint(w, diff)
+		return false, nil
+	}
+	if err := writeBytes(golden, got); err != nil {
+		return true, err
+	}
+	fmt.Fprintf(w, "%s: written\n", golden)
+	return true, nil
+}
+
+// goldenPath returns the path of the golden enriched spec of input.
+func goldenPath(input string) string {
+	ext := filepath.Ext(input)
+	base := strings.TrimSuffix(strings.TrimSuffix(input, ext), ".input")
+	return base + ".expected" + ext
+}
+
+// enrichFile writes the spec at path enriched with profile to w, streaming the YAML
+// rather than marshaling it in memory first.
+func enrichFile(w io.Writer, path string, profile enrich.Profile) error {
+	doc, err := loadSpec(path)
+	if err != nil {
+		return err
+	}
+	if err := profile.Spec(doc); err != nil {
+		return err
+	}
+	enc, err := yaml11.NewEncoder(w)
+	if err != nil {
+		return err
+	}
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
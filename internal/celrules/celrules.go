@@ -0,0 +1,196 @@
+// Package celrules compiles the x-validate-cel rules of schemas: CEL expressions on the
+// properties of an object, e.g. "endDate > startDate" or "tags.size() <= maxTags", each
+// property being a variable of the type of its schema.
+package celrules
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+)
+
+// Keyword is the extension holding the rules of a schema.
+const Keyword = "x-validate-cel"
+
+// Rule is a compiled x-validate-cel expression.
+type Rule struct {
+	Expr string
+	// Vars holds the properties the expression uses, in order of appearance.
+	Vars []string
+	prg  cel.Program
+}
+
+// Compile compiles the x-validate-cel rules of s, which must be boolean expressions on
+// its properties. It returns no rules when s has none.
+func Compile(s *schema.Schema) ([]*Rule, error) {
+	exprs, err := expressions(s)
+	if err != nil || len(exprs) == 0 {
+		return nil, err
+	}
+	var opts []cel.EnvOption
+	for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+		opts = append(opts, cel.Variable(name, celType(s.Properties[name])))
+	}
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []*Rule
+	for _, expr := range exprs {
+		rule, err := compile(env, s, expr)
+		if err != nil {
+			return nil, fmt.Errorf("x-validate-cel %q: %w", expr, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func expressions(s *schema.Schema) ([]string, error) {
+	switch ext := s.Extensions[Keyword].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{ext}, nil
+	case []any:
+		exprs := make([]string, 0, len(ext))
+		for _, e := range ext {
+			e, ok := e.(string)
+			if !ok {
+				return nil, errors.New("x-validate-cel must hold strings")
+			}
+			exprs = append(exprs, e)
+		}
+		return exprs, nil
+	}
+	return nil, errors.New("x-validate-cel must be a string or a list of strings")
+}
+
+func compile(env *cel.Env, s *schema.Schema, expr string) (*Rule, error) {
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	if ast.OutputType() != cel.BoolType {
+		return nil, fmt.Errorf("returns %s, not a bool", ast.OutputType())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+
+	native := ast.NativeRep()
+	offsets := make(map[string]int32)
+	for id, ref := range native.ReferenceMap() {
+		if _, ok := s.Properties[ref.Name]; !ok {
+			continue
+		}
+		r, _ := native.SourceInfo().GetOffsetRange(id)
+		if o, seen := offsets[ref.Name]; !seen || r.Start < o {
+			offsets[ref.Name] = r.Start
+		}
+	}
+	vars := slices.SortedFunc(maps.Keys(offsets), func(a, b string) int {
+		return int(offsets[a] - offsets[b])
+	})
+	if len(vars) == 0 {
+		return nil, errors.New("uses no property")
+	}
+	return &Rule{Expr: expr, Vars: vars, prg: prg}, nil
+}
+
+// celType returns the CEL type of the values of s.
+func celType(s *schema.Schema) *cel.Type {
+	switch {
+	case s.Is(schema.TypeString) && s.Format == "date-time":
+		return cel.TimestampType
+	case s.Is(schema.TypeString):
+		return cel.StringType
+	case s.Is(schema.TypeInteger):
+		return cel.IntType
+	case s.Is(schema.TypeNumber):
+		return cel.DoubleType
+	case s.Is(schema.TypeBoolean):
+		return cel.BoolType
+	case s.Is(schema.TypeArray) && s.Items != nil:
+		return cel.ListType(celType(s.Items))
+	case s.Is(schema.TypeObject):
+		return cel.MapType(cel.StringType, cel.DynType)
+	}
+	return cel.DynType
+}
+
+// Eval evaluates the rule on obj, a JSON object of schema s decoded with
+// json.Decoder.UseNumber. ok is false when the rule does not hold; applied is false when
+// the rule was skipped because a property it uses is absent or null.
+func (r *Rule) Eval(s *schema.Schema, obj map[string]any) (ok, applied bool, err error) {
+	vars := make(map[string]any, len(r.Vars))
+	for _, name := range r.Vars {
+		v, present := obj[name]
+		if !present || v == nil {
+			return true, false, nil
+		}
+		if vars[name], err = value(s.Properties[name], v); err != nil {
+			return false, true, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	out, _, err := r.prg.Eval(vars)
+	if err != nil {
+		return false, true, err
+	}
+	holds, isBool := out.Value().(bool)
+	if !isBool {
+		return false, true, fmt.Errorf("returned %v, not a bool", out.Value())
+	}
+	return holds, true, nil
+}
+
+// value converts the JSON value v to the Go value of the CEL type of s.
+func value(s *schema.Schema, v any) (any, error) {
+	switch v := v.(type) {
+	case json.Number:
+		if s.Is(schema.TypeInteger) {
+			return v.Int64()
+		}
+		return v.Float64()
+	case string:
+		if s.Is(schema.TypeString) && s.Format == "date-time" {
+			return time.Parse(time.RFC3339, v)
+		}
+	case []any:
+		if s.Items == nil {
+			break
+		}
+		items := make([]any, len(v))
+		for i, item := range v {
+			var err error
+			if items[i], err = value(s.Items, item); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	case map[string]any:
+		values := make(map[string]any, len(v))
+		for k, item := range v {
+			var err error
+			if prop := s.Properties[k]; prop != nil {
+				item, err = value(prop, item)
+			} else {
+				item, err = value(&schema.Schema{}, item)
+			}
+			if err != nil {
+				return nil, err
+			}
+			values[k] = item
+		}
+		return values, nil
+	}
+	return v, nil
+}
@@ -0,0 +1,85 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ValidationChangelog lists the changes of the validation rules between two releases of
+// a spec, for release notes and client notifications.
+type ValidationChangelog struct {
+	// From and To are the versions of the specs, from their info.
+	From        string       `json:"from"`
+	To          string       `json:"to"`
+	Breaking    []RuleChange `json:"breaking"`
+	NonBreaking []RuleChange `json:"nonBreaking"`
+}
+
+// NewChangelog returns the changelog of the validation rules from oldDoc to newDoc, see
+// Diff.
+func NewChangelog(oldDoc, newDoc *openapi3.T) *ValidationChangelog {
+	c := &ValidationChangelog{Breaking: []RuleChange{}, NonBreaking: []RuleChange{}}
+	if oldDoc.Info != nil {
+		c.From = oldDoc.Info.Version
+	}
+	if newDoc.Info != nil {
+		c.To = newDoc.Info.Version
+	}
+	for _, change := range Diff(oldDoc, newDoc) {
+		if change.Breaking {
+			c.Breaking = append(c.Breaking, change)
+		} else {
+			c.NonBreaking = append(c.NonBreaking, change)
+		}
+	}
+	return c
+}
+
+// Changelog generates the changelog of the validation rules from oldDoc to newDoc,
+// formatted as JSON or as Markdown, depending on format.
+func Changelog(oldDoc, newDoc *openapi3.T, format string) ([]byte, error) {
+	c := NewChangelog(oldDoc, newDoc)
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case "markdown":
+		return c.markdown(), nil
+	}
+	return nil, fmt.Errorf("unknown changelog format %q", format)
+}
+
+func (c *ValidationChangelog) markdown() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Validation changes from %s to %s\n", c.From, c.To)
+	if len(c.Breaking) == 0 && len(c.NonBreaking) == 0 {
+		b.WriteString("\nNo changes.\n")
+	}
+	section := func(title string, changes []RuleChange) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n## %s\n", title)
+		schema := ""
+		for _, change := range changes {
+			if change.Schema != schema {
+				schema = change.Schema
+				fmt.Fprintf(&b, "\n### %s\n\n", schema)
+			}
+			if change.Field == "" {
+				fmt.Fprintf(&b, "- %s\n", change.Change)
+			} else {
+				fmt.Fprintf(&b, "- `%s`: %s\n", change.Field, change.Change)
+			}
+		}
+	}
+	section("Breaking changes", c.Breaking)
+	section("Non-breaking changes", c.NonBreaking)
+	return b.Bytes()
+}
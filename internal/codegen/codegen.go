@@ -0,0 +1,461 @@
+// Package codegen generates Go code from an OpenAPI document, to be compiled in the
+// package oapi-codegen generates from the same document.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"iter"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+)
+
+const (
+	middlewarePkg = "github.com/hadrienk/oapi-codegen-validator/pkg/middleware"
+	typesPkg      = `openapi_types "github.com/oapi-codegen/runtime/types"`
+)
+
+type generator struct {
+	doc      *openapi3.T
+	body     strings.Builder
+	imports  map[string]bool
+	patterns map[string]string
+	// prefix names the package-level variables, unique per generated file.
+	prefix string
+	// check prefixes the names of the functions validating a type, and fieldError is the
+	// type of the errors they return.
+	check, fieldError string
+	// owned reports whether fieldError has an Owner, set to owner, the type whose own
+	// fields are being checked, for WithSensitiveFields; owner is empty in inline objects.
+	owned   bool
+	owner   string
+	formats map[string]bool
+	locals  int
+}
+
+func newGenerator(doc *openapi3.T, prefix string) *generator {
+	return &generator{
+		doc:        doc,
+		prefix:     prefix,
+		check:      "validate",
+		fieldError: "middleware.FieldError",
+		owned:      true,
+		imports:    make(map[string]bool),
+		patterns:   make(map[string]string),
+		formats:    make(map[string]bool),
+	}
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+// local returns a new variable name.
+func (g *generator) local(prefix string) string {
+	g.locals++
+	return prefix + strconv.Itoa(g.locals)
+}
+
+// pattern returns the variable holding the compiled pattern src.
+func (g *generator) pattern(src string) string {
+	g.imports["regexp"] = true
+	re, ok := g.patterns[src]
+	if !ok {
+		re = g.prefix + "Pattern" + strconv.Itoa(len(g.patterns))
+		g.patterns[src] = re
+	}
+	return re
+}
+
+// file assembles the generated file and formats it.
+func (g *generator) file(pkg string) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by oapi-codegen-validator. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+
+	if strings.Contains(g.body.String(), "openapi_types.") {
+		g.imports[typesPkg] = true
+	}
+	if len(g.imports) > 0 {
+		b.WriteString("import (\n")
+		// Standard library first, grouped as goimports does.
+		var std, other []string
+		for _, imp := range slices.Sorted(maps.Keys(g.imports)) {
+			if strings.Contains(imp, ".") {
+				other = append(other, imp)
+			} else {
+				std = append(std, imp)
+			}
+		}
+		writeImports(&b, std)
+		if len(std) > 0 && len(other) > 0 {
+			b.WriteString("\n")
+		}
+		writeImports(&b, other)
+		b.WriteString(")\n\n")
+	}
+
+	if len(g.patterns) > 0 {
+		b.WriteString("var (\n")
+		for _, pattern := range slices.SortedFunc(maps.Keys(g.patterns), func(a, b string) int {
+			return strings.Compare(g.patterns[a], g.patterns[b])
+		}) {
+			fmt.Fprintf(&b, "%s = regexp.MustCompile(%q)\n", g.patterns[pattern], pattern)
+		}
+		b.WriteString(")\n\n")
+	}
+
+	b.WriteString(g.body.String())
+	for _, f := range slices.Sorted(maps.Keys(g.formats)) {
+		fmt.Fprintf(&b, formatFuncs[f], g.prefix)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// writeImports writes import specs: paths, quoted, or named imports such as
+// `openapi_types "github.com/oapi-codegen/runtime/types"`, as is.
+func writeImports(b *bytes.Buffer, imports []string) {
+	for _, imp := range imports {
+		if strings.Contains(imp, " ") {
+			fmt.Fprintf(b, "%s\n", imp)
+		} else {
+			fmt.Fprintf(b, "%q\n", imp)
+		}
+	}
+}
+
+// componentName returns the name of the component schema a $ref points to.
+func componentName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func isObject(s *openapi3.Schema) bool {
+	return s.Type.Is(openapi3.TypeObject) || len(s.Properties) > 0
+}
+
+// isPointer reports whether oapi-codegen generates the field of property prop of parent
+// as a pointer.
+func isPointer(parent *openapi3.Schema, prop string, s *openapi3.Schema) bool {
+	if skip, _ := s.Extensions["x-go-type-skip-optional-pointer"].(bool); skip {
+		return false
+	}
+	return !slices.Contains(parent.Required, prop) || s.Nullable
+}
+
+func isSensitive(s *openapi3.Schema) bool {
+	sensitive, _ := s.Extensions["x-sensitive"].(bool)
+	return sensitive || s.Format == "password"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// numberBound is a value next to a bound of a number, allowed by it or not.
+type numberBound struct {
+	value float64
+	valid bool
+}
+
+// numberBounds returns the values on either side of the minimum and maximum of the
+// integer or number s. The bounds of an integer are rounded to the integers they allow,
+// as the rules round them, and those of a number stepped by the unit of their last
+// decimal. The values a float32 field rounds across a bound are left out.
+func numberBounds(s *openapi3.Schema) []numberBound {
+	integer := s.Type.Is(openapi3.TypeInteger) && !s.Type.Is(openapi3.TypeNumber)
+	next := func(v float64, up bool) float64 {
+		_, frac, _ := strings.Cut(formatFloat(v), ".")
+		step := math.Pow10(-len(frac))
+		if !up {
+			step = -step
+		}
+		v, _ = strconv.ParseFloat(strconv.FormatFloat(v+step, 'f', len(frac), 64), 64)
+		return v
+	}
+	allowed := func(v float64) bool {
+		return (s.Min == nil || v > *s.Min || v == *s.Min && !s.ExclusiveMin) &&
+			(s.Max == nil || v < *s.Max || v == *s.Max && !s.ExclusiveMax)
+	}
+	var bs []numberBound
+	add := func(v float64, valid bool) {
+		if !integer && s.Format != "double" && allowed(float64(float32(v))) != valid {
+			return
+		}
+		bs = append(bs, numberBound{value: v, valid: valid})
+	}
+	if s.Min != nil {
+		// The greatest rejected value and the least allowed one.
+		out, in := next(*s.Min, false), *s.Min
+		switch {
+		case integer:
+			in = math.Ceil(*s.Min)
+			if s.ExclusiveMin && in == *s.Min {
+				in++
+			}
+			out = in - 1
+		case s.ExclusiveMin:
+			out, in = *s.Min, next(*s.Min, true)
+		}
+		add(out, false)
+		add(in, true)
+	}
+	if s.Max != nil {
+		// The greatest allowed value and the least rejected one.
+		in, out := *s.Max, next(*s.Max, true)
+		switch {
+		case integer:
+			in = math.Floor(*s.Max)
+			if s.ExclusiveMax && in == *s.Max {
+				in--
+			}
+			out = in + 1
+		case s.ExclusiveMax:
+			in, out = next(*s.Max, false), *s.Max
+		}
+		add(in, true)
+		add(out, false)
+	}
+	return bs
+}
+
+// path is a Go expression building the JSON path of a field: a variable followed by
+// a literal suffix.
+type path struct {
+	base, lit string
+}
+
+func (p path) add(s string) path {
+	p.lit += s
+	return p
+}
+
+func (p path) String() string {
+	switch {
+	case p.lit == "":
+		return p.base
+	case p.base == "":
+		return strconv.Quote(p.lit)
+	default:
+		return p.base + " + " + strconv.Quote(p.lit)
+	}
+}
+
+// typeName returns the Go type oapi-codegen generates for a component schema.
+func typeName(ref string) string {
+	return naming.TypeName(componentName(ref))
+}
+
+// schemas yields every schema of the document's components and operations once, nested
+// schemas included.
+func schemas(doc *openapi3.T) iter.Seq[*openapi3.Schema] {
+	return func(yield func(*openapi3.Schema) bool) {
+		seen := make(map[*openapi3.Schema]bool)
+		var walk func(ref *openapi3.SchemaRef) bool
+		walk = func(ref *openapi3.SchemaRef) bool {
+			if ref == nil || ref.Value == nil || seen[ref.Value] {
+				return true
+			}
+			s := ref.Value
+			seen[s] = true
+			if !yield(s) {
+				return false
+			}
+			children := []*openapi3.SchemaRef{s.Items, s.AdditionalProperties.Schema, s.Not}
+			for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+				children = append(children, s.Properties[name])
+			}
+			children = append(children, s.AllOf...)
+			children = append(children, s.OneOf...)
+			children = append(children, s.AnyOf...)
+			for _, child := range children {
+				if !walk(child) {
+					return false
+				}
+			}
+			return true
+		}
+
+		var roots []*openapi3.SchemaRef
+		if c := doc.Components; c != nil {
+			for _, name := range slices.Sorted(maps.Keys(c.Schemas)) {
+				roots = append(roots, c.Schemas[name])
+			}
+			for _, name := range slices.Sorted(maps.Keys(c.Parameters)) {
+				if p := c.Parameters[name].Value; p != nil {
+					roots = append(roots, p.Schema)
+				}
+			}
+			for _, name := range slices.Sorted(maps.Keys(c.Headers)) {
+				if h := c.Headers[name].Value; h != nil {
+					roots = append(roots, h.Schema)
+				}
+			}
+		}
+		if doc.Paths != nil {
+			for _, path := range doc.Paths.InMatchingOrder() {
+				for _, op := range doc.Paths.Value(path).Operations() {
+					for _, p := range op.Parameters {
+						if p.Value != nil {
+							roots = append(roots, p.Value.Schema)
+						}
+					}
+					if op.RequestBody != nil && op.RequestBody.Value != nil {
+						for _, ct := range slices.Sorted(maps.Keys(op.RequestBody.Value.Content)) {
+							roots = append(roots, op.RequestBody.Value.Content[ct].Schema)
+						}
+					}
+				}
+			}
+		}
+		for _, root := range roots {
+			if !walk(root) {
+				return
+			}
+		}
+	}
+}
+
+// validateTag returns the validate rules the enricher set on s.
+func validateTag(s *openapi3.Schema) string {
+	return extensionTag(s.Extensions)
+}
+
+// parameterTag returns the validate rules the enricher set on p, in its own extensions
+// rather than its schema's, where oapi-codegen reads those of the Params fields.
+func parameterTag(p *openapi3.Parameter) string {
+	return extensionTag(p.Extensions)
+}
+
+func extensionTag(extensions map[string]any) string {
+	tags, _ := extensions["x-oapi-codegen-extra-tags"].(map[string]any)
+	tag, _ := tags["validate"].(string)
+	return rules.UnquoteTag(tag)
+}
+
+// parameters yields every parameter of the document's components, paths and operations
+// once.
+func parameters(doc *openapi3.T) iter.Seq[*openapi3.Parameter] {
+	return func(yield func(*openapi3.Parameter) bool) {
+		seen := make(map[*openapi3.Parameter]bool)
+		var all []*openapi3.ParameterRef
+		if doc.Components != nil {
+			for _, name := range slices.Sorted(maps.Keys(doc.Components.Parameters)) {
+				all = append(all, doc.Components.Parameters[name])
+			}
+		}
+		if doc.Paths != nil {
+			for _, path := range doc.Paths.InMatchingOrder() {
+				item := doc.Paths.Value(path)
+				all = append(all, item.Parameters...)
+				for _, op := range item.Operations() {
+					all = append(all, op.Parameters...)
+				}
+			}
+		}
+		for _, ref := range all {
+			if ref == nil || ref.Value == nil || seen[ref.Value] {
+				continue
+			}
+			seen[ref.Value] = true
+			if !yield(ref.Value) {
+				return
+			}
+		}
+	}
+}
+
+// operationParameters returns the parameters of the operations of doc with an operation
+// ID, those of their path included, by operation ID. The parameters of an operation
+// override those of its path with the same name and location.
+func operationParameters(doc *openapi3.T) map[string][]*openapi3.Parameter {
+	params := make(map[string][]*openapi3.Parameter)
+	if doc.Paths == nil {
+		return params
+	}
+	for _, item := range doc.Paths.Map() {
+		for _, op := range item.Operations() {
+			if op.OperationID == "" {
+				continue
+			}
+			var ps []*openapi3.Parameter
+			for _, ref := range slices.Concat(op.Parameters, item.Parameters) {
+				if ref == nil || ref.Value == nil || ref.Value.Schema == nil || ref.Value.Schema.Value == nil {
+					continue
+				}
+				if !slices.ContainsFunc(ps, func(p *openapi3.Parameter) bool { return p.Name == ref.Value.Name && p.In == ref.Value.In }) {
+					ps = append(ps, ref.Value)
+				}
+			}
+			if len(ps) > 0 {
+				params[op.OperationID] = ps
+			}
+		}
+	}
+	return params
+}
+
+// goType returns the Go type oapi-codegen generates for property prop of the struct typ.
+// Inline enums of array items, and the types of formats without a literal syntax, such
+// as uuid or date-time, are not supported.
+func (g *generator) goType(typ, prop string, ref *openapi3.SchemaRef) (string, bool) {
+	if ref == nil || ref.Value == nil {
+		return "", false
+	}
+	if ref.Ref != "" {
+		return typeName(ref.Ref), true
+	}
+	s := ref.Value
+	switch {
+	case len(s.Enum) > 0:
+		if prop == "" || !s.Type.Is(openapi3.TypeString) {
+			return "", false
+		}
+		return typ + naming.TypeName(prop), true
+	case s.Type.Is(openapi3.TypeString):
+		switch {
+		case s.Format == "email":
+			return "openapi_types.Email", true
+		case isString(s):
+			return "string", true
+		}
+	case s.Type.Is(openapi3.TypeInteger):
+		switch s.Format {
+		case "int32", "int64":
+			return s.Format, true
+		}
+		return "int", true
+	case s.Type.Is(openapi3.TypeNumber):
+		if s.Format == "double" {
+			return "float64", true
+		}
+		return "float32", true
+	case s.Type.Is(openapi3.TypeBoolean):
+		return "bool", true
+	case s.Type.Is(openapi3.TypeArray):
+		if elem, ok := g.goType("", "", s.Items); ok {
+			return "[]" + elem, true
+		}
+	}
+	return "", false
+}
+
+// typed converts the expression x, of type def, to typ.
+func typed(typ, x, def string) string {
+	if typ == def {
+		return x
+	}
+	return typ + "(" + x + ")"
+}
@@ -0,0 +1,146 @@
+package codegen
+
+import (
+	"cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperations(t *testing.T) {
+	runDir(t, "testdata/operations", Operations)
+}
+
+func TestRegistrations(t *testing.T) {
+	runDir(t, "testdata/registrations", Registrations)
+}
+
+func TestModels(t *testing.T) {
+	runDir(t, "testdata/models", Models)
+}
+
+func TestTests(t *testing.T) {
+	runDir(t, "testdata/tests", Tests)
+}
+
+func TestProblems(t *testing.T) {
+	runDir(t, "testdata/problems", Problems)
+}
+
+func TestProblemsExternalModel(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "errors.yaml"), []byte(`
+components:
+  schemas:
+    Problem:
+      type: object
+      properties:
+        title: {type: string}
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api.yaml"), []byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /users:
+    post:
+      operationId: createUser
+      requestBody:
+        content:
+          application/json:
+            schema: {type: object}
+      responses:
+        "400":
+          description: Invalid
+          content:
+            application/json:
+              schema: {$ref: 'errors.yaml#/components/schemas/Problem'}
+`), 0o644))
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	doc, err := loader.LoadFromFile(filepath.Join(dir, "api.yaml"))
+	require.NoError(t, err)
+
+	_, err = Problems(doc, "api")
+	assert.EqualError(t, err, "no error model: Problem is not a component schema of the spec")
+}
+
+func TestStructs(t *testing.T) {
+	runDir(t, "testdata/structs", Structs)
+}
+
+func TestContract(t *testing.T) {
+	runDir(t, "testdata/contract", Contract)
+}
+
+func TestManifest(t *testing.T) {
+	for _, format := range []string{"json", "ts"} {
+		runDir(t, "testdata/manifest", func(doc *openapi3.T, _ string) ([]byte, error) {
+			return Manifest(doc, format)
+		}, ".expected."+format)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var b strings.Builder
+	for _, c := range Diff(loadDoc(t, "testdata/diff/users.old.yaml"), loadDoc(t, "testdata/diff/users.new.yaml")) {
+		b.WriteString(c.String() + "\n")
+	}
+	expected, err := os.ReadFile("testdata/diff/users.expected.txt")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), b.String())
+}
+
+func TestChangelog(t *testing.T) {
+	oldDoc, newDoc := loadDoc(t, "testdata/diff/users.old.yaml"), loadDoc(t, "testdata/diff/users.new.yaml")
+	for format, ext := range map[string]string{"json": "json", "markdown": "md"} {
+		src, err := Changelog(oldDoc, newDoc, format)
+		require.NoError(t, err)
+		expected, err := os.ReadFile("testdata/diff/users.changelog." + ext)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), string(src))
+	}
+}
+
+func loadDoc(t *testing.T, path string) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromFile(path)
+	require.NoError(t, err, "failed to load %s", path)
+	return doc
+}
+
+// runDir compares the output of generate for the inputs of dir with the files of the
+// same name with the extension ext, ".expected.go" by default.
+func runDir(t *testing.T, dir string, generate func(*openapi3.T, string) ([]byte, error), ext ...string) {
+	t.Helper()
+	inputs, err := filepath.Glob(filepath.Join(dir, "*.input.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, inputs, "no test input files found in %s", dir)
+
+	for _, inputPath := range inputs {
+		base := strings.TrimSuffix(filepath.Base(inputPath), ".input.yaml")
+		t.Run(base, func(t *testing.T) {
+			doc, err := openapi3.NewLoader().LoadFromFile(inputPath)
+			require.NoError(t, err, "failed to load %s", inputPath)
+
+			src, err := generate(doc, "api")
+			require.NoError(t, err)
+
+			expected, err := os.ReadFile(filepath.Join(dir, base+cmp.Or(strings.Join(ext, ""), ".expected.go")))
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(src))
+		})
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	for _, format := range []string{"json", "txt"} {
+		runDir(t, "testdata/coverage", func(doc *openapi3.T, _ string) ([]byte, error) {
+			return CoverageReport(doc, cmp.Or(map[string]string{"txt": "text"}[format], format))
+		}, ".expected."+format)
+	}
+}
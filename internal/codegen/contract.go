@@ -0,0 +1,335 @@
+package codegen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+)
+
+// Contract generates a test harness checking that a deployed service enforces the rules
+// of the spec. For each operation with a JSON body it sends a valid payload, built from
+// the examples and constraints of the spec, then payloads changing one property to the
+// boundaries of its constraints, expecting a 2xx status for the valid ones and 400 for the
+// others. The service is the one at the URL in the CONTRACT_BASE_URL environment variable,
+// without which the tests are skipped; CONTRACT_AUTHORIZATION, when set, is sent as the
+// Authorization header.
+func Contract(doc *openapi3.T, pkg string) ([]byte, error) {
+	g := newGenerator(doc, "contract")
+	g.imports["net/http"] = true
+	g.imports["os"] = true
+	g.imports["strings"] = true
+	g.imports["testing"] = true
+	g.body.WriteString(contractHelpers)
+
+	for _, id := range g.operationIDs() {
+		method, path := g.route(id)
+		op := g.operation(id)
+		g.printf("func TestContract%s(t *testing.T) {\n", naming.TypeName(id))
+		target, cases, err := contractCases(path, op)
+		if err != nil {
+			g.printf("t.Skip(%q)\n}\n\n", fmt.Sprintf("no valid %s request can be built from the spec: %v", id, err))
+			continue
+		}
+		g.printf("for _, tc := range []contractCase{\n")
+		for _, c := range cases {
+			g.printf("{%q, %s, %t},\n", c.name, rawString(c.body), c.valid)
+		}
+		g.printf("} {\nt.Run(tc.name, func(t *testing.T) {\ncontractSend(t, %q, %q, tc.body, tc.valid)\n})\n}\n}\n\n", method, target)
+	}
+	return g.file(pkg)
+}
+
+var contractHelpers = `type contractCase struct {
+	name, body string
+	valid      bool
+}
+
+// contractSend sends body to the service at CONTRACT_BASE_URL, expecting a 2xx status
+// when valid and 400 otherwise.
+func contractSend(t *testing.T, method, path, body string, valid bool) {
+	t.Helper()
+	base := os.Getenv("CONTRACT_BASE_URL")
+	if base == "" {
+		t.Skip("CONTRACT_BASE_URL is not set")
+	}
+	req, err := http.NewRequestWithContext(t.Context(), method, strings.TrimSuffix(base, "/")+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth := os.Getenv("CONTRACT_AUTHORIZATION"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case valid && resp.StatusCode/100 != 2:
+		t.Errorf("%s %s: valid payload answered %s", method, path, resp.Status)
+	case !valid && resp.StatusCode != http.StatusBadRequest:
+		t.Errorf("%s %s: invalid payload answered %s, want 400 Bad Request", method, path, resp.Status)
+	}
+}
+
+`
+
+// route returns the method and path template of the operation id.
+func (g *generator) route(id string) (method, path string) {
+	for path, item := range g.doc.Paths.Map() {
+		for method, op := range item.Operations() {
+			if op.OperationID == id {
+				return method, path
+			}
+		}
+	}
+	return "", ""
+}
+
+// payload is a body sent to an operation, expected to be accepted or not.
+type payload struct {
+	name, body string
+	valid      bool
+}
+
+// contractCases returns the URL of a request to op, at path, and the payloads to send.
+func contractCases(path string, op *openapi3.Operation) (string, []payload, error) {
+	target, err := contractTarget(path, op.Parameters)
+	if err != nil {
+		return "", nil, err
+	}
+	mt := jsonMediaType(op.RequestBody.Value)
+	if mt == nil || mt.Schema == nil || mt.Schema.Value == nil || !isObject(mt.Schema.Value) {
+		return "", nil, errors.New("no JSON object body")
+	}
+	s := mt.Schema.Value
+	valid, err := jsonSample(mt.Schema, map[*openapi3.Schema]bool{})
+	if err != nil {
+		return "", nil, err
+	}
+	body := valid.(map[string]any)
+
+	cases := []payload{{name: "valid", body: encodeJSON(body), valid: true}}
+	for _, prop := range slices.Sorted(maps.Keys(s.Properties)) {
+		ref := s.Properties[prop]
+		if ref.Value == nil {
+			continue
+		}
+		for _, b := range boundaries(ref, isPointer(s, prop, ref.Value), slices.Contains(s.Required, prop)) {
+			changed := maps.Clone(body)
+			if b.missing {
+				delete(changed, prop)
+			} else {
+				changed[prop] = b.value
+			}
+			cases = append(cases, payload{name: prop + " " + b.name, body: encodeJSON(changed), valid: b.valid})
+		}
+	}
+	return target, cases, nil
+}
+
+// contractTarget fills the path parameters of path, and its required query parameters,
+// with the examples of the spec.
+func contractTarget(path string, params openapi3.Parameters) (string, error) {
+	query := url.Values{}
+	for _, ref := range params {
+		p := ref.Value
+		if p == nil || !p.Required && p.In != openapi3.ParameterInPath {
+			continue
+		}
+		var v any = p.Example
+		if v == nil && p.Schema != nil {
+			var err error
+			if v, err = jsonSample(p.Schema, map[*openapi3.Schema]bool{}); err != nil {
+				return "", fmt.Errorf("parameter %s: %w", p.Name, err)
+			}
+		}
+		switch p.In {
+		case openapi3.ParameterInPath:
+			path = strings.ReplaceAll(path, "{"+p.Name+"}", url.PathEscape(fmt.Sprint(v)))
+		case openapi3.ParameterInQuery:
+			query.Set(p.Name, fmt.Sprint(v))
+		default:
+			return "", fmt.Errorf("required %s parameter %s", p.In, p.Name)
+		}
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
+
+func jsonMediaType(body *openapi3.RequestBody) *openapi3.MediaType {
+	for _, ct := range slices.Sorted(maps.Keys(body.Content)) {
+		if ct == "application/json" || strings.HasSuffix(ct, "+json") {
+			return body.Content[ct]
+		}
+	}
+	return nil
+}
+
+// boundary is a value of a property, or its absence, expected to be valid or not.
+type boundary struct {
+	name    string
+	value   any
+	missing bool
+	valid   bool
+}
+
+// boundaries returns the boundary values of a property of schema ref, as the boundary
+// cases of Tests: zero values of fields that are not pointers are left out, as the
+// required and omitempty rules decide on them rather than the constraints.
+func boundaries(ref *openapi3.SchemaRef, pointer, required bool) []boundary {
+	s := ref.Value
+	// A required number allowing 0 is not tagged required: a missing value decodes to 0,
+	// which its rules check.
+	zeroAllowed := required && !pointer && rules.ZeroAllowed(schema.FromKin(s))
+	var bs []boundary
+	add := func(name string, value any, zero, valid bool) {
+		if zero && !pointer && (!required || valid && !zeroAllowed) {
+			return
+		}
+		bs = append(bs, boundary{name: name, value: value, valid: valid})
+	}
+	if required && !zeroAllowed {
+		bs = append(bs, boundary{name: "missing", missing: true})
+	}
+
+	switch {
+	case s.Type.Is(openapi3.TypeString) && len(s.Enum) == 0 && s.Pattern == "":
+		if bad, ok := malformed[s.Format]; ok {
+			add("malformed", bad, false, false)
+			break
+		}
+		if !isString(s) {
+			break
+		}
+		length := func(n uint64, valid bool) {
+			add(fmt.Sprintf("of length %d", n), strings.Repeat("a", int(n)), n == 0, valid)
+		}
+		if s.MinLength > 0 {
+			length(s.MinLength-1, false)
+			length(s.MinLength, true)
+		}
+		if s.MaxLength != nil {
+			length(*s.MaxLength, true)
+			length(*s.MaxLength+1, false)
+		}
+
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		for _, b := range numberBounds(s) {
+			add("of "+formatFloat(b.value), b.value, b.value == 0, b.valid)
+		}
+
+	case s.Type.Is(openapi3.TypeArray) && !s.UniqueItems:
+		item, err := jsonSample(s.Items, map[*openapi3.Schema]bool{})
+		if err != nil {
+			break
+		}
+		size := func(n uint64, valid bool) {
+			add(fmt.Sprintf("of %d items", n), slices.Repeat([]any{item}, int(n)), n == 0, valid)
+		}
+		if s.MinItems > 0 {
+			size(s.MinItems-1, false)
+			size(s.MinItems, true)
+		}
+		if s.MaxItems != nil {
+			size(*s.MaxItems, true)
+			size(*s.MaxItems+1, false)
+		}
+	}
+	return bs
+}
+
+// jsonSample returns a valid JSON value of schema ref, objects holding their required
+// properties. The schemas on path are being sampled, to reject recursive ones.
+func jsonSample(ref *openapi3.SchemaRef, path map[*openapi3.Schema]bool) (any, error) {
+	if ref == nil || ref.Value == nil {
+		return nil, errors.New("unresolved schema")
+	}
+	s := ref.Value
+	if isObject(s) {
+		if path[s] {
+			return nil, errors.New("recursive schema")
+		}
+		path[s] = true
+		defer delete(path, s)
+		obj := make(map[string]any)
+		for _, prop := range s.Required {
+			v, err := jsonSample(s.Properties[prop], path)
+			if err != nil {
+				return nil, fmt.Errorf("property %s: %w", prop, err)
+			}
+			obj[prop] = v
+		}
+		return obj, nil
+	}
+
+	if len(s.Enum) > 0 {
+		return s.Enum[0], nil
+	}
+	if s.Example != nil {
+		return s.Example, nil
+	}
+	switch {
+	case s.Type.Is(openapi3.TypeString):
+		if s.Pattern != "" {
+			return nil, errors.New("pattern without example")
+		}
+		if v, ok := wellFormed[s.Format]; ok {
+			n := uint64(len(v))
+			if n < s.MinLength || s.MaxLength != nil && n > *s.MaxLength {
+				return nil, fmt.Errorf("length of %s without example", s.Format)
+			}
+			return v, nil
+		}
+		if !isString(s) {
+			return nil, fmt.Errorf("format %s without example", s.Format)
+		}
+		return strings.Repeat("a", int(max(s.MinLength, 1))), nil
+
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		return sampleNumber(s)
+
+	case s.Type.Is(openapi3.TypeBoolean):
+		return true, nil
+
+	case s.Type.Is(openapi3.TypeArray):
+		if s.MinItems == 0 {
+			return []any{}, nil
+		}
+		if s.UniqueItems {
+			return nil, errors.New("unique items without example")
+		}
+		item, err := jsonSample(s.Items, path)
+		if err != nil {
+			return nil, err
+		}
+		return slices.Repeat([]any{item}, int(s.MinItems)), nil
+	}
+	return nil, errors.New("unsupported type")
+}
+
+func encodeJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// rawString returns a Go string literal of s, raw when possible for readability.
+func rawString(s string) string {
+	if strings.ContainsAny(s, "`\r") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
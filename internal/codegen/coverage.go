@@ -0,0 +1,145 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// coverageWeights weighs fields by type in coverage scores: unbounded strings and arrays
+// are the riskiest inputs, while booleans and objects, whose fields are scored instead,
+// have nothing to constrain and are left out.
+var coverageWeights = map[string]float64{
+	openapi3.TypeString:  3,
+	openapi3.TypeArray:   2,
+	openapi3.TypeInteger: 1,
+	openapi3.TypeNumber:  1,
+}
+
+// presenceRules only decide on the presence of a field, they don't constrain its value.
+var presenceRules = []string{"required", "omitempty", "omitnil", "omitzero"}
+
+// Coverage scores the validation of an enriched spec: the weighted fraction of its fields
+// with at least one rule constraining their value, from 0 to 1.
+type Coverage struct {
+	Score float64 `json:"score"`
+	// Schemas holds the coverage of the object component schemas, of the inline request
+	// bodies as "operation" followed by the operation ID, and of the parameters of the
+	// operations as "operation" followed by the operation ID and "parameters", by name.
+	Schemas map[string]*SchemaCoverage `json:"schemas"`
+}
+
+// SchemaCoverage is the coverage of the own fields of a schema, those of the inline
+// objects it holds included, see Diff.
+type SchemaCoverage struct {
+	Score         float64  `json:"score"`
+	Fields        int      `json:"fields"`
+	Constrained   int      `json:"constrained"`
+	Unconstrained []string `json:"unconstrained,omitempty"`
+
+	weight, covered float64
+}
+
+// NewCoverage returns the validation coverage of doc. Schemas without fields to
+// constrain score 1.
+func NewCoverage(doc *openapi3.T) *Coverage {
+	c := &Coverage{Schemas: make(map[string]*SchemaCoverage)}
+	var weight, covered float64
+	for name, s := range ruleSchemas(doc) {
+		sc := &SchemaCoverage{}
+		sc.addFields("", s)
+		sc.Score = score(sc.covered, sc.weight)
+		c.Schemas[name] = sc
+		weight += sc.weight
+		covered += sc.covered
+	}
+	for id, params := range operationParameters(doc) {
+		sc := &SchemaCoverage{}
+		for _, p := range params {
+			rules, _ := manifestRules(parameterTag(p), p.Schema.Value)
+			sc.add(p.Name, p.Schema.Value, rules)
+		}
+		sc.Score = score(sc.covered, sc.weight)
+		c.Schemas[parametersName(id)] = sc
+		weight += sc.weight
+		covered += sc.covered
+	}
+	c.Score = score(covered, weight)
+	return c
+}
+
+func score(covered, weight float64) float64 {
+	if weight == 0 {
+		return 1
+	}
+	return covered / weight
+}
+
+// addFields scores the fields of s, at path prefix, descending into inline objects and
+// into the primitive items of arrays.
+func (sc *SchemaCoverage) addFields(prefix string, s *openapi3.Schema) {
+	for _, prop := range slices.Sorted(maps.Keys(s.Properties)) {
+		ref := s.Properties[prop]
+		if ref.Value == nil {
+			continue
+		}
+		field := prefix + prop
+		rules, items := manifestRules(validateTag(ref.Value), ref.Value)
+		sc.add(field, ref.Value, rules)
+		if ref.Value.Type.Is(openapi3.TypeArray) && ref.Value.Items != nil && ref.Value.Items.Value != nil {
+			field, ref = field+"[]", ref.Value.Items
+			sc.add(field, ref.Value, items)
+		}
+		if ref.Ref == "" && isObject(ref.Value) {
+			sc.addFields(field+".", ref.Value)
+		}
+	}
+}
+
+func (sc *SchemaCoverage) add(field string, s *openapi3.Schema, rules []ManifestRule) {
+	w := 0.0
+	if types := s.Type.Slice(); len(types) > 0 {
+		w = coverageWeights[types[0]]
+	}
+	if w == 0 {
+		return
+	}
+	sc.Fields++
+	sc.weight += w
+	if slices.ContainsFunc(rules, func(r ManifestRule) bool { return !slices.Contains(presenceRules, r.Rule) }) {
+		sc.Constrained++
+		sc.covered += w
+	} else {
+		sc.Unconstrained = append(sc.Unconstrained, field)
+	}
+}
+
+// CoverageReport reports the validation coverage of doc, formatted as JSON or as text,
+// depending on format.
+func CoverageReport(doc *openapi3.T, format string) ([]byte, error) {
+	c := NewCoverage(doc)
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case "text":
+		var b bytes.Buffer
+		for _, name := range slices.Sorted(maps.Keys(c.Schemas)) {
+			sc := c.Schemas[name]
+			fmt.Fprintf(&b, "%5.1f%%  %-30s %d/%d fields constrained\n", 100*sc.Score, name, sc.Constrained, sc.Fields)
+			for _, field := range sc.Unconstrained {
+				fmt.Fprintf(&b, "        - %s\n", field)
+			}
+		}
+		fmt.Fprintf(&b, "%5.1f%%  total\n", 100*c.Score)
+		return b.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown coverage format %q", format)
+}
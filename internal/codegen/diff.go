@@ -0,0 +1,294 @@
+package codegen
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// RuleChange is a change of the validation rules of a field between two revisions of an
+// enriched spec.
+type RuleChange struct {
+	// Schema is the name of the component schema, or "operation" followed by the
+	// operation ID for inline request bodies, and by "parameters" for parameters.
+	Schema string `json:"schema"`
+	// Field is the JSON path of the field, as in the constraint manifest.
+	Field string `json:"field,omitempty"`
+	// Change describes the change, e.g. "max tightened from 50 to 20".
+	Change string `json:"change"`
+	// Breaking reports whether requests valid against the old revision may be rejected
+	// by the new one or, for a response-only field, whether responses may hold values the
+	// old one rejected, which clients may not expect.
+	Breaking bool `json:"breaking"`
+}
+
+func (c RuleChange) String() string {
+	kind := "non-breaking"
+	if c.Breaking {
+		kind = "BREAKING"
+	}
+	if c.Field == "" {
+		return fmt.Sprintf("%-12s %s: %s", kind, c.Schema, c.Change)
+	}
+	return fmt.Sprintf("%-12s %s.%s: %s", kind, c.Schema, c.Field, c.Change)
+}
+
+// Diff compares the validate rules of the fields of two revisions of an enriched spec,
+// classifying each change as breaking when it tightens the rules, e.g. a field becoming
+// required or a maximum decreasing, or, for the response-only fields marked readOnly,
+// when it relaxes them. Rules the validator checks alike, min and gte, max and lte, are
+// the same rule. Schemas are compared on their own fields: those of the schemas they
+// reference are reported under the referenced schema.
+func Diff(oldDoc, newDoc *openapi3.T) []RuleChange {
+	before, after := diffFields(oldDoc), diffFields(newDoc)
+	var changes []RuleChange
+	for _, schema := range slices.Sorted(maps.Keys(before)) {
+		if _, ok := after[schema]; !ok {
+			changes = append(changes, RuleChange{Schema: schema, Change: "schema removed", Breaking: true})
+		}
+	}
+	for _, schema := range slices.Sorted(maps.Keys(after)) {
+		old, ok := before[schema]
+		if !ok {
+			changes = append(changes, RuleChange{Schema: schema, Change: "schema added"})
+			continue
+		}
+		changes = append(changes, diffSchema(schema, old, after[schema])...)
+	}
+	slices.SortStableFunc(changes, func(a, b RuleChange) int {
+		return cmp.Or(strings.Compare(a.Schema, b.Schema), strings.Compare(a.Field, b.Field))
+	})
+	return changes
+}
+
+// schemaFields are the fields of a rule schema, and those of them only in responses.
+type schemaFields struct {
+	fields   ManifestFields
+	readOnly map[string]bool
+}
+
+// diffFields returns the fields of the rule schemas of doc, by name.
+func diffFields(doc *openapi3.T) map[string]schemaFields {
+	schemas := make(map[string]schemaFields)
+	for name, s := range ruleSchemas(doc) {
+		fields := schemaFields{fields: make(ManifestFields), readOnly: make(map[string]bool)}
+		fields.add("", s, false)
+		schemas[name] = fields
+	}
+	for id, params := range operationParameters(doc) {
+		fields := schemaFields{fields: make(ManifestFields), readOnly: make(map[string]bool)}
+		for _, p := range params {
+			fields.fields[p.Name], _ = manifestRules(parameterTag(p), p.Schema.Value)
+		}
+		schemas[parametersName(id)] = fields
+	}
+	return schemas
+}
+
+// parametersName names the parameters of operation id among the rule schemas.
+func parametersName(id string) string {
+	return "operation " + id + " parameters"
+}
+
+// ruleSchemas returns the object component schemas of doc, by name, and its inline object
+// request bodies, named "operation" followed by the operation ID.
+func ruleSchemas(doc *openapi3.T) map[string]*openapi3.Schema {
+	schemas := make(map[string]*openapi3.Schema)
+	if doc.Components != nil {
+		for name, ref := range doc.Components.Schemas {
+			if ref.Value != nil && isObject(ref.Value) {
+				schemas[name] = ref.Value
+			}
+		}
+	}
+	g := newGenerator(doc, "")
+	for _, id := range g.operationIDs() {
+		mt := g.operation(id).RequestBody.Value.Content.Get("application/json")
+		if mt != nil && mt.Schema != nil && mt.Schema.Ref == "" && mt.Schema.Value != nil && isObject(mt.Schema.Value) {
+			schemas["operation "+id] = mt.Schema.Value
+		}
+	}
+	return schemas
+}
+
+// add adds the fields of s, at path prefix, those without rules included, descending
+// into inline objects but not into referenced schemas. The fields of a readOnly object
+// are response-only, as are those marked readOnly.
+func (f schemaFields) add(prefix string, s *openapi3.Schema, readOnly bool) {
+	for _, prop := range slices.Sorted(maps.Keys(s.Properties)) {
+		ref := s.Properties[prop]
+		if ref.Value == nil {
+			continue
+		}
+		field := prefix + prop
+		readOnly := readOnly || ref.Value.ReadOnly
+		rules, items := manifestRules(validateTag(ref.Value), ref.Value)
+		f.fields[field], f.readOnly[field] = rules, readOnly
+		if len(items) > 0 {
+			f.fields[field+"[]"], f.readOnly[field+"[]"] = items, readOnly
+		}
+		if ref.Value.Type.Is(openapi3.TypeArray) && ref.Value.Items != nil && ref.Value.Items.Value != nil {
+			field, ref = field+"[]", ref.Value.Items
+		}
+		if ref.Ref == "" && isObject(ref.Value) {
+			f.add(field+".", ref.Value, readOnly)
+		}
+	}
+}
+
+func diffSchema(schema string, beforeFields, afterFields schemaFields) []RuleChange {
+	before, after := beforeFields.fields, afterFields.fields
+	var changes []RuleChange
+	for _, field := range slices.Sorted(maps.Keys(before)) {
+		if _, ok := after[field]; !ok {
+			changes = append(changes, RuleChange{Schema: schema, Field: field, Change: "field removed"})
+		}
+	}
+	for _, field := range slices.Sorted(maps.Keys(after)) {
+		// Tightening the rules of a response-only field rejects no request.
+		responseOnly := afterFields.readOnly[field]
+		old, ok := before[field]
+		if ok {
+			changes = append(changes, diffRules(schema, field, old, after[field], responseOnly)...)
+			continue
+		}
+		// The fields of a new object are reported with it.
+		if parent := parentField(field); parent != "" {
+			if _, ok := before[parent]; !ok {
+				continue
+			}
+		}
+		if strings.HasSuffix(field, "[]") {
+			// The item rules of an existing array, e.g. after a dive.
+			for _, r := range after[field] {
+				changes = append(changes, RuleChange{Schema: schema, Field: field, Change: describeRule(ruleKey(r), r) + " added", Breaking: !responseOnly})
+			}
+			continue
+		}
+		if hasRule(after[field], "required") {
+			changes = append(changes, RuleChange{Schema: schema, Field: field, Change: "required field added", Breaking: !responseOnly})
+		} else {
+			changes = append(changes, RuleChange{Schema: schema, Field: field, Change: "optional field added"})
+		}
+	}
+	return changes
+}
+
+// parentField returns the path of the object or array holding field, empty for top-level
+// fields.
+func parentField(field string) string {
+	i := max(strings.LastIndex(field, "."), strings.LastIndex(field, "[]"))
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(field[:i], "[]")
+}
+
+func hasRule(rules []ManifestRule, name string) bool {
+	return slices.ContainsFunc(rules, func(r ManifestRule) bool { return r.Rule == name })
+}
+
+// sameRules are the rules the validator checks as others, by name: gte as min, and lte
+// as max, whatever the kind of the field.
+var sameRules = map[string]string{"gte": "min", "lte": "max"}
+
+// ruleKey identifies a rule within a field: its name, or the names of its alternatives.
+func ruleKey(r ManifestRule) string {
+	if len(r.AnyOf) == 0 {
+		return r.Rule
+	}
+	names := make([]string, len(r.AnyOf))
+	for i, alt := range r.AnyOf {
+		names[i] = alt.Rule + "=" + alt.Param
+	}
+	return strings.Join(names, "|")
+}
+
+// diffRules compares the rules of a field, responseOnly when it is only in responses,
+// where relaxing the rules rather than tightening them is breaking.
+func diffRules(schema, field string, before, after []ManifestRule, responseOnly bool) []RuleChange {
+	index := func(rules []ManifestRule) map[string]ManifestRule {
+		m := make(map[string]ManifestRule)
+		for _, r := range rules {
+			if r.Rule != "omitempty" {
+				key := ruleKey(r)
+				m[cmp.Or(sameRules[key], key)] = r
+			}
+		}
+		return m
+	}
+	old, cur := index(before), index(after)
+	change := func(desc string, tightened bool) RuleChange {
+		return RuleChange{Schema: schema, Field: field, Change: desc, Breaking: tightened != responseOnly}
+	}
+
+	var changes []RuleChange
+	for _, key := range slices.Sorted(maps.Keys(old)) {
+		if _, ok := cur[key]; ok {
+			continue
+		}
+		if key == "required" {
+			changes = append(changes, change("became optional", false))
+		} else {
+			changes = append(changes, change(describeRule(ruleKey(old[key]), old[key])+" removed", false))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(cur)) {
+		r := cur[key]
+		prev, ok := old[key]
+		switch {
+		case !ok && key == "required":
+			changes = append(changes, change("became required", true))
+		case !ok:
+			changes = append(changes, change(describeRule(ruleKey(r), r)+" added", true))
+		case prev.Param != r.Param:
+			changes = append(changes, paramChange(prev, r, change))
+		}
+	}
+	return changes
+}
+
+func describeRule(key string, r ManifestRule) string {
+	if r.Param == "" {
+		return key
+	}
+	return key + "=" + r.Param
+}
+
+// paramChange classifies the change of the parameter of rule from prev to r, change
+// taking whether it tightens the rule. Another change than of a bound or of the values
+// of an enum is breaking either way.
+func paramChange(prev, r ManifestRule, change func(string, bool) RuleChange) RuleChange {
+	from, to := prev.Param, r.Param
+	a, errA := strconv.ParseFloat(from, 64)
+	b, errB := strconv.ParseFloat(to, 64)
+	numeric := errA == nil && errB == nil
+	switch {
+	case numeric && slices.Contains([]string{"min", "gte", "gt"}, r.Rule):
+		if b > a {
+			return change(fmt.Sprintf("%s tightened from %s to %s", r.Rule, from, to), true)
+		}
+		return change(fmt.Sprintf("%s relaxed from %s to %s", r.Rule, from, to), false)
+	case numeric && slices.Contains([]string{"max", "lte", "lt"}, r.Rule):
+		if b < a {
+			return change(fmt.Sprintf("%s tightened from %s to %s", r.Rule, from, to), true)
+		}
+		return change(fmt.Sprintf("%s relaxed from %s to %s", r.Rule, from, to), false)
+	case r.Rule == "oneof" || r.Rule == "oneofnumber":
+		values := strings.Fields(to)
+		for _, v := range strings.Fields(from) {
+			if !slices.Contains(values, v) {
+				return change(fmt.Sprintf("%s narrowed from %q to %q", r.Rule, from, to), true)
+			}
+		}
+		return change(fmt.Sprintf("%s widened from %q to %q", r.Rule, from, to), false)
+	}
+	c := change(fmt.Sprintf("%s changed from %q to %q", r.Rule, from, to), true)
+	c.Breaking = true
+	return c
+}
@@ -0,0 +1,308 @@
+package codegen
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+)
+
+// exprValidations writes to w the struct-level validation of every model whose schema has
+// x-validate-expr rules, and returns their names by type. A rule is an expression on the
+// properties of the schema with Go syntax, e.g. "endDate > startDate" or
+// "len(tags) <= maxTags || admin"; it is skipped when an optional property it uses is
+// absent, and reported as the rule "expr" on the first property it uses.
+func (g *generator) exprValidations(w *strings.Builder) (map[string]string, error) {
+	fns := make(map[string]string)
+	models := g.modelTypes()
+	for _, typ := range slices.Sorted(maps.Keys(models)) {
+		s := models[typ]
+		var rules []string
+		switch ext := s.Extensions["x-validate-expr"].(type) {
+		case nil:
+			continue
+		case string:
+			rules = []string{ext}
+		case []any:
+			for _, r := range ext {
+				r, ok := r.(string)
+				if !ok {
+					return nil, fmt.Errorf("%s: x-validate-expr must hold strings", typ)
+				}
+				rules = append(rules, r)
+			}
+		default:
+			return nil, fmt.Errorf("%s: x-validate-expr must be a string or a list of strings", typ)
+		}
+
+		fn := g.prefix + "Expr" + typ
+		var checks strings.Builder
+		for _, rule := range rules {
+			if err := g.exprRule(&checks, s, rule); err != nil {
+				return nil, fmt.Errorf("%s: x-validate-expr %q: %w", typ, rule, err)
+			}
+		}
+		fmt.Fprintf(w, "func %s(sl validator.StructLevel) {\nm := sl.Current().Interface().(%s)\n%s}\n\n", fn, typ, checks.String())
+		fns[typ] = fn
+	}
+	return fns, nil
+}
+
+// exprRule writes the check of rule on the struct m of schema s.
+func (g *generator) exprRule(w *strings.Builder, s *openapi3.Schema, rule string) error {
+	e, err := parser.ParseExpr(rule)
+	if err != nil {
+		return err
+	}
+	c := &exprCompiler{g: g, s: s, guards: make(map[string]bool)}
+	x, err := c.compile(e)
+	if err != nil {
+		return err
+	}
+	if x.kind != kindBool {
+		return errors.New("not a condition")
+	}
+	if c.first == "" {
+		return errors.New("uses no property")
+	}
+
+	cond := "!(" + c.deref(x) + ")"
+	if len(c.guards) > 0 {
+		var guards []string
+		for _, field := range slices.Sorted(maps.Keys(c.guards)) {
+			guards = append(guards, field+" != nil")
+		}
+		cond = strings.Join(guards, " && ") + " && " + cond
+	}
+	field := "m." + naming.TypeName(c.first)
+	fmt.Fprintf(w, "if %s {\nsl.ReportError(%s, %q, %q, \"expr\", %q)\n}\n", cond, field, c.first, naming.TypeName(c.first), rule)
+	return nil
+}
+
+type exprKind int
+
+const (
+	kindBool exprKind = iota
+	kindNumber
+	kindString
+	kindTime
+	kindNil
+)
+
+// operand is a compiled expression: its Go source, whether it is typed rather than an
+// untyped literal, as typed values of different types need a conversion to be compared,
+// and the pointer field it dereferences, if any.
+type operand struct {
+	src     string
+	kind    exprKind
+	typed   bool
+	pointer string
+}
+
+type exprCompiler struct {
+	g *generator
+	s *openapi3.Schema
+	// guards holds the pointer fields the rule dereferences, and first the first
+	// property it uses.
+	guards map[string]bool
+	first  string
+}
+
+func (c *exprCompiler) compile(e ast.Expr) (operand, error) {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		x, err := c.compile(e.X)
+		if err != nil {
+			return operand{}, err
+		}
+		return operand{src: "(" + c.deref(x) + ")", kind: x.kind, typed: x.typed}, nil
+
+	case *ast.Ident:
+		switch e.Name {
+		case "true", "false":
+			return operand{src: e.Name, kind: kindBool}, nil
+		case "nil":
+			return operand{src: "nil", kind: kindNil}, nil
+		}
+		return c.property(e.Name)
+
+	case *ast.BasicLit:
+		switch e.Kind {
+		case token.INT, token.FLOAT:
+			return operand{src: e.Value, kind: kindNumber}, nil
+		case token.STRING:
+			v, err := strconv.Unquote(e.Value)
+			if err != nil {
+				return operand{}, err
+			}
+			return operand{src: strconv.Quote(v), kind: kindString}, nil
+		}
+
+	case *ast.UnaryExpr:
+		x, err := c.compile(e.X)
+		if err != nil {
+			return operand{}, err
+		}
+		switch {
+		case e.Op == token.NOT && x.kind == kindBool:
+			return operand{src: "!" + c.deref(x), kind: kindBool}, nil
+		case e.Op == token.SUB && x.kind == kindNumber:
+			return operand{src: "-" + c.deref(x), kind: kindNumber, typed: x.typed}, nil
+		}
+
+	case *ast.CallExpr:
+		if fn, ok := e.Fun.(*ast.Ident); ok && fn.Name == "len" && len(e.Args) == 1 {
+			return c.length(e.Args[0])
+		}
+
+	case *ast.BinaryExpr:
+		return c.binary(e)
+	}
+	return operand{}, fmt.Errorf("unsupported expression %s", types.ExprString(e))
+}
+
+// property returns the field of the property name.
+func (c *exprCompiler) property(name string) (operand, error) {
+	ref, ok := c.s.Properties[name]
+	if !ok || ref.Value == nil {
+		return operand{}, fmt.Errorf("unknown property %s", name)
+	}
+	if c.first == "" {
+		c.first = name
+	}
+	p := ref.Value
+	x := operand{src: "m." + naming.TypeName(name), typed: true}
+	if isPointer(c.s, name, p) {
+		x.pointer = x.src
+	}
+	switch {
+	case p.Type.Is(openapi3.TypeString) && p.Format == "date-time":
+		x.kind = kindTime
+	case isString(p):
+		x.kind = kindString
+	case p.Type.Is(openapi3.TypeInteger), p.Type.Is(openapi3.TypeNumber):
+		x.kind = kindNumber
+	case p.Type.Is(openapi3.TypeBoolean):
+		x.kind = kindBool
+	default:
+		return operand{}, fmt.Errorf("property %s: unsupported type", name)
+	}
+	return x, nil
+}
+
+// deref returns the value of x, guarding the rule against nil pointers.
+func (c *exprCompiler) deref(x operand) string {
+	if x.pointer == "" {
+		return x.src
+	}
+	c.guards[x.pointer] = true
+	return "(*" + x.src + ")"
+}
+
+func (c *exprCompiler) length(arg ast.Expr) (operand, error) {
+	ident, ok := arg.(*ast.Ident)
+	if !ok {
+		return operand{}, errors.New("len only applies to properties")
+	}
+	name := ident.Name
+	x, err := c.property(name)
+	if err == nil && x.kind == kindString {
+		c.g.imports["unicode/utf8"] = true
+		return operand{src: "utf8.RuneCountInString(string(" + c.deref(x) + "))", kind: kindNumber, typed: true}, nil
+	}
+	if ref := c.s.Properties[name]; ref != nil && ref.Value != nil && ref.Value.Type.Is(openapi3.TypeArray) {
+		if c.first == "" {
+			c.first = name
+		}
+		x := operand{src: "m." + naming.TypeName(name)}
+		if isPointer(c.s, name, ref.Value) {
+			x.pointer = x.src
+		}
+		return operand{src: "len(" + c.deref(x) + ")", kind: kindNumber, typed: true}, nil
+	}
+	return operand{}, fmt.Errorf("len of %s: not a string or an array", name)
+}
+
+func (c *exprCompiler) binary(e *ast.BinaryExpr) (operand, error) {
+	x, err := c.compile(e.X)
+	if err != nil {
+		return operand{}, err
+	}
+	y, err := c.compile(e.Y)
+	if err != nil {
+		return operand{}, err
+	}
+
+	switch e.Op {
+	case token.LAND, token.LOR:
+		if x.kind != kindBool || y.kind != kindBool {
+			return operand{}, fmt.Errorf("%s applies to conditions", e.Op)
+		}
+		return operand{src: c.deref(x) + " " + e.Op.String() + " " + c.deref(y), kind: kindBool}, nil
+	case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+	default:
+		return operand{}, fmt.Errorf("unsupported operator %s", e.Op)
+	}
+
+	// Presence checks: "endDate != nil".
+	if x.kind == kindNil || y.kind == kindNil {
+		if x.kind == kindNil {
+			x, y = y, x
+		}
+		if x.pointer == "" || (e.Op != token.EQL && e.Op != token.NEQ) {
+			return operand{}, errors.New("only optional properties can be compared with nil")
+		}
+		return operand{src: x.pointer + " " + e.Op.String() + " nil", kind: kindBool}, nil
+	}
+
+	if x.kind != y.kind {
+		return operand{}, fmt.Errorf("mismatched operands of %s", e.Op)
+	}
+	a, b := c.deref(x), c.deref(y)
+	switch x.kind {
+	case kindTime:
+		if !x.typed || !y.typed {
+			return operand{}, errors.New("date-times can only be compared with each other")
+		}
+		return operand{src: timeComparison(a, b, e.Op), kind: kindBool}, nil
+	case kindBool:
+		if e.Op != token.EQL && e.Op != token.NEQ {
+			return operand{}, fmt.Errorf("%s does not apply to booleans", e.Op)
+		}
+	case kindNumber:
+		if x.typed && y.typed {
+			a, b = "float64("+a+")", "float64("+b+")"
+		}
+	case kindString:
+		if x.typed && y.typed {
+			a, b = "string("+a+")", "string("+b+")"
+		}
+	}
+	return operand{src: a + " " + e.Op.String() + " " + b, kind: kindBool}, nil
+}
+
+// timeComparison compares the time.Time values a and b.
+func timeComparison(a, b string, op token.Token) string {
+	switch op {
+	case token.LSS:
+		return a + ".Before(" + b + ")"
+	case token.GTR:
+		return a + ".After(" + b + ")"
+	case token.LEQ:
+		return "!" + a + ".After(" + b + ")"
+	case token.GEQ:
+		return "!" + a + ".Before(" + b + ")"
+	case token.NEQ:
+		return "!" + a + ".Equal(" + b + ")"
+	}
+	return a + ".Equal(" + b + ")"
+}
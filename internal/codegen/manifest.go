@@ -0,0 +1,208 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+)
+
+// ConstraintManifest lists the validate rules of the fields of an enriched spec, for
+// clients to mirror the validation of the server. Fields are JSON paths from the schema,
+// e.g. "address.street" or "lines[].code", nested schemas included.
+type ConstraintManifest struct {
+	// Schemas holds the fields of the object component schemas, by name.
+	Schemas map[string]ManifestFields `json:"schemas"`
+	// Operations holds the request bodies, by operation ID.
+	Operations map[string]ManifestBody `json:"operations,omitempty"`
+	// Parameters holds the rules of the parameters, by operation ID and then by name.
+	Parameters map[string]ManifestFields `json:"parameters,omitempty"`
+}
+
+// ManifestFields holds the rules of fields, by path.
+type ManifestFields map[string][]ManifestRule
+
+// ManifestBody is either the name of the component schema of a request body or the
+// fields of an inline one.
+type ManifestBody struct {
+	Schema string         `json:"schema,omitempty"`
+	Fields ManifestFields `json:"fields,omitempty"`
+}
+
+// ManifestRule is a validate rule, e.g. {"rule": "min", "param": "3"}, or the
+// alternatives of an "or" rule, such as "hexcolor|rgb", in AnyOf.
+type ManifestRule struct {
+	Rule    string         `json:"rule,omitempty"`
+	Param   string         `json:"param,omitempty"`
+	Message string         `json:"message,omitempty"`
+	AnyOf   []ManifestRule `json:"anyOf,omitempty"`
+}
+
+// Manifest generates the constraint manifest of doc, formatted as JSON or as a TypeScript
+// module exporting it as constraints, depending on format.
+func Manifest(doc *openapi3.T, format string) ([]byte, error) {
+	m := NewManifest(doc)
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "json":
+		return append(data, '\n'), nil
+	case "ts":
+		var b bytes.Buffer
+		b.WriteString(tsManifest)
+		b.Write(data)
+		b.WriteString(";\n")
+		return b.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unknown manifest format %q", format)
+}
+
+var tsManifest = `// Code generated by oapi-codegen-validator. DO NOT EDIT.
+
+export interface Rule {
+  readonly rule?: string;
+  readonly param?: string;
+  readonly message?: string;
+  readonly anyOf?: readonly Rule[];
+}
+
+export type Fields = Readonly<Record<string, readonly Rule[]>>;
+
+export interface Body {
+  readonly schema?: string;
+  readonly fields?: Fields;
+}
+
+export interface ConstraintManifest {
+  readonly schemas: Readonly<Record<string, Fields>>;
+  readonly operations?: Readonly<Record<string, Body>>;
+  readonly parameters?: Readonly<Record<string, Fields>>;
+}
+
+export const constraints: ConstraintManifest = `
+
+// NewManifest returns the constraint manifest of doc.
+func NewManifest(doc *openapi3.T) *ConstraintManifest {
+	m := &ConstraintManifest{Schemas: make(map[string]ManifestFields)}
+	if doc.Components != nil {
+		for name, ref := range doc.Components.Schemas {
+			if ref.Value != nil && isObject(ref.Value) {
+				m.Schemas[name] = manifestFields(ref.Value)
+			}
+		}
+	}
+
+	g := newGenerator(doc, "")
+	for _, id := range g.operationIDs() {
+		mt := g.operation(id).RequestBody.Value.Content.Get("application/json")
+		if mt == nil || mt.Schema == nil || mt.Schema.Value == nil || !isObject(mt.Schema.Value) {
+			continue
+		}
+		if m.Operations == nil {
+			m.Operations = make(map[string]ManifestBody)
+		}
+		if mt.Schema.Ref != "" {
+			m.Operations[id] = ManifestBody{Schema: componentName(mt.Schema.Ref)}
+		} else {
+			m.Operations[id] = ManifestBody{Fields: manifestFields(mt.Schema.Value)}
+		}
+	}
+	for id, params := range operationParameters(doc) {
+		fields := make(ManifestFields)
+		for _, p := range params {
+			if rules, _ := manifestRules(parameterTag(p), p.Schema.Value); len(rules) > 0 {
+				fields[p.Name] = rules
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		if m.Parameters == nil {
+			m.Parameters = make(map[string]ManifestFields)
+		}
+		m.Parameters[id] = fields
+	}
+	return m
+}
+
+func manifestFields(s *openapi3.Schema) ManifestFields {
+	fields := make(ManifestFields)
+	addFields(fields, "", s, map[*openapi3.Schema]bool{s: true})
+	return fields
+}
+
+// addFields adds the fields of s, at path prefix, to fields. The fields of the schemas
+// on path are not added again, to stop at recursive schemas.
+func addFields(fields ManifestFields, prefix string, s *openapi3.Schema, path map[*openapi3.Schema]bool) {
+	for _, prop := range slices.Sorted(maps.Keys(s.Properties)) {
+		ref := s.Properties[prop]
+		if ref.Value == nil {
+			continue
+		}
+		field := prefix + prop
+		rules, items := manifestRules(validateTag(ref.Value), ref.Value)
+		if len(rules) > 0 {
+			fields[field] = rules
+		}
+		if len(items) > 0 {
+			fields[field+"[]"] = items
+		}
+
+		child := ref.Value
+		if child.Type.Is(openapi3.TypeArray) && child.Items != nil && child.Items.Value != nil {
+			field, child = field+"[]", child.Items.Value
+		}
+		if isObject(child) && !path[child] {
+			path[child] = true
+			addFields(fields, field+".", child, path)
+			delete(path, child)
+		}
+	}
+}
+
+// manifestRules returns the rules of tag, before and after its dive rule. The rules of
+// the keys of a map, between keys and endkeys, are left out. Messages are read from the
+// x-error-message extension of s.
+func manifestRules(tag string, s *openapi3.Schema) (rules, items []ManifestRule) {
+	dived, inKeys := false, false
+	for _, r := range strings.Split(tag, ",") {
+		switch {
+		case r == "":
+			continue
+		case r == "dive":
+			dived = true
+			continue
+		case r == "keys" || r == "endkeys":
+			inKeys = r == "keys"
+			continue
+		case inKeys:
+			continue
+		}
+		var rule ManifestRule
+		if alternatives := strings.Split(r, "|"); len(alternatives) == 1 {
+			rule = manifestRule(r, s)
+		} else {
+			for _, alt := range alternatives {
+				rule.AnyOf = append(rule.AnyOf, manifestRule(alt, s))
+			}
+		}
+		if dived {
+			items = append(items, rule)
+		} else {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, items
+}
+
+func manifestRule(r string, s *openapi3.Schema) ManifestRule {
+	name, param, _ := strings.Cut(r, "=")
+	return ManifestRule{Rule: name, Param: rules.Unescape(param), Message: errorMessage(s, name)}
+}
@@ -0,0 +1,81 @@
+package codegen
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+)
+
+// Models generates a Validate method on the struct of every object component schema and
+// inline JSON request body, checking the constraints of the spec without struct tags,
+// reflection or the middleware, e.g. for queue consumers.
+func Models(doc *openapi3.T, pkg string) ([]byte, error) {
+	g := newGenerator(doc, "model")
+	g.check, g.fieldError, g.owned = "check", "ModelFieldError", false
+	g.imports["fmt"] = true
+	g.imports["strings"] = true
+	g.body.WriteString(modelErrors)
+
+	types := g.modelTypes()
+	for _, typ := range slices.Sorted(maps.Keys(types)) {
+		g.printf("// Validate checks m against the constraints of the spec.\n")
+		g.printf("func (m %s) Validate() error {\n", typ)
+		g.printf("if errs := %s%s(&m, \"\"); len(errs) > 0 {\n", g.check, typ)
+		g.printf("return &ModelError{Fields: errs}\n}\nreturn nil\n}\n\n")
+		g.object(typ, types[typ])
+	}
+	return g.file(pkg)
+}
+
+// modelTypes returns the schemas of the structs oapi-codegen generates for the object
+// component schemas and inline JSON request bodies, by type name.
+func (g *generator) modelTypes() map[string]*openapi3.Schema {
+	types := make(map[string]*openapi3.Schema)
+	if g.doc.Components != nil {
+		for name, ref := range g.doc.Components.Schemas {
+			if ref.Value != nil && isObject(ref.Value) {
+				types[naming.TypeName(name)] = ref.Value
+			}
+		}
+	}
+	for _, id := range g.operationIDs() {
+		mt := g.operation(id).RequestBody.Value.Content.Get("application/json")
+		if mt != nil && mt.Schema != nil && mt.Schema.Ref == "" && mt.Schema.Value != nil && isObject(mt.Schema.Value) {
+			types[naming.TypeName(id)+"JSONBody"] = mt.Schema.Value
+		}
+	}
+	return types
+}
+
+var modelErrors = `// ModelError is returned by the Validate methods of the models.
+type ModelError struct {
+	Fields []ModelFieldError
+}
+
+// ModelFieldError describes one broken rule.
+type ModelFieldError struct {
+	// Field is the JSON path of the field, e.g. "address.street".
+	Field string
+	Rule  string
+	Param string
+	// Value is the invalid value, left empty when Redacted.
+	Value    any
+	Redacted bool
+	Message  string
+}
+
+func (e *ModelError) Error() string {
+	var b strings.Builder
+	b.WriteString("validation failed:")
+	for i, fe := range e.Fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, " %s (%s)", fe.Field, fe.Rule)
+	}
+	return b.String()
+}
+
+`
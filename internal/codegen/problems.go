@@ -0,0 +1,206 @@
+package codegen
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+)
+
+// problemSources maps the property names of the error model to the fields of
+// middleware.Problem they are set from, and fieldSources the property names of the items
+// of its errors to the fields of middleware.FieldError. The x-validation-source extension
+// of a property, holding one of these names, overrides its own.
+var (
+	problemSources = map[string]string{
+		"type":      "Type",
+		"title":     "Title",
+		"status":    "Status",
+		"code":      "Status",
+		"detail":    "Detail",
+		"message":   "Detail",
+		"requestId": "RequestID",
+		"errors":    "Errors",
+	}
+	fieldSources = map[string]string{
+		"field":       "Field",
+		"name":        "Field",
+		"path":        "Field",
+		"pointer":     "Field",
+		"property":    "Field",
+		"rule":        "Rule",
+		"code":        "Rule",
+		"reason":      "Rule",
+		"param":       "Param",
+		"value":       "Value",
+		"redacted":    "Redacted",
+		"message":     "Message",
+		"detail":      "Message",
+		"title":       "Title",
+		"description": "Description",
+		"docs":        "Docs",
+	}
+)
+
+// Problems generates a translator of the errors of the middleware into the error model of
+// the spec, so that validation failures are written as documented: NewModel builds the
+// model from an error and ModelHandler is a middleware.ErrorHandler writing it. The model
+// is the component schema marked with x-validation-problem: true or, without one, the
+// schema of the 400 responses of the operations.
+func Problems(doc *openapi3.T, pkg string) ([]byte, error) {
+	g := newGenerator(doc, "problem")
+	name, contentType, err := g.problemModel()
+	if err != nil {
+		return nil, err
+	}
+	var model *openapi3.Schema
+	if doc.Components != nil && doc.Components.Schemas[name] != nil {
+		model = doc.Components.Schemas[name].Value
+	}
+	if model == nil {
+		return nil, fmt.Errorf("no error model: %s is not a component schema of the spec", name)
+	}
+	typ := naming.TypeName(name)
+	g.imports["encoding/json"] = true
+	g.imports["net/http"] = true
+	g.imports[middlewarePkg] = true
+
+	var w strings.Builder
+	if err := g.assign(&w, "m", typ, "p", model, problemSources); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	g.printf("// New%s describes err, returned by the validation middleware, as a %s.\n", typ, typ)
+	g.printf("func New%s(err error) %s {\np := middleware.NewProblem(err)\nvar m %s\n%sreturn m\n}\n\n", typ, typ, typ, w.String())
+	g.printf("// %sHandler is a middleware.ErrorHandler writing the error as a %s.\n", typ, typ)
+	g.printf("func %sHandler(w http.ResponseWriter, r *http.Request, err error) {\n", typ)
+	g.printf("m := New%s(err)\nw.Header().Set(\"Content-Type\", %q)\n", typ, contentType)
+	g.printf("w.WriteHeader(middleware.NewProblem(err).Status)\n_ = json.NewEncoder(w).Encode(m)\n}\n")
+	if strings.Contains(g.body.String(), "problemPtr(") {
+		g.body.WriteString("\nfunc problemPtr[T any](v T) *T {\nreturn &v\n}\n")
+	}
+	return g.file(pkg)
+}
+
+// problemModel returns the name of the component schema of the error model and the
+// content type it is documented with.
+func (g *generator) problemModel() (name, contentType string, err error) {
+	if c := g.doc.Components; c != nil {
+		for _, name := range slices.Sorted(maps.Keys(c.Schemas)) {
+			if c.Schemas[name].Value == nil {
+				continue
+			}
+			if marked, _ := c.Schemas[name].Value.Extensions["x-validation-problem"].(bool); marked {
+				return name, "application/problem+json", nil
+			}
+		}
+	}
+	for _, id := range g.operationIDs() {
+		resp := g.operation(id).Responses.Status(400)
+		if resp == nil || resp.Value == nil {
+			continue
+		}
+		for _, ct := range slices.Sorted(maps.Keys(resp.Value.Content)) {
+			if s := resp.Value.Content[ct].Schema; s != nil && s.Ref != "" && strings.Contains(ct, "json") {
+				return componentName(s.Ref), ct, nil
+			}
+		}
+	}
+	return "", "", errors.New("no error model: mark a component schema with x-validation-problem: true")
+}
+
+// assign writes the statements setting the properties of recv, a typ of schema s, from
+// the fields of src named by sources.
+func (g *generator) assign(w *strings.Builder, recv, typ, src string, s *openapi3.Schema, sources map[string]string) error {
+	for _, prop := range slices.Sorted(maps.Keys(s.Properties)) {
+		ref := s.Properties[prop]
+		if ref.Value == nil {
+			continue
+		}
+		source := sources[prop]
+		ext, explicit := ref.Value.Extensions["x-validation-source"].(string)
+		if explicit {
+			var ok bool
+			if source, ok = sources[ext]; !ok {
+				return fmt.Errorf("property %s: unknown x-validation-source %q", prop, ext)
+			}
+		}
+		if source == "" {
+			continue
+		}
+		field := recv + "." + naming.TypeName(prop)
+		pointer := isPointer(s, prop, ref.Value)
+		value := src + "." + source
+
+		if source == "Errors" {
+			if err := g.items(w, field, value, ref, pointer); err != nil {
+				return fmt.Errorf("property %s: %w", prop, err)
+			}
+			continue
+		}
+
+		ftyp, ok := g.goType(typ, prop, ref)
+		t := ref.Value.Type
+		var zero string
+		switch {
+		case source == "Value" && t == nil:
+			// Untyped properties hold any value.
+			zero = "nil"
+		case source == "Value" && ftyp == "string":
+			g.imports["fmt"] = true
+			value = "fmt.Sprint(" + value + ")"
+			if pointer {
+				value = "problemPtr(" + value + ")"
+			}
+			fmt.Fprintf(w, "if %s != nil {\n%s = %s\n}\n", src+".Value", field, value)
+			continue
+		case ok && source == "Status" && t.Is(openapi3.TypeInteger):
+			value, zero = typed(ftyp, value, "int"), "0"
+		case ok && source == "Redacted" && t.Is(openapi3.TypeBoolean):
+			value, zero = typed(ftyp, value, "bool"), "false"
+		case ok && source != "Value" && source != "Status" && source != "Redacted" && t.Is(openapi3.TypeString):
+			value, zero = typed(ftyp, value, "string"), `""`
+		case explicit:
+			return fmt.Errorf("property %s: type does not match x-validation-source %q", prop, ext)
+		default:
+			// A property named like a field of another type, e.g. a string code.
+			continue
+		}
+		if pointer {
+			fmt.Fprintf(w, "if %s != %s {\n%s = problemPtr(%s)\n}\n", src+"."+source, zero, field, value)
+		} else {
+			fmt.Fprintf(w, "%s = %s\n", field, value)
+		}
+	}
+	return nil
+}
+
+// items writes the statements setting field, of schema ref, to the translated field
+// errors in errs.
+func (g *generator) items(w *strings.Builder, field, errs string, ref *openapi3.SchemaRef, pointer bool) error {
+	s := ref.Value
+	if !s.Type.Is(openapi3.TypeArray) || s.Items == nil || s.Items.Value == nil {
+		return errors.New("the field errors must be an array")
+	}
+	if s.Items.Ref == "" {
+		return errors.New("the items of the field errors must be a component schema")
+	}
+	item := typeName(s.Items.Ref)
+	list := g.local("items")
+	fmt.Fprintf(w, "%s := make([]%s, 0, len(%s))\n", list, item, errs)
+	fmt.Fprintf(w, "for _, fe := range %s {\nvar item %s\n", errs, item)
+	if err := g.assign(w, "item", item, "fe", s.Items.Value, fieldSources); err != nil {
+		return fmt.Errorf("%s: %w", componentName(s.Items.Ref), err)
+	}
+	fmt.Fprintf(w, "%s = append(%s, item)\n}\n", list, list)
+	if pointer {
+		fmt.Fprintf(w, "if len(%s) > 0 {\n%s = &%s\n}\n", list, field, list)
+	} else {
+		fmt.Fprintf(w, "%s = %s\n", field, list)
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package codegen
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/patterns"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+)
+
+const validatorPkg = "github.com/go-playground/validator/v10"
+
+// Registrations generates the custom validations that the validate tags of an enriched
+// spec use, with their regex patterns compiled once, as a GeneratedValidations table and
+// a RegisterGenerated function registering it on a validator. The x-validate-expr rules of
+// the models are generated as struct-level validations, in GeneratedStructValidations.
+func Registrations(doc *openapi3.T, pkg string) ([]byte, error) {
+	g := newGenerator(doc, "tag")
+	g.imports[validatorPkg] = true
+
+	var tags []string
+	for s := range schemas(doc) {
+		tags = append(tags, validateTag(s))
+	}
+	for p := range parameters(doc) {
+		tags = append(tags, parameterTag(p))
+	}
+	used := make(map[string]bool)
+	for _, tag := range tags {
+		for _, rule := range splitRules(tag) {
+			name, param, _ := strings.Cut(rule, "=")
+			if _, ok := customValidations[name]; !ok {
+				continue
+			}
+			used[name] = true
+			if name == "regex" {
+				g.pattern(rules.Unescape(param))
+			}
+		}
+	}
+
+	var exprs strings.Builder
+	structFns, err := g.exprValidations(&exprs)
+	if err != nil {
+		return nil, err
+	}
+
+	g.printf("// GeneratedValidations are the custom validations used by the rules of the spec, by tag.\n")
+	g.printf("var GeneratedValidations = map[string]validator.Func{\n")
+	for _, name := range slices.Sorted(maps.Keys(used)) {
+		g.printf("%q: %s,\n", name, customValidations[name].fn)
+	}
+	g.printf("}\n\n")
+	if len(structFns) > 0 {
+		g.imports["reflect"] = true
+		g.printf("// GeneratedStructValidations are the struct-level validations of the x-validate-expr\n")
+		g.printf("// rules of the spec, by type.\n")
+		g.printf("var GeneratedStructValidations = map[reflect.Type]validator.StructLevelFunc{\n")
+		for _, typ := range slices.Sorted(maps.Keys(structFns)) {
+			g.printf("reflect.TypeFor[%s](): %s,\n", typ, structFns[typ])
+		}
+		g.printf("}\n\n")
+		g.printf("// RegisterGenerated registers GeneratedValidations and GeneratedStructValidations on v.\n")
+	} else {
+		g.printf("// RegisterGenerated registers GeneratedValidations on v.\n")
+	}
+	g.printf("func RegisterGenerated(v *validator.Validate) error {\n")
+	g.printf("for tag, fn := range GeneratedValidations {\n")
+	g.printf("if err := v.RegisterValidation(tag, fn); err != nil {\nreturn err\n}\n")
+	g.printf("}\n")
+	if len(structFns) > 0 {
+		g.printf("for t, fn := range GeneratedStructValidations {\n")
+		g.printf("v.RegisterStructValidation(fn, reflect.New(t).Elem().Interface())\n}\n")
+	}
+	g.printf("return nil\n}\n\n")
+	g.body.WriteString(exprs.String())
+
+	if used["regex"] {
+		g.printf("// tagPatterns are the compiled patterns of the regex rules, by source.\n")
+		g.printf("var tagPatterns = map[string]*regexp.Regexp{\n")
+		for _, src := range slices.Sorted(maps.Keys(g.patterns)) {
+			g.printf("%q: %s,\n", src, g.patterns[src])
+		}
+		g.printf("}\n\n")
+	}
+	helpers := make(map[string]bool)
+	for _, name := range slices.Sorted(maps.Keys(used)) {
+		v := customValidations[name]
+		for _, imp := range v.imports {
+			g.imports[imp] = true
+		}
+		g.body.WriteString(v.src)
+		if v.helper != "" && !helpers[v.helper] {
+			helpers[v.helper] = true
+			g.body.WriteString(v.helper)
+		}
+	}
+	return g.file(pkg)
+}
+
+// splitRules splits a validate tag into its rules, the alternatives of "or" rules included.
+func splitRules(tag string) []string {
+	var rules []string
+	for _, rule := range strings.Split(tag, ",") {
+		rules = append(rules, strings.Split(rule, "|")...)
+	}
+	return rules
+}
+
+type customValidation struct {
+	fn      string
+	imports []string
+	src     string
+	// helper is the source of a function shared with other validations, if any.
+	helper string
+}
+
+// customValidations are the validations validator/v10 does not provide, by tag.
+var customValidations = map[string]customValidation{
+	"regex": {
+		fn:      "regexValidation",
+		imports: []string{"regexp"},
+		src: `
+func regexValidation(fl validator.FieldLevel) bool {
+	re, ok := tagPatterns[fl.Param()]
+	if !ok {
+		// A rule added after generation.
+		match, err := regexp.MatchString(fl.Param(), fl.Field().String())
+		return err == nil && match
+	}
+	return re.MatchString(fl.Field().String())
+}
+`,
+	},
+	"base64": {
+		fn:      "base64Validation",
+		imports: []string{"encoding/base64", "reflect", "slices"},
+		src: `
+func base64Validation(fl validator.FieldLevel) bool {
+	return encoded(fl, base64.StdEncoding)
+}
+`,
+		helper: encoded,
+	},
+	"base64url": {
+		fn:      "base64URLValidation",
+		imports: []string{"encoding/base64", "reflect", "slices"},
+		src: `
+func base64URLValidation(fl validator.FieldLevel) bool {
+	return encoded(fl, base64.URLEncoding, base64.RawURLEncoding)
+}
+`,
+		helper: encoded,
+	},
+	"datetime": {
+		fn:      "datetimeValidation",
+		imports: []string{"reflect", "strings", "time"},
+		src: `
+func datetimeValidation(fl validator.FieldLevel) bool {
+	return parsesAs(fl, fl.Param())
+}
+`,
+		helper: parsesAs,
+	},
+	"rfc3339": {
+		fn:      "rfc3339Validation",
+		imports: []string{"reflect", "strings", "time"},
+		src: `
+func rfc3339Validation(fl validator.FieldLevel) bool {
+	return parsesAs(fl, time.RFC3339)
+}
+`,
+		helper: parsesAs,
+	},
+	"iso8601duration": {
+		fn:      "iso8601DurationValidation",
+		imports: []string{"regexp"},
+		src: `
+// durationPattern matches the ISO 8601 durations of the duration format.
+var durationPattern = regexp.MustCompile(` + strconv.Quote(patterns.Duration) + `)
+
+func iso8601DurationValidation(fl validator.FieldLevel) bool {
+	return durationPattern.MatchString(fl.Field().String())
+}
+`,
+	},
+	"idn_hostname": {
+		fn:      "idnHostnameValidation",
+		imports: []string{"strings", "unicode", "unicode/utf8"},
+		src: `
+func idnHostnameValidation(fl validator.FieldLevel) bool {
+	s := fl.Field().String()
+	if s == "" || utf8.RuneCountInString(s) > 253 {
+		return false
+	}
+	for label := range strings.SplitSeq(strings.TrimSuffix(s, "."), ".") {
+		if label == "" || utf8.RuneCountInString(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if r != '-' && !unicode.In(r, unicode.L, unicode.M, unicode.Nd) {
+				return false
+			}
+		}
+	}
+	return true
+}
+`,
+	},
+	"oneofnumber": {
+		fn:      "oneOfNumberValidation",
+		imports: []string{"reflect", "strconv", "strings"},
+		src: `
+func oneOfNumberValidation(fl validator.FieldLevel) bool {
+	var x float64
+	bits := 64
+	switch f := fl.Field(); f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x = float64(f.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x = float64(f.Uint())
+	case reflect.Float32:
+		x, bits = f.Float(), 32
+	case reflect.Float64:
+		x = f.Float()
+	default:
+		return false
+	}
+	for value := range strings.FieldsSeq(fl.Param()) {
+		if n, err := strconv.ParseFloat(value, bits); err == nil && n == x {
+			return true
+		}
+	}
+	return false
+}
+`,
+	},
+	"multipleof": {
+		fn:      "multipleOfValidation",
+		imports: []string{"math", "reflect", "strconv"},
+		src: `
+func multipleOfValidation(fl validator.FieldLevel) bool {
+	n, err := strconv.ParseFloat(fl.Param(), 64)
+	if err != nil || n <= 0 {
+		return false
+	}
+	var x float64
+	switch f := fl.Field(); f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x = float64(f.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x = float64(f.Uint())
+	case reflect.Float32, reflect.Float64:
+		x = f.Float()
+	default:
+		return false
+	}
+	q := x / n
+	return math.Abs(q-math.Round(q)) < 1e-9
+}
+`,
+	},
+}
+
+// parsesAs is the source shared by the datetime and rfc3339 validations: unlike the
+// datetime of validator/v10, it accepts the time.Time and openapi_types.Date fields of the
+// date formats, and the lower case T and Z of RFC 3339.
+const parsesAs = `
+func parsesAs(fl validator.FieldLevel, layout string) bool {
+	f := fl.Field()
+	if t := f.Type(); f.Kind() == reflect.Struct {
+		timeType := reflect.TypeFor[time.Time]()
+		return t == timeType || t.NumField() == 1 && t.Field(0).Anonymous && t.Field(0).Type == timeType
+	}
+	if f.Kind() != reflect.String {
+		return false
+	}
+	_, err := time.Parse(layout, strings.ToUpper(f.String()))
+	return err == nil
+}
+`
+
+// encoded is the source shared by the base64 and base64url validations: unlike those of
+// validator/v10, it accepts the []byte fields of format: byte, decoded already.
+const encoded = `
+func encoded(fl validator.FieldLevel, encodings ...*base64.Encoding) bool {
+	f := fl.Field()
+	if f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Uint8 {
+		return true
+	}
+	if f.Kind() != reflect.String {
+		return false
+	}
+	return slices.ContainsFunc(encodings, func(enc *base64.Encoding) bool {
+		_, err := enc.DecodeString(f.String())
+		return err == nil
+	})
+}
+`
@@ -0,0 +1,153 @@
+package codegen
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+)
+
+// Structs generates the Go types of the component schemas of doc, their fields tagged
+// with json and the tags of x-oapi-codegen-extra-tags, validate included, for consumers
+// that only need the validated models, e.g. queue consumers, without running oapi-codegen.
+// Types are named as oapi-codegen names them, inline objects and enums after their
+// parent and property, e.g. UserRole.
+func Structs(doc *openapi3.T, pkg string) ([]byte, error) {
+	g := newGenerator(doc, "struct")
+	if doc.Components != nil {
+		for _, name := range slices.Sorted(maps.Keys(doc.Components.Schemas)) {
+			switch ref := doc.Components.Schemas[name]; {
+			case ref.Ref != "":
+				g.printf("type %s = %s\n\n", naming.TypeName(name), typeName(ref.Ref))
+			case ref.Value != nil:
+				g.declare(naming.TypeName(name), ref.Value)
+			}
+		}
+	}
+	return g.file(pkg)
+}
+
+// declare generates the type typ of schema s, then the types of its inline objects and
+// enums.
+func (g *generator) declare(typ string, s *openapi3.Schema) {
+	var nested []func()
+	inline := func(name string, s *openapi3.Schema) string {
+		nested = append(nested, func() { g.declare(name, s) })
+		return name
+	}
+
+	g.typeComment(typ, s.Description)
+	switch {
+	case len(s.Enum) > 0 && s.Type.Is(openapi3.TypeString):
+		g.printf("type %s string\n\n", typ)
+		g.printf("const (\n")
+		for _, v := range s.Enum {
+			if v, ok := v.(string); ok {
+				g.printf("%s%s %s = %q\n", typ, naming.TypeName(v), typ, v)
+			}
+		}
+		g.printf(")\n\n")
+
+	case len(s.Properties) > 0:
+		g.printf("type %s struct {\n", typ)
+		for _, prop := range slices.Sorted(maps.Keys(s.Properties)) {
+			ref := s.Properties[prop]
+			if ref.Value == nil {
+				continue
+			}
+			ftyp := g.structType(typ+naming.TypeName(prop), ref, inline)
+			if isPointer(s, prop, ref.Value) {
+				ftyp = "*" + ftyp
+			}
+			if d := ref.Value.Description; d != "" {
+				g.printf("// %s\n", strings.ReplaceAll(strings.TrimSpace(d), "\n", "\n// "))
+			}
+			g.printf("%s %s `%s`\n", naming.TypeName(prop), ftyp, structTags(prop, s, ref.Value))
+		}
+		g.printf("}\n\n")
+
+	default:
+		g.printf("type %s %s\n\n", typ, g.structType(typ, &openapi3.SchemaRef{Value: s}, inline))
+	}
+
+	for _, declare := range nested {
+		declare()
+	}
+}
+
+// typeComment writes the doc comment of typ, starting with its name as gofmt expects.
+func (g *generator) typeComment(typ, description string) {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return
+	}
+	if !strings.HasPrefix(description, typ+" ") {
+		description = typ + " " + description
+	}
+	g.printf("// %s\n", strings.ReplaceAll(description, "\n", "\n// "))
+}
+
+// structType returns the Go type of ref, declaring its inline objects and enums with
+// inline under the name name.
+func (g *generator) structType(name string, ref *openapi3.SchemaRef, inline func(string, *openapi3.Schema) string) string {
+	if ref == nil || ref.Value == nil {
+		return "any"
+	}
+	if ref.Ref != "" {
+		return typeName(ref.Ref)
+	}
+	s := ref.Value
+	switch {
+	case len(s.Enum) > 0 && s.Type.Is(openapi3.TypeString), len(s.Properties) > 0:
+		return inline(name, s)
+	case s.Type.Is(openapi3.TypeObject):
+		if s.AdditionalProperties.Schema != nil {
+			return "map[string]" + g.structType(name+"Value", s.AdditionalProperties.Schema, inline)
+		}
+		return "map[string]any"
+	case s.Type.Is(openapi3.TypeArray):
+		return "[]" + g.structType(name+"Item", s.Items, inline)
+	case s.Type.Is(openapi3.TypeString):
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = true
+			return "time.Time"
+		case "byte", "binary":
+			return "[]byte"
+		}
+		return "string"
+	case s.Type.Is(openapi3.TypeInteger):
+		switch s.Format {
+		case "int32", "int64":
+			return s.Format
+		}
+		return "int"
+	case s.Type.Is(openapi3.TypeNumber):
+		if s.Format == "double" {
+			return "float64"
+		}
+		return "float32"
+	case s.Type.Is(openapi3.TypeBoolean):
+		return "bool"
+	}
+	return "any"
+}
+
+// structTags returns the tags of the field of property prop of parent, of schema s.
+func structTags(prop string, parent, s *openapi3.Schema) string {
+	json := prop
+	if !slices.Contains(parent.Required, prop) {
+		json += ",omitempty"
+	}
+	tags := []string{"json:" + strconv.Quote(json)}
+	extra, _ := s.Extensions["x-oapi-codegen-extra-tags"].(map[string]any)
+	for _, key := range slices.Sorted(maps.Keys(extra)) {
+		tags = append(tags, fmt.Sprintf("%s:%s", key, strconv.Quote(rules.UnquoteTag(fmt.Sprint(extra[key])))))
+	}
+	return strings.Join(tags, " ")
+}
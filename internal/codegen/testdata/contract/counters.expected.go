@@ -0,0 +1,76 @@
+// Code generated by oapi-codegen-validator. DO NOT EDIT.
+
+package api
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+type contractCase struct {
+	name, body string
+	valid      bool
+}
+
+// contractSend sends body to the service at CONTRACT_BASE_URL, expecting a 2xx status
+// when valid and 400 otherwise.
+func contractSend(t *testing.T, method, path, body string, valid bool) {
+	t.Helper()
+	base := os.Getenv("CONTRACT_BASE_URL")
+	if base == "" {
+		t.Skip("CONTRACT_BASE_URL is not set")
+	}
+	req, err := http.NewRequestWithContext(t.Context(), method, strings.TrimSuffix(base, "/")+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if auth := os.Getenv("CONTRACT_AUTHORIZATION"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case valid && resp.StatusCode/100 != 2:
+		t.Errorf("%s %s: valid payload answered %s", method, path, resp.Status)
+	case !valid && resp.StatusCode != http.StatusBadRequest:
+		t.Errorf("%s %s: invalid payload answered %s, want 400 Bad Request", method, path, resp.Status)
+	}
+}
+
+func TestContractCreateCounter(t *testing.T) {
+	for _, tc := range []contractCase{
+		{"valid", `{"count":5,"name":"hits"}`, true},
+		{"count of -1", `{"count":-1,"name":"hits"}`, false},
+		{"count of 0", `{"count":0,"name":"hits"}`, true},
+		{"count of 100", `{"count":100,"name":"hits"}`, true},
+		{"count of 101", `{"count":101,"name":"hits"}`, false},
+		{"level of 0", `{"count":5,"level":0,"name":"hits"}`, false},
+		{"level of 1", `{"count":5,"level":1,"name":"hits"}`, true},
+		{"level of 9", `{"count":5,"level":9,"name":"hits"}`, true},
+		{"level of 10", `{"count":5,"level":10,"name":"hits"}`, false},
+		{"name missing", `{"count":5}`, false},
+		{"name of length 20", `{"count":5,"name":"aaaaaaaaaaaaaaaaaaaa"}`, true},
+		{"name of length 21", `{"count":5,"name":"aaaaaaaaaaaaaaaaaaaaa"}`, false},
+		{"ratio of 0.24", `{"count":5,"name":"hits","ratio":0.24}`, false},
+		{"ratio of 0.25", `{"count":5,"name":"hits","ratio":0.25}`, true},
+		{"ratio of 0.74", `{"count":5,"name":"hits","ratio":0.74}`, true},
+		{"ratio of 0.75", `{"count":5,"name":"hits","ratio":0.75}`, false},
+		{"score of 0", `{"count":5,"name":"hits","score":0}`, false},
+		{"score of 0.1", `{"count":5,"name":"hits","score":0.1}`, true},
+		{"score of 0.4", `{"count":5,"name":"hits","score":0.4}`, false},
+		{"weight of 0.1", `{"count":5,"name":"hits","weight":0.1}`, false},
+		{"weight of 0.2", `{"count":5,"name":"hits","weight":0.2}`, true},
+		{"weight of 12.5", `{"count":5,"name":"hits","weight":12.5}`, true},
+		{"weight of 12.6", `{"count":5,"name":"hits","weight":12.6}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			contractSend(t, "POST", "/counters", tc.body, tc.valid)
+		})
+	}
+}
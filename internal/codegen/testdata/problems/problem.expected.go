@@ -0,0 +1,53 @@
+// Code generated by oapi-codegen-validator. DO NOT EDIT.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/hadrienk/oapi-codegen-validator/pkg/middleware"
+)
+
+// NewValidationProblem describes err, returned by the validation middleware, as a ValidationProblem.
+func NewValidationProblem(err error) ValidationProblem {
+	p := middleware.NewProblem(err)
+	var m ValidationProblem
+	if p.Detail != "" {
+		m.Detail = problemPtr(p.Detail)
+	}
+	items1 := make([]InvalidParam, 0, len(p.Errors))
+	for _, fe := range p.Errors {
+		var item InvalidParam
+		if fe.Message != "" {
+			item.Hint = problemPtr(fe.Message)
+		}
+		item.Name = fe.Field
+		item.Reason = fe.Rule
+		if fe.Value != nil {
+			item.Value = problemPtr(fe.Value)
+		}
+		items1 = append(items1, item)
+	}
+	if len(items1) > 0 {
+		m.InvalidParams = &items1
+	}
+	m.Status = int32(p.Status)
+	m.Title = p.Title
+	if p.Type != "" {
+		m.Type = problemPtr(p.Type)
+	}
+	return m
+}
+
+// ValidationProblemHandler is a middleware.ErrorHandler writing the error as a ValidationProblem.
+func ValidationProblemHandler(w http.ResponseWriter, r *http.Request, err error) {
+	m := NewValidationProblem(err)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(middleware.NewProblem(err).Status)
+	_ = json.NewEncoder(w).Encode(m)
+}
+
+func problemPtr[T any](v T) *T {
+	return &v
+}
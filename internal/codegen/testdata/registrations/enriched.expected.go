@@ -0,0 +1,69 @@
+// Code generated by oapi-codegen-validator. DO NOT EDIT.
+
+package api
+
+import (
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	tagPattern0 = regexp.MustCompile("^[a-z]+-[0-9]+$")
+	tagPattern1 = regexp.MustCompile("^[A-Z]{8,12}$")
+)
+
+// GeneratedValidations are the custom validations used by the rules of the spec, by tag.
+var GeneratedValidations = map[string]validator.Func{
+	"multipleof": multipleOfValidation,
+	"regex":      regexValidation,
+}
+
+// RegisterGenerated registers GeneratedValidations on v.
+func RegisterGenerated(v *validator.Validate) error {
+	for tag, fn := range GeneratedValidations {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagPatterns are the compiled patterns of the regex rules, by source.
+var tagPatterns = map[string]*regexp.Regexp{
+	"^[A-Z]{8,12}$":   tagPattern1,
+	"^[a-z]+-[0-9]+$": tagPattern0,
+}
+
+func multipleOfValidation(fl validator.FieldLevel) bool {
+	n, err := strconv.ParseFloat(fl.Param(), 64)
+	if err != nil || n <= 0 {
+		return false
+	}
+	var x float64
+	switch f := fl.Field(); f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x = float64(f.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x = float64(f.Uint())
+	case reflect.Float32, reflect.Float64:
+		x = f.Float()
+	default:
+		return false
+	}
+	q := x / n
+	return math.Abs(q-math.Round(q)) < 1e-9
+}
+
+func regexValidation(fl validator.FieldLevel) bool {
+	re, ok := tagPatterns[fl.Param()]
+	if !ok {
+		// A rule added after generation.
+		match, err := regexp.MatchString(fl.Param(), fl.Field().String())
+		return err == nil && match
+	}
+	return re.MatchString(fl.Field().String())
+}
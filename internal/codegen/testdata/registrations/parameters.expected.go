@@ -0,0 +1,64 @@
+// Code generated by oapi-codegen-validator. DO NOT EDIT.
+
+package api
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var (
+	tagPattern0 = regexp.MustCompile("^[a-f0-9]{32}$")
+)
+
+// GeneratedValidations are the custom validations used by the rules of the spec, by tag.
+var GeneratedValidations = map[string]validator.Func{
+	"regex":   regexValidation,
+	"rfc3339": rfc3339Validation,
+}
+
+// RegisterGenerated registers GeneratedValidations on v.
+func RegisterGenerated(v *validator.Validate) error {
+	for tag, fn := range GeneratedValidations {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagPatterns are the compiled patterns of the regex rules, by source.
+var tagPatterns = map[string]*regexp.Regexp{
+	"^[a-f0-9]{32}$": tagPattern0,
+}
+
+func regexValidation(fl validator.FieldLevel) bool {
+	re, ok := tagPatterns[fl.Param()]
+	if !ok {
+		// A rule added after generation.
+		match, err := regexp.MatchString(fl.Param(), fl.Field().String())
+		return err == nil && match
+	}
+	return re.MatchString(fl.Field().String())
+}
+
+func rfc3339Validation(fl validator.FieldLevel) bool {
+	return parsesAs(fl, time.RFC3339)
+}
+
+func parsesAs(fl validator.FieldLevel, layout string) bool {
+	f := fl.Field()
+	if t := f.Type(); f.Kind() == reflect.Struct {
+		timeType := reflect.TypeFor[time.Time]()
+		return t == timeType || t.NumField() == 1 && t.Field(0).Anonymous && t.Field(0).Type == timeType
+	}
+	if f.Kind() != reflect.String {
+		return false
+	}
+	_, err := time.Parse(layout, strings.ToUpper(f.String()))
+	return err == nil
+}
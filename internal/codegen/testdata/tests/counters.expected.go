@@ -0,0 +1,72 @@
+// Code generated by oapi-codegen-validator. DO NOT EDIT.
+
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hadrienk/oapi-codegen-validator/pkg/middleware"
+)
+
+var testValidator = middleware.NewValidator()
+
+func testPtr[T any](v T) *T {
+	return &v
+}
+
+func testRepeat[T any](n int, v T) []T {
+	s := make([]T, n)
+	for i := range s {
+		s[i] = v
+	}
+	return s
+}
+
+func TestCounterConstraints(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		mutate func(m *Counter)
+		valid  bool
+	}{
+		{"count of -1", func(m *Counter) { m.Count = -1 }, false},
+		{"count of 0", func(m *Counter) { m.Count = 0 }, true},
+		{"count of 100", func(m *Counter) { m.Count = 100 }, true},
+		{"count of 101", func(m *Counter) { m.Count = 101 }, false},
+		{"level of 0", func(m *Counter) { m.Level = testPtr(0) }, false},
+		{"level of 1", func(m *Counter) { m.Level = testPtr(1) }, true},
+		{"level of 9", func(m *Counter) { m.Level = testPtr(9) }, true},
+		{"level of 10", func(m *Counter) { m.Level = testPtr(10) }, false},
+		{"name missing", func(m *Counter) { m.Name = "" }, false},
+		{"name of length 20", func(m *Counter) { m.Name = strings.Repeat("a", 20) }, true},
+		{"name of length 21", func(m *Counter) { m.Name = strings.Repeat("a", 21) }, false},
+		{"ratio of 0.24", func(m *Counter) { m.Ratio = testPtr(float32(0.24)) }, false},
+		{"ratio of 0.25", func(m *Counter) { m.Ratio = testPtr(float32(0.25)) }, true},
+		{"ratio of 0.74", func(m *Counter) { m.Ratio = testPtr(float32(0.74)) }, true},
+		{"ratio of 0.75", func(m *Counter) { m.Ratio = testPtr(float32(0.75)) }, false},
+		{"score of 0", func(m *Counter) { m.Score = testPtr(float32(0)) }, false},
+		{"score of 0.1", func(m *Counter) { m.Score = testPtr(float32(0.1)) }, true},
+		{"score of 0.4", func(m *Counter) { m.Score = testPtr(float32(0.4)) }, false},
+		{"weight of 0.1", func(m *Counter) { m.Weight = testPtr(float64(0.1)) }, false},
+		{"weight of 0.2", func(m *Counter) { m.Weight = testPtr(float64(0.2)) }, true},
+		{"weight of 12.5", func(m *Counter) { m.Weight = testPtr(float64(12.5)) }, true},
+		{"weight of 12.6", func(m *Counter) { m.Weight = testPtr(float64(12.6)) }, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := validCounter()
+			tc.mutate(&m)
+			if err := testValidator.Validate(&m); tc.valid && err != nil {
+				t.Errorf("valid Counter rejected: %v", err)
+			} else if !tc.valid && err == nil {
+				t.Error("invalid Counter accepted")
+			}
+		})
+	}
+}
+
+func validCounter() Counter {
+	return Counter{
+		Name:  "hits",
+		Count: 5,
+	}
+}
@@ -0,0 +1,354 @@
+package codegen
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+)
+
+// Tests generates a table-driven test per model checking that the validate tags of its
+// fields accept and reject the values at the boundaries of the constraints of the spec:
+// min-1, min, max and max+1 for lengths, item counts and bounds, and malformed values for
+// formats. Each case changes one field of a valid model, built from the examples and
+// constraints of the spec, so that the consuming repo catches regressions in either the
+// spec or the enricher.
+func Tests(doc *openapi3.T, pkg string) ([]byte, error) {
+	g := &testGenerator{
+		generator:    newGenerator(doc, "test"),
+		constructors: make(map[string]string),
+		built:        make(map[string]error),
+		used:         make(map[string]bool),
+	}
+	g.types = g.modelTypes()
+	g.imports["testing"] = true
+	g.imports[middlewarePkg] = true
+	g.body.WriteString(testHelpers)
+
+	for _, typ := range slices.Sorted(maps.Keys(g.types)) {
+		g.test(typ)
+	}
+	for _, typ := range slices.Sorted(maps.Keys(g.used)) {
+		g.body.WriteString(g.constructors[typ])
+	}
+
+	if strings.Contains(g.body.String(), "strings.") {
+		g.imports["strings"] = true
+	}
+	return g.file(pkg)
+}
+
+var testHelpers = `var testValidator = middleware.NewValidator()
+
+func testPtr[T any](v T) *T {
+	return &v
+}
+
+func testRepeat[T any](n int, v T) []T {
+	s := make([]T, n)
+	for i := range s {
+		s[i] = v
+	}
+	return s
+}
+
+`
+
+type testGenerator struct {
+	*generator
+	types map[string]*openapi3.Schema
+	// constructors holds the source of the validTyp functions, and built why none could
+	// be generated for a type, nil when one was.
+	constructors map[string]string
+	built        map[string]error
+	used         map[string]bool
+}
+
+// testCase changes field to value, expecting the model to remain valid or not.
+type testCase struct {
+	name, field, value string
+	valid              bool
+}
+
+// test generates TestTypConstraints.
+func (g *testGenerator) test(typ string) {
+	s := g.types[typ]
+	var cases []testCase
+	for _, prop := range slices.Sorted(maps.Keys(s.Properties)) {
+		if ref := s.Properties[prop]; ref.Value != nil {
+			cases = append(cases, g.cases(typ, prop, ref, isPointer(s, prop, ref.Value), slices.Contains(s.Required, prop))...)
+		}
+	}
+	if len(cases) == 0 {
+		return
+	}
+
+	g.printf("func Test%sConstraints(t *testing.T) {\n", typ)
+	if err := g.constructor(typ); err != nil {
+		g.printf("t.Skip(%q)\n}\n\n", fmt.Sprintf("no valid %s can be built from the spec: %v", typ, err))
+		return
+	}
+	g.used[typ] = true
+	g.printf("for _, tc := range []struct {\nname string\nmutate func(m *%s)\nvalid bool\n}{\n", typ)
+	for _, c := range cases {
+		g.printf("{%q, func(m *%s) { m.%s = %s }, %t},\n", c.name, typ, c.field, c.value, c.valid)
+	}
+	g.printf("} {\nt.Run(tc.name, func(t *testing.T) {\nm := valid%s()\ntc.mutate(&m)\n", typ)
+	g.printf("if err := testValidator.Validate(&m); tc.valid && err != nil {\n")
+	g.printf("t.Errorf(\"valid %s rejected: %%v\", err)\n", typ)
+	g.printf("} else if !tc.valid && err == nil {\nt.Error(\"invalid %s accepted\")\n}\n})\n}\n}\n\n", typ)
+}
+
+// cases returns the boundary cases of property prop of the struct typ. Zero values of
+// optional fields are left out, as omitempty skips their rules.
+func (g *testGenerator) cases(typ, prop string, ref *openapi3.SchemaRef, pointer, required bool) []testCase {
+	ftyp, ok := g.goType(typ, prop, ref)
+	s := ref.Value
+	if !ok || isObject(s) {
+		return nil
+	}
+	// A required number allowing 0 is not tagged required, its rules checking 0.
+	zeroAllowed := required && !pointer && rules.ZeroAllowed(schema.FromKin(s))
+	var cases []testCase
+	add := func(name, value string, zero, valid bool) {
+		switch {
+		case zero && !pointer && !required:
+			return
+		case zero && !pointer && valid && !zeroAllowed:
+			// Rejected by the required rule.
+			return
+		case pointer:
+			value = "testPtr(" + value + ")"
+		}
+		cases = append(cases, testCase{name: prop + " " + name, field: naming.TypeName(prop), value: value, valid: valid})
+	}
+
+	if required && !pointer && !zeroAllowed {
+		switch {
+		case isString(s):
+			add("missing", typed(ftyp, `""`, "string"), false, false)
+		case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+			add("missing", typed(ftyp, "0", "int"), false, false)
+		case s.Type.Is(openapi3.TypeArray):
+			add("missing", "nil", false, false)
+		}
+	}
+
+	switch {
+	case isString(s) && len(s.Enum) == 0 && s.Pattern == "":
+		if bad, ok := malformed[s.Format]; ok {
+			add("malformed", typed(ftyp, strconv.Quote(bad), "string"), false, false)
+			break
+		}
+		length := func(n uint64, valid bool) {
+			add(fmt.Sprintf("of length %d", n), typed(ftyp, fmt.Sprintf("strings.Repeat(\"a\", %d)", n), "string"), n == 0, valid)
+		}
+		if s.MinLength > 0 {
+			length(s.MinLength-1, false)
+			length(s.MinLength, true)
+		}
+		if s.MaxLength != nil {
+			length(*s.MaxLength, true)
+			length(*s.MaxLength+1, false)
+		}
+
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		for _, b := range numberBounds(s) {
+			add("of "+formatFloat(b.value), typed(ftyp, formatFloat(b.value), "int"), b.value == 0, b.valid)
+		}
+
+	case s.Type.Is(openapi3.TypeArray) && !s.UniqueItems:
+		elem, ok := g.goType(typ, prop, s.Items)
+		if !ok {
+			break
+		}
+		item, err := g.sample(elem, s.Items)
+		if err != nil {
+			break
+		}
+		size := func(n uint64, valid bool) {
+			add(fmt.Sprintf("of %d items", n), typed(ftyp, fmt.Sprintf("testRepeat(%d, %s)", n, item), "[]"+elem), n == 0, valid)
+		}
+		if s.MinItems > 0 {
+			size(s.MinItems-1, false)
+			size(s.MinItems, true)
+		}
+		if s.MaxItems != nil {
+			size(*s.MaxItems, true)
+			size(*s.MaxItems+1, false)
+		}
+	}
+	return cases
+}
+
+// malformed holds, by format, a string the rule of the format rejects.
+var malformed = map[string]string{
+	"email":     "not-an-email",
+	"ipv4":      "2001:db8::1",
+	"ipv6":      "192.0.2.1",
+	"uri":       "not a url",
+	"url":       "not a url",
+	"uuid":      "not-a-uuid",
+	"date":      "01/02/2024",
+	"date-time": "2024-01-02",
+}
+
+// wellFormed holds, by format, a string the rule of the format accepts.
+var wellFormed = map[string]string{
+	"email":     "user@example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"date":      "2024-01-02",
+	"date-time": "2024-01-02T15:04:05Z",
+}
+
+// constructor generates validTyp, returning a valid value of the struct typ, unless the
+// spec lacks the examples to build one.
+func (g *testGenerator) constructor(typ string) error {
+	if err, ok := g.built[typ]; ok {
+		return err
+	}
+	// Recursive types are rejected while their constructor is being built.
+	g.built[typ] = errors.New("recursive type " + typ)
+
+	s := g.types[typ]
+	var b strings.Builder
+	fmt.Fprintf(&b, "func valid%s() %s {\nreturn %s{\n", typ, typ, typ)
+	for _, prop := range s.Required {
+		ref := s.Properties[prop]
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		err := fmt.Errorf("field %s has no example", prop)
+		ftyp, ok := g.goType(typ, prop, ref)
+		var value string
+		if ok {
+			value, err = g.sample(ftyp, ref)
+		}
+		if err != nil {
+			g.built[typ] = err
+			return err
+		}
+		if isPointer(s, prop, ref.Value) {
+			value = "testPtr(" + value + ")"
+		}
+		fmt.Fprintf(&b, "%s: %s,\n", naming.TypeName(prop), value)
+	}
+	b.WriteString("}\n}\n\n")
+	g.constructors[typ] = b.String()
+	g.built[typ] = nil
+	return nil
+}
+
+// sample returns an expression of type typ holding a value of schema ref.
+func (g *testGenerator) sample(typ string, ref *openapi3.SchemaRef) (string, error) {
+	s := ref.Value
+	if isObject(s) {
+		if ref.Ref == "" {
+			return "", errors.New("inline objects are not supported")
+		}
+		if err := g.constructor(typ); err != nil {
+			return "", err
+		}
+		g.used[typ] = true
+		return "valid" + typ + "()", nil
+	}
+
+	if len(s.Enum) > 0 {
+		return literal(typ, s.Enum[0])
+	}
+	if s.Example != nil {
+		return literal(typ, s.Example)
+	}
+	switch {
+	case isString(s):
+		if s.Pattern != "" {
+			return "", errors.New("pattern without example")
+		}
+		if v, ok := wellFormed[s.Format]; ok {
+			n := uint64(len(v))
+			if n < s.MinLength || s.MaxLength != nil && n > *s.MaxLength {
+				return "", fmt.Errorf("length of %s without example", s.Format)
+			}
+			return typed(typ, strconv.Quote(v), "string"), nil
+		}
+		return typed(typ, fmt.Sprintf("strings.Repeat(\"a\", %d)", max(s.MinLength, 1)), "string"), nil
+
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		v, err := sampleNumber(s)
+		if err != nil {
+			return "", err
+		}
+		return typed(typ, formatFloat(v), "int"), nil
+
+	case s.Type.Is(openapi3.TypeBoolean):
+		return typed(typ, "true", "bool"), nil
+
+	case s.Type.Is(openapi3.TypeArray):
+		if s.MinItems == 0 {
+			return typ + "{}", nil
+		}
+		if s.UniqueItems {
+			return "", errors.New("unique items without example")
+		}
+		elem, ok := g.goType("", "", s.Items)
+		if !ok {
+			return "", errors.New("unsupported items")
+		}
+		item, err := g.sample(elem, s.Items)
+		if err != nil {
+			return "", err
+		}
+		return typed(typ, fmt.Sprintf("testRepeat(%d, %s)", s.MinItems, item), "[]"+elem), nil
+	}
+	return "", errors.New("unsupported type")
+}
+
+// sampleNumber returns a non-zero integral value within the bounds of s.
+func sampleNumber(s *openapi3.Schema) (float64, error) {
+	v := 1.0
+	switch {
+	case s.Min != nil && s.ExclusiveMin:
+		v = math.Floor(*s.Min) + 1
+	case s.Min != nil:
+		v = math.Ceil(*s.Min)
+	case s.Max != nil && s.ExclusiveMax:
+		v = math.Min(v, math.Ceil(*s.Max)-1)
+	case s.Max != nil:
+		v = math.Min(v, math.Floor(*s.Max))
+	}
+	if v == 0 || s.MultipleOf != nil || s.Max != nil && v > *s.Max {
+		return 0, errors.New("number without example")
+	}
+	return v, nil
+}
+
+// literal returns an expression of type typ holding the spec value v.
+func literal(typ string, v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return typed(typ, strconv.Quote(v), "string"), nil
+	case bool:
+		return typed(typ, strconv.FormatBool(v), "bool"), nil
+	case float64:
+		def := "float64"
+		if v == math.Trunc(v) {
+			def = "int"
+		}
+		return typed(typ, formatFloat(v), def), nil
+	case int:
+		return typed(typ, strconv.Itoa(v), "int"), nil
+	}
+	return "", fmt.Errorf("unsupported example %v", v)
+}
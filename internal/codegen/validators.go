@@ -0,0 +1,428 @@
+package codegen
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+)
+
+// Operations generates functions checking the JSON request body of every operation field
+// by field, without reflection or struct tags, and an OperationValidators table
+// dispatching to them by operation ID for middleware.WithOperationValidators.
+func Operations(doc *openapi3.T, pkg string) ([]byte, error) {
+	g := newGenerator(doc, "body")
+	g.operations()
+	g.models()
+	return g.file(pkg)
+}
+
+type inlineBody struct {
+	typ    string
+	schema *openapi3.Schema
+}
+
+func (g *generator) operations() {
+	g.imports["context"] = true
+	g.imports[middlewarePkg] = true
+
+	var inline []inlineBody
+	g.printf("// OperationValidators validates the request objects of each operation, see\n")
+	g.printf("// middleware.WithOperationValidators.\n")
+	g.printf("var OperationValidators = map[string]func(context.Context, any) error{\n")
+	for _, id := range g.operationIDs() {
+		op := g.operation(id)
+		rb := op.RequestBody.Value
+		mt := rb.Content.Get("application/json")
+		if mt == nil || mt.Schema == nil || mt.Schema.Value == nil || !isObject(mt.Schema.Value) {
+			continue
+		}
+
+		name := naming.TypeName(id)
+		var call string
+		if ref := mt.Schema.Ref; ref != "" {
+			call = fmt.Sprintf("%s%s((*%s)(req.Body), \"\")", g.check, typeName(ref), typeName(ref))
+		} else {
+			typ := name + "JSONRequestBody"
+			inline = append(inline, inlineBody{typ: typ, schema: mt.Schema.Value})
+			call = fmt.Sprintf("%s%s(req.Body, \"\")", g.check, typ)
+		}
+		missing := "nil"
+		if rb.Required {
+			missing = "middleware.ErrMissingBody"
+		}
+
+		g.printf("%q: func(ctx context.Context, args any) error {\n", name)
+		g.printf("req, ok := args.(%sRequestObject)\n", name)
+		g.printf("if !ok {\nreturn nil\n}\n")
+		g.printf("if req.Body == nil {\nreturn %s\n}\n", missing)
+		g.printf("return fieldErrors(%s)\n", call)
+		g.printf("},\n")
+	}
+	g.printf("}\n\n")
+
+	g.printf("func fieldErrors(errs []middleware.FieldError) error {\n")
+	g.printf("if len(errs) == 0 {\nreturn nil\n}\n")
+	g.printf("return &middleware.ValidationError{Fields: errs}\n")
+	g.printf("}\n\n")
+
+	for _, body := range inline {
+		g.object(body.typ, body.schema)
+	}
+}
+
+// operationIDs returns the sorted IDs of the operations with a request body.
+func (g *generator) operationIDs() []string {
+	var ids []string
+	for _, item := range g.doc.Paths.Map() {
+		for _, op := range item.Operations() {
+			if op.OperationID != "" && op.RequestBody != nil && op.RequestBody.Value != nil {
+				ids = append(ids, op.OperationID)
+			}
+		}
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func (g *generator) operation(id string) *openapi3.Operation {
+	for _, item := range g.doc.Paths.Map() {
+		for _, op := range item.Operations() {
+			if op.OperationID == id {
+				return op
+			}
+		}
+	}
+	return nil
+}
+
+// models generates the validation function of every object component schema.
+func (g *generator) models() {
+	if g.doc.Components == nil {
+		return
+	}
+	for _, name := range slices.Sorted(maps.Keys(g.doc.Components.Schemas)) {
+		if s := g.doc.Components.Schemas[name].Value; s != nil && isObject(s) {
+			g.object(naming.TypeName(name), s)
+		}
+	}
+}
+
+// object generates validateTyp, returning the errors of a struct generated from s.
+func (g *generator) object(typ string, s *openapi3.Schema) {
+	g.printf("func %s%s(v *%s, path string) (errs []%s) {\n", g.check, typ, typ, g.fieldError)
+	var w strings.Builder
+	g.owner = ""
+	if g.owned {
+		g.owner = typ
+	}
+	g.properties(&w, "v", path{base: "path"}, s)
+	g.body.WriteString(w.String())
+	g.printf("return errs\n}\n\n")
+}
+
+// properties writes the checks of the fields of recv, a struct generated from s.
+func (g *generator) properties(w *strings.Builder, recv string, prefix path, s *openapi3.Schema) {
+	for _, prop := range slices.Sorted(maps.Keys(s.Properties)) {
+		ref := s.Properties[prop]
+		if ref.Value == nil {
+			continue
+		}
+		field := recv + "." + naming.TypeName(prop)
+		p := prefix.add(prop)
+		x := g.local("f")
+
+		var checks strings.Builder
+		g.checks(&checks, x, p, ref)
+
+		if isPointer(s, prop, ref.Value) {
+			if checks.Len() == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "if %s != nil {\n%s := *%s\n%s}\n", field, x, field, checks.String())
+			continue
+		}
+
+		zero := zeroCheck(x, ref.Value)
+		required := slices.Contains(s.Required, prop)
+		if required && rules.ZeroAllowed(schema.FromKin(ref.Value)) {
+			// 0 is allowed, checked by the rules rather than rejected as missing.
+			zero = ""
+		}
+		switch {
+		case required && zero != "":
+			fmt.Fprintf(w, "if %s := %s; %s {\n", x, field, zero)
+			g.fail(w, x, p, "required", "", ref.Value)
+			if checks.Len() > 0 {
+				fmt.Fprintf(w, "} else {\n%s", checks.String())
+			}
+			w.WriteString("}\n")
+		case checks.Len() == 0:
+		case zero != "":
+			// Like omitempty, zero values of optional fields are not checked.
+			fmt.Fprintf(w, "if %s := %s; !(%s) {\n%s}\n", x, field, zero, checks.String())
+		default:
+			fmt.Fprintf(w, "{\n%s := %s\n%s}\n", x, field, checks.String())
+		}
+	}
+}
+
+// zeroCheck returns the expression testing whether x, of schema s, is the zero value.
+func zeroCheck(x string, s *openapi3.Schema) string {
+	switch {
+	case isString(s):
+		return x + ` == ""`
+	case s.Type.Is(openapi3.TypeArray):
+		return "len(" + x + ") == 0"
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		return x + " == 0"
+	case s.Type.Is(openapi3.TypeBoolean):
+		return "!" + x
+	}
+	return ""
+}
+
+// isString reports whether oapi-codegen generates a string type for s, rather than the
+// dedicated types of formats such as uuid or date-time.
+func isString(s *openapi3.Schema) bool {
+	if !s.Type.Is(openapi3.TypeString) {
+		return false
+	}
+	switch s.Format {
+	case "uuid", "date", "date-time", "binary", "byte":
+		return false
+	}
+	return true
+}
+
+// checks writes the checks of the value x of schema ref, reported at path p.
+func (g *generator) checks(w *strings.Builder, x string, p path, ref *openapi3.SchemaRef) {
+	s := ref.Value
+	if isObject(s) {
+		if ref.Ref != "" {
+			fmt.Fprintf(w, "errs = append(errs, %s%s(&%s, %s)...)\n", g.check, typeName(ref.Ref), x, p.add("."))
+		} else {
+			owner := g.owner
+			g.owner = ""
+			g.properties(w, x, p.add("."), s)
+			g.owner = owner
+		}
+		return
+	}
+
+	switch {
+	case isString(s):
+		g.stringChecks(w, x, p, s)
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		g.numberChecks(w, x, p, s)
+	case s.Type.Is(openapi3.TypeArray):
+		g.arrayChecks(w, x, p, s)
+	}
+}
+
+func (g *generator) stringChecks(w *strings.Builder, x string, p path, s *openapi3.Schema) {
+	str := "string(" + x + ")"
+	if s.MinLength > 0 {
+		g.imports["unicode/utf8"] = true
+		fmt.Fprintf(w, "if utf8.RuneCountInString(%s) < %d {\n", str, s.MinLength)
+		g.fail(w, x, p, "min", strconv.FormatUint(s.MinLength, 10), s)
+		w.WriteString("}\n")
+	}
+	if s.MaxLength != nil {
+		g.imports["unicode/utf8"] = true
+		fmt.Fprintf(w, "if utf8.RuneCountInString(%s) > %d {\n", str, *s.MaxLength)
+		g.fail(w, x, p, "max", strconv.FormatUint(*s.MaxLength, 10), s)
+		w.WriteString("}\n")
+	}
+	if s.Pattern != "" {
+		re := g.pattern(s.Pattern)
+		fmt.Fprintf(w, "if !%s.MatchString(%s) {\n", re, str)
+		g.fail(w, x, p, "regex", s.Pattern, s)
+		w.WriteString("}\n")
+	}
+	if fn, rule, ok := g.format(s.Format); ok {
+		fmt.Fprintf(w, "if !%s(%s) {\n", fn, str)
+		g.fail(w, x, p, rule, "", s)
+		w.WriteString("}\n")
+	}
+	g.enumCheck(w, str, x, p, "oneof", s, strconv.Quote)
+}
+
+func (g *generator) numberChecks(w *strings.Builder, x string, p path, s *openapi3.Schema) {
+	num := "float64(" + x + ")"
+	if s.Min != nil {
+		op, rule := "<", "gte"
+		if s.ExclusiveMin {
+			op, rule = "<=", "gt"
+		}
+		fmt.Fprintf(w, "if %s %s %s {\n", num, op, formatFloat(*s.Min))
+		g.fail(w, x, p, rule, formatFloat(*s.Min), s)
+		w.WriteString("}\n")
+	}
+	if s.Max != nil {
+		op, rule := ">", "lte"
+		if s.ExclusiveMax {
+			op, rule = ">=", "lt"
+		}
+		fmt.Fprintf(w, "if %s %s %s {\n", num, op, formatFloat(*s.Max))
+		g.fail(w, x, p, rule, formatFloat(*s.Max), s)
+		w.WriteString("}\n")
+	}
+	rule := "oneof"
+	if s.Type.Is(openapi3.TypeNumber) {
+		rule = "oneofnumber"
+	}
+	g.enumCheck(w, num, x, p, rule, s, func(v string) string { return v })
+}
+
+func (g *generator) arrayChecks(w *strings.Builder, x string, p path, s *openapi3.Schema) {
+	if s.MinItems > 0 {
+		fmt.Fprintf(w, "if len(%s) < %d {\n", x, s.MinItems)
+		g.fail(w, x, p, "min", strconv.FormatUint(s.MinItems, 10), s)
+		w.WriteString("}\n")
+	}
+	if s.MaxItems != nil {
+		fmt.Fprintf(w, "if len(%s) > %d {\n", x, *s.MaxItems)
+		g.fail(w, x, p, "max", strconv.FormatUint(*s.MaxItems, 10), s)
+		w.WriteString("}\n")
+	}
+	if s.Items == nil || s.Items.Value == nil {
+		return
+	}
+	items := s.Items.Value
+	if s.UniqueItems && !isObject(items) && !items.Type.Is(openapi3.TypeArray) {
+		seen := g.local("seen")
+		fmt.Fprintf(w, "%s := make(map[any]bool, len(%s))\n", seen, x)
+		fmt.Fprintf(w, "for _, item := range %s {\n", x)
+		fmt.Fprintf(w, "if %s[item] {\n", seen)
+		g.fail(w, x, p, "unique", "", s)
+		w.WriteString("break\n}\n")
+		fmt.Fprintf(w, "%s[item] = true\n}\n", seen)
+	}
+
+	i, item, itemPath := g.local("i"), g.local("item"), g.local("path")
+	var checks strings.Builder
+	g.checks(&checks, item, path{base: itemPath}, s.Items)
+	if checks.Len() == 0 {
+		return
+	}
+	g.imports["fmt"] = true
+	fmt.Fprintf(w, "for %s, %s := range %s {\n", i, item, x)
+	fmt.Fprintf(w, "%s := fmt.Sprintf(\"%%s[%%d]\", %s, %s)\n", itemPath, p, i)
+	w.WriteString(checks.String())
+	w.WriteString("}\n")
+}
+
+// enumCheck writes the check of an enum, comparing the converted value conv of x with
+// the enum values rendered by lit, reported as rule.
+func (g *generator) enumCheck(w *strings.Builder, conv, x string, p path, rule string, s *openapi3.Schema, lit func(string) string) {
+	if len(s.Enum) == 0 {
+		return
+	}
+	var values, cases []string
+	for _, v := range s.Enum {
+		str := fmt.Sprint(v)
+		if v == nil || slices.Contains(values, str) {
+			continue
+		}
+		values = append(values, str)
+		cases = append(cases, lit(str))
+	}
+	fmt.Fprintf(w, "switch %s {\ncase %s:\ndefault:\n", conv, strings.Join(cases, ", "))
+	g.fail(w, x, p, rule, strings.Join(values, " "), s)
+	w.WriteString("}\n")
+}
+
+// fail writes the report of a broken rule for the value x of schema s.
+func (g *generator) fail(w *strings.Builder, x string, p path, rule, param string, s *openapi3.Schema) {
+	fmt.Fprintf(w, "errs = append(errs, %s{Field: %s, Rule: %q", g.fieldError, p, rule)
+	if param != "" {
+		fmt.Fprintf(w, ", Param: %q", param)
+	}
+	if isSensitive(s) {
+		w.WriteString(", Redacted: true")
+	} else {
+		fmt.Fprintf(w, ", Value: %s", x)
+	}
+	if msg := errorMessage(s, rule); msg != "" {
+		fmt.Fprintf(w, ", Message: %q", msg)
+	}
+	if g.owner != "" {
+		fmt.Fprintf(w, ", Owner: %q", g.owner)
+	}
+	w.WriteString("})\n")
+}
+
+// errorMessage returns the custom message x-error-message defines for rule: either the
+// message of every rule or a map from rule to message.
+func errorMessage(s *openapi3.Schema, rule string) string {
+	switch msg := s.Extensions["x-error-message"].(type) {
+	case string:
+		return msg
+	case map[string]any:
+		if m, ok := msg[rule].(string); ok {
+			return m
+		}
+		m, _ := msg[""].(string)
+		return m
+	}
+	return ""
+}
+
+// format returns the function checking a string format and the rule it reports. Formats
+// oapi-codegen decodes into dedicated types, such as uuid or date-time, need no check.
+func (g *generator) format(f string) (fn, rule string, ok bool) {
+	switch f {
+	case "email":
+		g.imports["net/mail"] = true
+		fn, rule = g.prefix+"IsEmail", "email"
+	case "ipv4":
+		g.imports["net/netip"] = true
+		fn, rule = g.prefix+"IsIPv4", "ipv4"
+	case "ipv6":
+		g.imports["net/netip"] = true
+		fn, rule = g.prefix+"IsIPv6", "ipv6"
+	case "uri", "url":
+		g.imports["net/url"] = true
+		fn, rule, f = g.prefix+"IsURL", "url", "url"
+	default:
+		return "", "", false
+	}
+	g.formats[f] = true
+	return fn, rule, true
+}
+
+// formatFuncs are the sources of the format checks, to be formatted with the prefix of
+// the generator.
+var formatFuncs = map[string]string{
+	"email": `
+func %sIsEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+`,
+	"ipv4": `
+func %sIsIPv4(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is4()
+}
+`,
+	"ipv6": `
+func %sIsIPv6(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	return err == nil && addr.Is6()
+}
+`,
+	"url": `
+func %sIsURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && (u.Host != "" || u.Opaque != "")
+}
+`,
+}
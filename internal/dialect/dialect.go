@@ -0,0 +1,212 @@
+// Package dialect lets kin-openapi, which reads OpenAPI 3.0, load the keywords of OpenAPI
+// 3.1 it rejects: the numeric exclusiveMinimum and exclusiveMaximum, and the boolean
+// schemas of items, e.g. items: false after prefixItems. They are renamed to extensions
+// as the files are read, and back once the spec is loaded, kin-openapi keeping them with
+// the extensions of their schema as it does the other keywords of 3.1.
+package dialect
+
+import (
+	"bytes"
+	"iter"
+	"maps"
+	"net/url"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// prefix leads the extensions the keywords are renamed to.
+const prefix = "x-oapi-codegen-validator-"
+
+// keywords are the keywords renamed, with the tags of the scalar values kin-openapi
+// rejects.
+var keywords = map[string][]string{
+	"exclusiveMinimum": {"!!int", "!!float"},
+	"exclusiveMaximum": {"!!int", "!!float"},
+	"items":            {"!!bool"},
+}
+
+// dataKeys are the keywords whose values are instances rather than spec objects.
+var dataKeys = map[string]bool{
+	"enum":     true,
+	"default":  true,
+	"const":    true,
+	"example":  true,
+	"examples": true,
+}
+
+// Rename returns the spec data, YAML or JSON, with the keywords kin-openapi rejects
+// renamed to extensions, as YAML; as is when it has none.
+func Rename(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte("exclusiveM")) && !bytes.Contains(data, []byte("items")) {
+		return data, nil
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if !rename(&root) {
+		return data, nil
+	}
+	return yaml.Marshal(&root)
+}
+
+// Reader returns a ReadFromURIFunc reading with next, renaming the keywords kin-openapi
+// rejects in the files read. Files failing to parse are returned as read, for the loader
+// to report.
+func Reader(next openapi3.ReadFromURIFunc) openapi3.ReadFromURIFunc {
+	return func(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+		data, err := next(loader, location)
+		if err != nil {
+			return nil, err
+		}
+		if renamed, err := Rename(data); err == nil {
+			return renamed, nil
+		}
+		return data, nil
+	}
+}
+
+// rename renames the keywords kin-openapi rejects in the tree of node, and reports
+// whether it had any.
+func rename(node *yaml.Node) bool {
+	renamed := false
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, n := range node.Content {
+			renamed = rename(n) || renamed
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			switch {
+			case dataKeys[key.Value]:
+			case value.Kind == yaml.ScalarNode && slices.Contains(keywords[key.Value], value.ShortTag()):
+				key.Value = prefix + key.Value
+				renamed = true
+			default:
+				renamed = rename(value) || renamed
+			}
+		}
+	}
+	return renamed
+}
+
+// Restore renames the extensions of the schemas of doc back to their keywords, once doc
+// is loaded from files renamed by Reader, so that it is written as read. A document
+// without paths, e.g. of webhooks only, which 3.1 allows, gets empty ones, kin-openapi
+// writing them as null otherwise.
+func Restore(doc *openapi3.T) {
+	if doc.Paths == nil {
+		doc.Paths = openapi3.NewPaths()
+	}
+	for s := range schemas(doc) {
+		for keyword := range keywords {
+			if v, ok := s.Extensions[prefix+keyword]; ok {
+				delete(s.Extensions, prefix+keyword)
+				s.Extensions[keyword] = v
+			}
+		}
+	}
+}
+
+// schemas yields every schema of the components and operations of doc once, nested
+// schemas included.
+func schemas(doc *openapi3.T) iter.Seq[*openapi3.Schema] {
+	return func(yield func(*openapi3.Schema) bool) {
+		seen := make(map[*openapi3.Schema]bool)
+		var walk func(ref *openapi3.SchemaRef) bool
+		walk = func(ref *openapi3.SchemaRef) bool {
+			if ref == nil || ref.Value == nil || seen[ref.Value] {
+				return true
+			}
+			s := ref.Value
+			seen[s] = true
+			if !yield(s) {
+				return false
+			}
+			children := []*openapi3.SchemaRef{s.Items, s.AdditionalProperties.Schema, s.Not}
+			children = append(children, slices.Collect(maps.Values(s.Properties))...)
+			children = slices.Concat(children, s.AllOf, s.OneOf, s.AnyOf)
+			for _, child := range children {
+				if !walk(child) {
+					return false
+				}
+			}
+			return true
+		}
+		content := func(c openapi3.Content) bool {
+			for _, mt := range c {
+				if !walk(mt.Schema) {
+					return false
+				}
+			}
+			return true
+		}
+		headers := func(hs openapi3.Headers) bool {
+			for _, h := range hs {
+				if h.Value != nil && (!walk(h.Value.Schema) || !content(h.Value.Content)) {
+					return false
+				}
+			}
+			return true
+		}
+		parameters := func(ps openapi3.Parameters) bool {
+			for _, p := range ps {
+				if p.Value != nil && (!walk(p.Value.Schema) || !content(p.Value.Content)) {
+					return false
+				}
+			}
+			return true
+		}
+		responses := func(rs map[string]*openapi3.ResponseRef) bool {
+			for _, r := range rs {
+				if r.Value != nil && (!content(r.Value.Content) || !headers(r.Value.Headers)) {
+					return false
+				}
+			}
+			return true
+		}
+
+		if c := doc.Components; c != nil {
+			for _, ref := range c.Schemas {
+				if !walk(ref) {
+					return
+				}
+			}
+			for _, p := range c.Parameters {
+				if p.Value != nil && (!walk(p.Value.Schema) || !content(p.Value.Content)) {
+					return
+				}
+			}
+			if !headers(c.Headers) || !responses(c.Responses) {
+				return
+			}
+			for _, b := range c.RequestBodies {
+				if b.Value != nil && !content(b.Value.Content) {
+					return
+				}
+			}
+		}
+		if doc.Paths == nil {
+			return
+		}
+		for _, item := range doc.Paths.Map() {
+			if !parameters(item.Parameters) {
+				return
+			}
+			for _, op := range item.Operations() {
+				if !parameters(op.Parameters) {
+					return
+				}
+				if op.RequestBody != nil && op.RequestBody.Value != nil && !content(op.RequestBody.Value.Content) {
+					return
+				}
+				if op.Responses != nil && !responses(op.Responses.Map()) {
+					return
+				}
+			}
+		}
+	}
+}
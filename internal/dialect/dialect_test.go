@@ -0,0 +1,53 @@
+package dialect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRestore(t *testing.T) {
+	data, err := Rename([]byte(`
+openapi: 3.1.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Order:
+      type: object
+      properties:
+        quantity: {type: integer, exclusiveMinimum: 0, exclusiveMaximum: 100}
+        legacy: {type: integer, minimum: 0, exclusiveMinimum: true}
+        sample: {type: object, const: {exclusiveMinimum: 1}}
+        point: {type: array, prefixItems: [{type: number}, {type: number}], items: false}
+`))
+	require.NoError(t, err)
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	require.NoError(t, err)
+	Restore(doc)
+
+	props := doc.Components.Schemas["Order"].Value.Properties
+	for name, want := range map[string]string{"quantity": "gt=0,lt=100", "legacy": "gt=0", "point": "max=2"} {
+		got, err := rules.Generate(schema.FromKin(props[name].Value))
+		require.NoError(t, err)
+		assert.Equal(t, want, strings.Join(got, ","), name)
+	}
+
+	out, err := yaml.Marshal(props["quantity"].Value)
+	require.NoError(t, err)
+	assert.Equal(t, "exclusiveMaximum: 100\nexclusiveMinimum: 0\ntype: integer\n", string(out))
+	assert.Equal(t, map[string]any{"exclusiveMinimum": float64(1)}, props["sample"].Value.Extensions["const"])
+}
+
+func TestRenameUnchanged(t *testing.T) {
+	data := []byte("openapi: 3.0.0\ncomponents: {schemas: {A: {type: integer, minimum: 0, exclusiveMinimum: true}}}\n")
+	got, err := Rename(data)
+	require.NoError(t, err)
+	assert.Equal(t, data, got)
+}
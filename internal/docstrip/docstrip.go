@@ -0,0 +1,102 @@
+// Package docstrip removes the documentation of specs, their descriptions and examples,
+// before they are loaded by commands not needing it, to bound the memory giant specs take.
+package docstrip
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// docKeys are the keywords removed.
+var docKeys = map[string]bool{
+	"description": true,
+	"example":     true,
+	"examples":    true,
+}
+
+// nameMaps are the keywords whose values map names, e.g. of properties, to objects: a
+// property named description is not a description.
+var nameMaps = map[string]bool{
+	"properties":        true,
+	"patternProperties": true,
+	"dependentSchemas":  true,
+	"$defs":             true,
+	"definitions":       true,
+	"schemas":           true,
+	"responses":         true,
+	"parameters":        true,
+	"requestBodies":     true,
+	"headers":           true,
+	"securitySchemes":   true,
+	"links":             true,
+	"callbacks":         true,
+	"pathItems":         true,
+	"paths":             true,
+	"webhooks":          true,
+	"content":           true,
+	"encoding":          true,
+	"mapping":           true,
+	"variables":         true,
+}
+
+// dataKeys are the keywords whose values are instances rather than spec objects.
+var dataKeys = map[string]bool{
+	"enum":    true,
+	"default": true,
+	"const":   true,
+}
+
+// Strip returns the spec data, YAML or JSON, without descriptions and examples, as YAML.
+func Strip(data []byte) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	strip(&root, false)
+	return yaml.Marshal(&root)
+}
+
+// Reader returns a ReadFromURIFunc reading with next, stripping the files read. Files
+// failing to parse are returned as read, for the loader to report.
+func Reader(next openapi3.ReadFromURIFunc) openapi3.ReadFromURIFunc {
+	return func(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+		data, err := next(loader, location)
+		if err != nil {
+			return nil, err
+		}
+		if stripped, err := Strip(data); err == nil {
+			return stripped, nil
+		}
+		return data, nil
+	}
+}
+
+// strip removes the documentation keys of node, whose keys are names when names is set.
+func strip(node *yaml.Node, names bool) {
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, n := range node.Content {
+			strip(n, false)
+		}
+	case yaml.MappingNode:
+		content := node.Content[:0]
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			switch {
+			case names:
+				strip(value, false)
+			case docKeys[key.Value]:
+				continue
+			case dataKeys[key.Value] || strings.HasPrefix(key.Value, "x-"):
+				// Values of any shape, kept as is.
+			default:
+				strip(value, nameMaps[key.Value])
+			}
+			content = append(content, key, value)
+		}
+		node.Content = content
+	}
+}
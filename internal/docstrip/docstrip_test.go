@@ -0,0 +1,60 @@
+package docstrip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrip(t *testing.T) {
+	got, err := Strip([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0, description: A test API}
+paths:
+  /users:
+    post:
+      description: Creates a user
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/User'}
+            examples:
+              admin: {value: {description: admin}}
+      responses:
+        '201': {description: Created}
+components:
+  schemas:
+    User:
+      type: object
+      description: A user
+      example: {description: a user}
+      properties:
+        description: {type: string, description: The description of the user, maxLength: 200}
+        role: {type: string, enum: [admin, user], default: user}
+      x-doc: {description: kept}
+    description: {type: string}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, `openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+    /users:
+        post:
+            requestBody:
+                content:
+                    application/json:
+                        schema: {$ref: '#/components/schemas/User'}
+            responses:
+                '201': {}
+components:
+    schemas:
+        User:
+            type: object
+            properties:
+                description: {type: string, maxLength: 200}
+                role: {type: string, enum: [admin, user], default: user}
+            x-doc: {description: kept}
+        description: {type: string}
+`, string(got))
+}
@@ -0,0 +1,567 @@
+// Package libopenapi enriches specs with pb33f/libopenapi, which reads the OpenAPI 3.1
+// keywords kin-openapi does not, such as a numeric exclusiveMinimum or a type list
+// including null, and gives access to the YAML nodes of the spec: the validate tags are
+// set in place, keeping the order of the keys and the comments of the spec.
+package libopenapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/hadrienk/oapi-codegen-validator/internal/celrules"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+	"github.com/hadrienk/oapi-codegen-validator/internal/swagger"
+	"github.com/hadrienk/oapi-codegen-validator/internal/yaml11"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/enrich"
+	pb33f "github.com/pb33f/libopenapi"
+	"github.com/pb33f/libopenapi/datamodel"
+	"github.com/pb33f/libopenapi/datamodel/high/base"
+	v3 "github.com/pb33f/libopenapi/datamodel/high/v3"
+	"github.com/pb33f/libopenapi/orderedmap"
+	"go.yaml.in/yaml/v4"
+)
+
+const tagKey = rules.TagKey
+
+// EnrichFile writes the spec at path enriched with profile to w, as YAML, resolving its
+// references to other files from its directory.
+func EnrichFile(w io.Writer, path string, profile enrich.Profile) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if swagger.Is(data) {
+		// Converted, the spec loses its order and comments.
+		if data, err = swagger.Convert(data); err != nil {
+			return err
+		}
+	}
+	return Enrich(w, data, filepath.Dir(path), profile)
+}
+
+// Enrich writes the spec data enriched with profile to w, as YAML, as Profile.Spec would:
+// the schemas are converted to the model of the rules, whose tags are then set on their
+// nodes. The document is streamed to w rather than marshaled in memory first.
+func Enrich(w io.Writer, data []byte, basePath string, profile enrich.Profile) error {
+	cfg := datamodel.NewDocumentConfiguration()
+	cfg.BasePath = basePath
+	cfg.AllowFileReferences = true
+	doc, err := pb33f.NewDocumentWithConfiguration(data, cfg)
+	if err != nil {
+		return err
+	}
+	model, err := doc.BuildV3Model()
+	if err != nil {
+		return err
+	}
+
+	e := &enricher{
+		profile: profile,
+		rules:   profile.Options(),
+		models:  make(map[*yaml.Node]*schema.Schema),
+		nodes:   make(map[*schema.Schema]*yaml.Node),
+		tags:    make(map[*schema.Schema]any),
+		visited: make(map[*schema.Schema]bool),
+	}
+	if err := errors.Join(e.components(model.Model.Components), e.paths(model.Model.Paths)); err != nil {
+		return err
+	}
+	for m, node := range e.nodes {
+		ext, has := m.Extensions[tagKey]
+		if orig, had := e.tags[m]; had == has && reflect.DeepEqual(orig, ext) {
+			continue
+		}
+		if err := setKey(node, tagKey, ext, has); err != nil {
+			return err
+		}
+	}
+
+	info := doc.GetSpecInfo()
+	yaml11.Quote(info.RootNode)
+	dumper, err := yaml.NewDumper(w, yaml.WithV3Defaults(), yaml.WithIndent(max(info.OriginalIndentation, 2)), yaml.WithLineWidth(-1))
+	if err != nil {
+		return err
+	}
+	if err := dumper.Dump(info.RootNode); err != nil {
+		return err
+	}
+	return dumper.Close()
+}
+
+// enricher converts the libopenapi schemas to the model, each once, keeping the node and
+// the original tags of each.
+type enricher struct {
+	profile enrich.Profile
+	rules   rules.Options
+	models  map[*yaml.Node]*schema.Schema
+	nodes   map[*schema.Schema]*yaml.Node
+	tags    map[*schema.Schema]any
+	visited map[*schema.Schema]bool
+}
+
+// components enriches the properties of the component schemas, nested ones included,
+// and the headers.
+func (e *enricher) components(components *v3.Components) (errs error) {
+	if components == nil {
+		return nil
+	}
+	for name, proxy := range components.Schemas.FromOldest() {
+		hs := proxy.Schema()
+		pointer := rules.Pointer("/components/schemas", name)
+		if hs == nil {
+			errs = errors.Join(errs, rules.Locate(pointer, proxy.GetBuildError()))
+			continue
+		}
+		errs = errors.Join(errs, e.node(pointer, hs))
+	}
+	headers := func(pointer string, hs *orderedmap.Map[string, *v3.Header]) {
+		for name, h := range hs.FromOldest() {
+			if h.Schema == nil {
+				continue
+			}
+			if err := e.field(h.Schema, h.Required, nil); err != nil {
+				errs = errors.Join(errs, rules.Locate(rules.Pointer(pointer, name, "schema"), err))
+			}
+		}
+	}
+	headers("/components/headers", components.Headers)
+	for name, r := range components.Responses.FromOldest() {
+		headers(rules.Pointer("/components/responses", name, "headers"), r.Headers)
+	}
+	for name, p := range components.Parameters.FromOldest() {
+		errs = errors.Join(errs, e.parameter(rules.Pointer("/components/parameters", name), p))
+	}
+	return errs
+}
+
+// paths enriches the parameters and the schemas of the request bodies and responses of
+// the operations, declared inline, and the schemas they reach, those of the components
+// excepted.
+func (e *enricher) paths(paths *v3.Paths) (errs error) {
+	if paths == nil {
+		return nil
+	}
+	content := func(pointer string, content *orderedmap.Map[string, *v3.MediaType]) {
+		for mediaType, mt := range content.FromOldest() {
+			if mt.Schema == nil {
+				continue
+			}
+			schemaPointer := rules.Pointer(pointer, "content", mediaType, "schema")
+			hs := mt.Schema.Schema()
+			if hs == nil {
+				errs = errors.Join(errs, rules.Locate(schemaPointer, mt.Schema.GetBuildError()))
+				continue
+			}
+			errs = errors.Join(errs, e.node(schemaPointer, hs))
+		}
+	}
+	for path, item := range paths.PathItems.FromOldest() {
+		for i, p := range item.Parameters {
+			errs = errors.Join(errs, e.parameter(rules.Pointer("/paths", path, "parameters", strconv.Itoa(i)), p))
+		}
+		for method, op := range item.GetOperations().FromOldest() {
+			pointer := rules.Pointer("/paths", path, method)
+			for i, p := range op.Parameters {
+				errs = errors.Join(errs, e.parameter(rules.Pointer(pointer, "parameters", strconv.Itoa(i)), p))
+			}
+			if op.RequestBody != nil {
+				content(rules.Pointer(pointer, "requestBody"), op.RequestBody.Content)
+			}
+			if op.Responses == nil {
+				continue
+			}
+			for status, resp := range op.Responses.Codes.FromOldest() {
+				content(rules.Pointer(pointer, "responses", status), resp.Content)
+			}
+			if op.Responses.Default != nil {
+				content(rules.Pointer(pointer, "responses", "default"), op.Responses.Default.Content)
+			}
+		}
+	}
+	return errs
+}
+
+// parameter sets the validate tag of the field generated from p, at pointer, in the
+// extensions of p, like enrich.Spec. References are left to the parameter they point to.
+func (e *enricher) parameter(pointer string, p *v3.Parameter) error {
+	if p == nil || p.IsReference() || p.Schema == nil {
+		return nil
+	}
+	hs := p.Schema.Schema()
+	if hs == nil {
+		return rules.Locate(rules.Pointer(pointer, "schema"), p.Schema.GetBuildError())
+	}
+	m, err := e.model(hs)
+	if err != nil {
+		return rules.Locate(rules.Pointer(pointer, "schema"), err)
+	}
+	var ext map[string]any
+	if n, ok := p.Extensions.Get(tagKey); ok {
+		if err := n.Decode(&ext); err != nil {
+			return rules.Locate(rules.Pointer(pointer, tagKey), err)
+		}
+	}
+	existing, _ := ext["validate"].(string)
+	tag, err := e.rules.Field(m, rules.UnquoteTag(existing), p.Required != nil && *p.Required)
+	if err != nil {
+		return rules.Locate(rules.Pointer(pointer, "schema"), err)
+	}
+	if tag = rules.QuoteTag(tag); tag == existing {
+		return nil
+	}
+	if tag == "" {
+		return setKey(p.GoLow().RootNode, tagKey, nil, false)
+	}
+	ext = maps.Clone(ext)
+	if ext == nil {
+		ext = make(map[string]any)
+	}
+	ext["validate"] = tag
+	return setKey(p.GoLow().RootNode, tagKey, ext, true)
+}
+
+// node enriches the properties of hs, at pointer, then its nested schemas, its items,
+// additional properties and oneOf and anyOf variants included, like enrich.Spec.
+func (e *enricher) node(pointer string, hs *base.Schema) (errs error) {
+	m, err := e.model(hs)
+	if err != nil {
+		return rules.Locate(pointer, err)
+	}
+	if e.visited[m] {
+		return nil
+	}
+	e.visited[m] = true
+	if _, err := celrules.Compile(m); err != nil {
+		errs = rules.Locate(pointer, &rules.KeywordError{Keyword: celrules.Keyword, Err: err})
+	}
+	if e.profile.RejectUnknownFields && m.Is(schema.TypeObject) && orderedmap.Len(hs.Properties) > 0 && hs.AdditionalProperties == nil {
+		if err := setKey(e.nodes[m], "additionalProperties", false, true); err != nil {
+			errs = errors.Join(errs, rules.Locate(pointer, err))
+		}
+	}
+	requiredIf, untranslated := rules.Conditions(m)
+	if err := rules.Locate(pointer, untranslated); err != nil && e.profile.Warn != nil {
+		e.profile.Warn(err)
+	}
+	for prop, proxy := range hs.Properties.FromOldest() {
+		if err := e.field(proxy, slices.Contains(hs.Required, prop), requiredIf[prop]); err != nil {
+			errs = errors.Join(errs, rules.Locate(rules.Pointer(pointer, "properties", prop), err))
+		}
+	}
+	for prop, proxy := range hs.Properties.FromOldest() {
+		errs = errors.Join(errs, e.node(rules.Pointer(pointer, "properties", prop), proxy.Schema()))
+	}
+	if hs.Items != nil && hs.Items.IsA() {
+		itemsPointer := rules.Pointer(pointer, "items")
+		if items := hs.Items.A.Schema(); items != nil {
+			errs = errors.Join(errs, e.node(itemsPointer, items))
+		} else {
+			errs = errors.Join(errs, rules.Locate(itemsPointer, hs.Items.A.GetBuildError()))
+		}
+	}
+	if ap := hs.AdditionalProperties; ap != nil && ap.IsA() {
+		valuesPointer := rules.Pointer(pointer, "additionalProperties")
+		if values := ap.A.Schema(); values != nil {
+			errs = errors.Join(errs, e.node(valuesPointer, values))
+		} else {
+			errs = errors.Join(errs, rules.Locate(valuesPointer, ap.A.GetBuildError()))
+		}
+	}
+	for _, composition := range []struct {
+		keyword  string
+		variants []*base.SchemaProxy
+	}{{"oneOf", hs.OneOf}, {"anyOf", hs.AnyOf}} {
+		for i, proxy := range composition.variants {
+			variantPointer := rules.Pointer(pointer, composition.keyword, strconv.Itoa(i))
+			variant := proxy.Schema()
+			if variant == nil {
+				errs = errors.Join(errs, rules.Locate(variantPointer, proxy.GetBuildError()))
+				continue
+			}
+			errs = errors.Join(errs, e.node(variantPointer, variant))
+		}
+	}
+	return errs
+}
+
+// field sets the validate tag of the struct field generated from the schema of proxy,
+// also required under the conditions of requiredIf, and its directional tags if the
+// profile has them.
+func (e *enricher) field(proxy *base.SchemaProxy, required bool, requiredIf []string) error {
+	hs := proxy.Schema()
+	if hs == nil {
+		return proxy.GetBuildError()
+	}
+	m, err := e.model(hs)
+	if err != nil {
+		return err
+	}
+	ext, _ := m.Extensions[tagKey].(map[string]any)
+	existing, _ := ext["validate"].(string)
+	tags := make(map[string]string)
+	tags["validate"], err = e.rules.FieldRequiredIf(m, rules.UnquoteTag(existing), required, requiredIf)
+	if err != nil {
+		return err
+	}
+	if e.profile.Directional {
+		request, _ := ext[rules.RequestTag].(string)
+		response, _ := ext[rules.ResponseTag].(string)
+		tags[rules.RequestTag], tags[rules.ResponseTag], err = e.rules.Directional(m, rules.UnquoteTag(request), rules.UnquoteTag(response), required, requiredIf)
+		if err != nil {
+			return err
+		}
+	}
+	if tags["validate"] == "" && tags[rules.RequestTag] == "" && tags[rules.ResponseTag] == "" {
+		delete(m.Extensions, tagKey)
+		return nil
+	}
+	ext = maps.Clone(ext)
+	if ext == nil {
+		ext = make(map[string]any)
+	}
+	for key, tag := range tags {
+		if tag == "" {
+			delete(ext, key)
+		} else {
+			ext[key] = rules.QuoteTag(tag)
+		}
+	}
+	m.Extensions[tagKey] = ext
+	return nil
+}
+
+// model converts hs to the model of the rules, the keywords of 3.1 to their 3.0 form.
+func (e *enricher) model(hs *base.Schema) (*schema.Schema, error) {
+	node := hs.GoLow().RootNode
+	if m, ok := e.models[node]; ok {
+		return m, nil
+	}
+	m := &schema.Schema{Extensions: make(map[string]any)}
+	e.models[node], e.nodes[m] = m, node
+
+	for _, t := range hs.Type {
+		if t == "null" {
+			m.Nullable = true
+		} else {
+			m.Types = append(m.Types, t)
+		}
+	}
+	m.Nullable = m.Nullable || hs.Nullable != nil && *hs.Nullable
+	m.Format, m.Pattern, m.ContentEncoding, m.ContentMediaType = hs.Format, hs.Pattern, hs.ContentEncoding, hs.ContentMediaType
+	m.Minimum, m.Maximum, m.MultipleOf = hs.Minimum, hs.Maximum, hs.MultipleOf
+	if b := hs.ExclusiveMinimum; b != nil {
+		if b.IsA() {
+			m.ExclusiveMinimum = b.A
+		} else {
+			m.Minimum, m.ExclusiveMinimum = &b.B, true
+		}
+	}
+	if b := hs.ExclusiveMaximum; b != nil {
+		if b.IsA() {
+			m.ExclusiveMaximum = b.A
+		} else {
+			m.Maximum, m.ExclusiveMaximum = &b.B, true
+		}
+	}
+	m.MinLength, m.MaxLength = unsigned(hs.MinLength), optional(hs.MaxLength)
+	m.MinItems, m.MaxItems = unsigned(hs.MinItems), optional(hs.MaxItems)
+	m.UniqueItems = hs.UniqueItems != nil && *hs.UniqueItems
+	m.ReadOnly = hs.ReadOnly != nil && *hs.ReadOnly
+	m.WriteOnly = hs.WriteOnly != nil && *hs.WriteOnly
+	m.MinProperties, m.MaxProperties = unsigned(hs.MinProperties), optional(hs.MaxProperties)
+	if ap := hs.AdditionalProperties; ap != nil {
+		m.AdditionalProperties = ap.IsA() || ap.B
+	}
+	m.Required = hs.Required
+	if hs.DependentRequired != nil {
+		m.DependentRequired = make(map[string][]string)
+	}
+	for name, dependents := range hs.DependentRequired.FromOldest() {
+		m.DependentRequired[name] = dependents
+	}
+	for _, n := range hs.Enum {
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return nil, fmt.Errorf("enum: %w", err)
+		}
+		m.Enum = append(m.Enum, v)
+	}
+	if hs.Const != nil {
+		if err := hs.Const.Decode(&m.Const); err != nil {
+			return nil, fmt.Errorf("const: %w", err)
+		}
+	}
+	for name, n := range hs.Extensions.FromOldest() {
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return nil, fmt.Errorf("extension %s: %w", name, err)
+		}
+		m.Extensions[name] = v
+	}
+	if ext, ok := m.Extensions[tagKey]; ok {
+		e.tags[m] = ext
+	}
+
+	if hs.Properties != nil {
+		m.Properties = make(map[string]*schema.Schema)
+	}
+	for name, proxy := range hs.Properties.FromOldest() {
+		child := proxy.Schema()
+		if child == nil {
+			return nil, fmt.Errorf("property %s: %w", name, proxy.GetBuildError())
+		}
+		var err error
+		if m.Properties[name], err = e.model(child); err != nil {
+			return nil, fmt.Errorf("property %s: %w", name, err)
+		}
+	}
+	for i, proxy := range hs.AllOf {
+		member := proxy.Schema()
+		if member == nil {
+			return nil, fmt.Errorf("allOf %d: %w", i, proxy.GetBuildError())
+		}
+		model, err := e.model(member)
+		if err != nil {
+			return nil, fmt.Errorf("allOf %d: %w", i, err)
+		}
+		m.AllOf = append(m.AllOf, model)
+	}
+	for i, proxy := range hs.OneOf {
+		variant := proxy.Schema()
+		if variant == nil {
+			return nil, fmt.Errorf("oneOf %d: %w", i, proxy.GetBuildError())
+		}
+		model, err := e.model(variant)
+		if err != nil {
+			return nil, fmt.Errorf("oneOf %d: %w", i, err)
+		}
+		m.OneOf = append(m.OneOf, model)
+	}
+	if d := hs.Discriminator; d != nil {
+		mapping := make(map[string]string)
+		for value, target := range d.Mapping.FromOldest() {
+			mapping[value] = target
+		}
+		m.Discriminator = &schema.Discriminator{PropertyName: d.PropertyName}
+		for _, proxy := range hs.OneOf {
+			m.Discriminator.Values = append(m.Discriminator.Values, schema.DiscriminatorValues(mapping, proxy.GetReference()))
+		}
+	}
+	for i, proxy := range hs.PrefixItems {
+		item := proxy.Schema()
+		if item == nil {
+			return nil, fmt.Errorf("prefixItems %d: %w", i, proxy.GetBuildError())
+		}
+		model, err := e.model(item)
+		if err != nil {
+			return nil, fmt.Errorf("prefixItems %d: %w", i, err)
+		}
+		m.PrefixItems = append(m.PrefixItems, model)
+	}
+	if hs.Items != nil && hs.Items.IsB() {
+		m.ClosedItems = !hs.Items.B
+	}
+	if hs.Items != nil && hs.Items.IsA() {
+		items := hs.Items.A.Schema()
+		if items == nil {
+			return nil, fmt.Errorf("items: %w", hs.Items.A.GetBuildError())
+		}
+		var err error
+		if m.Items, err = e.model(items); err != nil {
+			return nil, fmt.Errorf("items: %w", err)
+		}
+	}
+	if ap := hs.AdditionalProperties; ap != nil && ap.IsA() {
+		values := ap.A.Schema()
+		if values == nil {
+			return nil, fmt.Errorf("additionalProperties: %w", ap.A.GetBuildError())
+		}
+		var err error
+		if m.Values, err = e.model(values); err != nil {
+			return nil, fmt.Errorf("additionalProperties: %w", err)
+		}
+	}
+	for _, sub := range []struct {
+		keyword string
+		proxy   *base.SchemaProxy
+		model   **schema.Schema
+	}{
+		{"propertyNames", hs.PropertyNames, &m.PropertyNames},
+		{"not", hs.Not, &m.Not},
+		{"if", hs.If, &m.If},
+		{"then", hs.Then, &m.Then},
+		{"else", hs.Else, &m.Else},
+	} {
+		if sub.proxy == nil {
+			continue
+		}
+		s := sub.proxy.Schema()
+		if s == nil {
+			return nil, fmt.Errorf("%s: %w", sub.keyword, sub.proxy.GetBuildError())
+		}
+		var err error
+		if *sub.model, err = e.model(s); err != nil {
+			return nil, fmt.Errorf("%s: %w", sub.keyword, err)
+		}
+	}
+	return m, nil
+}
+
+func unsigned(n *int64) uint64 {
+	if n == nil || *n < 0 {
+		return 0
+	}
+	return uint64(*n)
+}
+
+func optional(n *int64) *uint64 {
+	if n == nil || *n < 0 {
+		return nil
+	}
+	u := uint64(*n)
+	return &u
+}
+
+// setKey sets the value of key in mapping, appending it if missing, or deletes it when
+// not present.
+func setKey(mapping *yaml.Node, key string, value any, present bool) error {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+		if !present {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return nil
+		}
+		return encodeInto(mapping.Content[i+1], value, mapping.Style)
+	}
+	if !present {
+		return nil
+	}
+	var v yaml.Node
+	if err := encodeInto(&v, value, mapping.Style); err != nil {
+		return err
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &v)
+	return nil
+}
+
+// encodeInto replaces the value of node by value, keeping its comments.
+func encodeInto(node *yaml.Node, value any, style yaml.Style) error {
+	var v yaml.Node
+	if err := v.Encode(value); err != nil {
+		return err
+	}
+	v.Style |= style & yaml.FlowStyle
+	v.HeadComment, v.LineComment, v.FootComment = node.HeadComment, node.LineComment, node.FootComment
+	*node = v
+	return nil
+}
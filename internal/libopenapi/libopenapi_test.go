@@ -0,0 +1,232 @@
+package libopenapi
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hadrienk/oapi-codegen-validator/pkg/enrich"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnrichFile(t *testing.T) {
+	var got strings.Builder
+	require.NoError(t, EnrichFile(&got, "testdata/users.input.yaml", enrich.Default))
+	expected, err := os.ReadFile("testdata/users.expected.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), got.String())
+}
+
+func TestEnrichConflict(t *testing.T) {
+	err := Enrich(io.Discard, []byte(`
+openapi: 3.1.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        name:
+          type: string
+          maxLength: 20
+          x-oapi-codegen-extra-tags: {validate: max=10}
+`), ".", enrich.Default)
+	assert.ErrorContains(t, err, "/components/schemas/User/properties/name/x-oapi-codegen-extra-tags: conflict: manual tag 'max=10' differs from generated tag 'max=20'")
+}
+
+func TestEnrichStrictInternal(t *testing.T) {
+	var got strings.Builder
+	err := Enrich(&got, []byte(`openapi: 3.1.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        host: {type: string, format: hostname}
+`), ".", enrich.StrictInternal)
+	require.NoError(t, err)
+	assert.Equal(t, `openapi: 3.1.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        host: {type: string, format: hostname, x-oapi-codegen-extra-tags: {validate: 'omitempty,hostname_rfc1123'}}
+      additionalProperties: false
+`, got.String())
+}
+
+func TestEnrichQuotesYAML11Scalars(t *testing.T) {
+	var got strings.Builder
+	require.NoError(t, Enrich(&got, []byte(`openapi: 3.1.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Country:
+      type: object
+      properties:
+        code: {type: string, enum: [NO, SE, on], example: NO}
+`), ".", enrich.Default))
+	assert.Contains(t, got.String(), `code: {type: string, enum: ["NO", SE, "on"], example: "NO", x-oapi-codegen-extra-tags: {validate: 'omitempty,oneof=NO SE on'}}`)
+	assert.Contains(t, got.String(), "version: 1.0.0")
+}
+
+func TestEnrichDiscriminator(t *testing.T) {
+	var got strings.Builder
+	require.NoError(t, Enrich(&got, []byte(`
+openapi: 3.1.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        petType: {type: string}
+        bark: {type: string}
+      oneOf: [{$ref: '#/components/schemas/Dog'}]
+      discriminator:
+        propertyName: petType
+        mapping: {dog: '#/components/schemas/Dog'}
+    Dog:
+      type: object
+      required: [bark]
+      properties:
+        bark: {type: string}
+`), ".", enrich.Default))
+	assert.Contains(t, got.String(), "bark: {type: string, x-oapi-codegen-extra-tags: {validate: required_if=PetType dog}}")
+}
+
+func TestEnrichArrayItems(t *testing.T) {
+	var got strings.Builder
+	require.NoError(t, Enrich(&got, []byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        addresses:
+          type: array
+          items:
+            type: object
+            properties:
+              zip: {type: string, maxLength: 5}
+`), ".", enrich.Default))
+	assert.Contains(t, got.String(), "zip: {type: string, maxLength: 5, x-oapi-codegen-extra-tags: {validate: 'omitempty,max=5'}}")
+}
+
+func TestEnrichAdditionalProperties(t *testing.T) {
+	var got strings.Builder
+	require.NoError(t, Enrich(&got, []byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Inventory:
+      type: object
+      properties:
+        stock:
+          type: object
+          additionalProperties:
+            type: object
+            properties:
+              sku: {type: string, maxLength: 12}
+`), ".", enrich.Default))
+	assert.Contains(t, got.String(), "sku: {type: string, maxLength: 12, x-oapi-codegen-extra-tags: {validate: 'omitempty,max=12'}}")
+}
+
+func TestEnrichRecursive(t *testing.T) {
+	var got strings.Builder
+	require.NoError(t, Enrich(&got, []byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Category:
+      type: object
+      properties:
+        name: {type: string, maxLength: 20}
+        children:
+          type: array
+          items: {$ref: '#/components/schemas/Category'}
+        index:
+          type: object
+          additionalProperties: {$ref: '#/components/schemas/Category'}
+`), ".", enrich.Default))
+	assert.Contains(t, got.String(), "name: {type: string, maxLength: 20, x-oapi-codegen-extra-tags: {validate: 'omitempty,max=20'}}")
+}
+
+func TestEnrichParameters(t *testing.T) {
+	var got strings.Builder
+	require.NoError(t, Enrich(&got, []byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /users:
+    get:
+      parameters:
+        - {name: page, in: query, schema: {type: integer, minimum: 1}}
+      responses:
+        "200": {description: OK}
+`), ".", enrich.Default))
+	assert.Contains(t, got.String(), "- {name: page, in: query, schema: {type: integer, minimum: 1}, x-oapi-codegen-extra-tags: {validate: 'omitempty,gte=1'}}")
+}
+
+func TestEnrichIfThenElse(t *testing.T) {
+	var got strings.Builder
+	var warnings []error
+	profile := enrich.Default
+	profile.Warn = func(err error) { warnings = append(warnings, err) }
+	require.NoError(t, Enrich(&got, []byte(`
+openapi: 3.1.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Account:
+      type: object
+      properties:
+        kind: {type: string}
+        vatNumber: {type: string}
+        birthDate: {type: string}
+      if: {required: [kind], properties: {kind: {const: business}}}
+      then: {required: [vatNumber], properties: {vatNumber: {minLength: 5}}}
+      else: {not: {required: [vatNumber]}}
+`), ".", profile))
+	assert.Contains(t, got.String(), "vatNumber: {type: string, x-oapi-codegen-extra-tags: {validate: 'required_if=Kind business,excluded_unless=Kind business'}}")
+	require.Len(t, warnings, 1)
+	assert.EqualError(t, warnings[0], "/components/schemas/Account/then: validation keyword 'then' is only translated for the properties it requires or forbids with not: {required: [...]}, its other keywords are not checked")
+}
+
+func TestEnrichDependentRequired(t *testing.T) {
+	var got strings.Builder
+	require.NoError(t, Enrich(&got, []byte(`
+openapi: 3.1.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Payment:
+      type: object
+      properties:
+        cardNumber: {type: string}
+        billingAddress: {type: string}
+        point: {type: array, prefixItems: [{type: number}], items: false}
+      dependentRequired: {cardNumber: [billingAddress]}
+`), ".", enrich.Default))
+	assert.Contains(t, got.String(), "billingAddress: {type: string, x-oapi-codegen-extra-tags: {validate: required_with=CardNumber}}")
+	assert.Contains(t, got.String(), "items: false, x-oapi-codegen-extra-tags: {validate: 'omitempty,max=1'}}")
+}
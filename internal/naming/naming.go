@@ -0,0 +1,41 @@
+// Package naming reproduces the identifiers oapi-codegen derives from a spec, so that
+// generated code and runtime lookups agree with the code it generates.
+package naming
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ToCamelCase upper-cases the first letter of s and every letter following one of
+// "_", " ", "-" or ".", dropping every character that is neither a letter nor a digit.
+func ToCamelCase(s string) string {
+	var b strings.Builder
+	capNext := true
+	for _, r := range strings.Trim(s, " ") {
+		switch {
+		case unicode.IsUpper(r), unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsLower(r):
+			if capNext {
+				r = unicode.ToUpper(r)
+			}
+			b.WriteRune(r)
+		}
+		capNext = r == '_' || r == ' ' || r == '-' || r == '.'
+	}
+	return b.String()
+}
+
+// TypeName returns the Go identifier oapi-codegen generates for a schema, property or
+// operation name, e.g. "UserAccount" for "user-account".
+func TypeName(name string) string {
+	if name == "$" {
+		return "DollarSign"
+	}
+	name = ToCamelCase(name)
+	if name != "" && unicode.IsDigit(rune(name[0])) {
+		name = "N" + name
+	}
+	return name
+}
@@ -0,0 +1,176 @@
+package patterns
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// UnsupportedError lists the constructs of an ECMA-262 pattern RE2 cannot express.
+type UnsupportedError struct {
+	Constructs []string
+}
+
+func (e *UnsupportedError) Error() string {
+	return "unsupported ECMA-262 constructs: " + strings.Join(e.Constructs, ", ")
+}
+
+// lookarounds are the ECMA-262 lookaround groups, by opening.
+var lookarounds = []struct{ open, name string }{
+	{"(?=", "lookahead"},
+	{"(?!", "negative lookahead"},
+	{"(?<=", "lookbehind"},
+	{"(?<!", "negative lookbehind"},
+}
+
+// Translate rewrites the ECMA-262 syntax of pattern, the dialect of JSON Schema, that
+// RE2 rejects or reads differently to its RE2 equivalent:
+//
+//   - \uXXXX, surrogate pairs included, and \u{X...} become \x{X...};
+//   - \cX becomes the control character it stands for;
+//   - [\b] becomes the backspace;
+//   - [] and [^], which match nothing and any character, become ranges;
+//   - a - next to \d, \w or \s in a class is a literal.
+//
+// Its error is an *UnsupportedError listing the constructs RE2 cannot express:
+// lookarounds and backreferences.
+func Translate(pattern string) (string, error) {
+	var b strings.Builder
+	var unsupported []string
+	fail := func(construct string) {
+		if !slices.Contains(unsupported, construct) {
+			unsupported = append(unsupported, construct)
+		}
+	}
+	inClass := false
+	// last is the escape just read, if any.
+	var last string
+	for i := 0; i < len(pattern); {
+		rest := pattern[i:]
+		escaped := last
+		last = ""
+		switch {
+		case rest[0] == '\\' && len(rest) > 1:
+			n := escape(&b, rest, inClass, fail)
+			last = rest[:n]
+			i += n
+			continue
+		case inClass && rest[0] == ']':
+			inClass = false
+		case inClass && rest[0] == '-' && (classEscape(escaped) || len(rest) > 2 && classEscape(rest[1:3])):
+			b.WriteString(`\-`)
+			i++
+			continue
+		case !inClass && strings.HasPrefix(rest, "[]"):
+			b.WriteString(`[^\x00-\x{10FFFF}]`)
+			i += 2
+			continue
+		case !inClass && strings.HasPrefix(rest, "[^]"):
+			b.WriteString(`[\x00-\x{10FFFF}]`)
+			i += 3
+			continue
+		case !inClass && rest[0] == '[':
+			inClass = true
+			open := 1
+			if strings.HasPrefix(rest, "[^") {
+				open = 2
+			}
+			b.WriteString(rest[:open])
+			i += open
+			continue
+		case !inClass && rest[0] == '(':
+			for _, l := range lookarounds {
+				if strings.HasPrefix(rest, l.open) {
+					fail(l.name + " " + l.open)
+				}
+			}
+		}
+		b.WriteByte(rest[0])
+		i++
+	}
+	if len(unsupported) > 0 {
+		return pattern, &UnsupportedError{Constructs: unsupported}
+	}
+	return b.String(), nil
+}
+
+// CompileECMA compiles the ECMA-262 pattern once translated, see Translate.
+func CompileECMA(pattern string) (*regexp.Regexp, error) {
+	translated, err := Translate(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(translated)
+}
+
+// escape writes the translation of the escape rest starts with, and returns its length.
+func escape(b *strings.Builder, rest string, inClass bool, fail func(string)) int {
+	switch c := rest[1]; {
+	case c == 'u':
+		if r, n, ok := unicodeEscape(rest); ok {
+			fmt.Fprintf(b, `\x{%X}`, r)
+			return n
+		}
+	case c == 'c' && len(rest) > 2 && isLetter(rest[2]):
+		fmt.Fprintf(b, `\x{%02X}`, rest[2]%32)
+		return 3
+	case c == 'b' && inClass:
+		b.WriteString(`\x08`)
+		return 2
+	case c >= '1' && c <= '9' && !inClass:
+		n := 2
+		for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+			n++
+		}
+		fail("backreference " + rest[:n])
+		b.WriteString(rest[:n])
+		return n
+	case c == 'k' && strings.HasPrefix(rest[2:], "<"):
+		if end := strings.IndexByte(rest, '>'); end > 0 {
+			fail("backreference " + rest[:end+1])
+			b.WriteString(rest[:end+1])
+			return end + 1
+		}
+	}
+	b.WriteString(rest[:2])
+	return 2
+}
+
+// unicodeEscape decodes the \uXXXX or \u{X...} escape rest starts with, the low half of
+// a surrogate pair included, and returns its length.
+func unicodeEscape(rest string) (r rune, n int, ok bool) {
+	if strings.HasPrefix(rest, `\u{`) {
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return 0, 0, false
+		}
+		v, err := strconv.ParseUint(rest[3:end], 16, 32)
+		return rune(v), end + 1, err == nil && v <= 0x10FFFF
+	}
+	if len(rest) < 6 {
+		return 0, 0, false
+	}
+	v, err := strconv.ParseUint(rest[2:6], 16, 32)
+	if err != nil {
+		return 0, 0, false
+	}
+	r = rune(v)
+	if r >= 0xD800 && r < 0xDC00 && len(rest) >= 12 && strings.HasPrefix(rest[6:], `\u`) {
+		if low, err := strconv.ParseUint(rest[8:12], 16, 32); err == nil && low >= 0xDC00 && low < 0xE000 {
+			return 0x10000 + (r-0xD800)<<10 + rune(low) - 0xDC00, 12, true
+		}
+	}
+	return r, 6, true
+}
+
+// classEscape reports whether s is \d, \w or \s, or their negations, an escape standing
+// for a class rather than a character.
+func classEscape(s string) bool {
+	return len(s) == 2 && s[0] == '\\' && strings.IndexByte("dDwWsS", s[1]) >= 0
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
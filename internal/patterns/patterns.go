@@ -0,0 +1,32 @@
+// Package patterns compiles regular expressions once per process: specs repeat the same
+// patterns across many schemas, and the enrichment and the policy checks compile them all.
+package patterns
+
+import (
+	"regexp"
+	"sync"
+)
+
+// Duration matches the ISO 8601 durations of RFC 3339, appendix A, e.g. P1Y2M or
+// PT1H30M, with fractional seconds.
+const Duration = `^P(?:(?:\d+D|\d+M(?:\d+D)?|\d+Y(?:\d+M(?:\d+D)?)?)(?:` + durationTime + `)?|` + durationTime + `|\d+W)$`
+
+const durationTime = `T(?:\d+H(?:\d+M(?:\d+(?:\.\d+)?S)?)?|\d+M(?:\d+(?:\.\d+)?S)?|\d+(?:\.\d+)?S)`
+
+type compiled struct {
+	re  *regexp.Regexp
+	err error
+}
+
+var cache sync.Map // string -> compiled
+
+// Compile returns regexp.Compile(expr), compiled the first time only, errors included.
+// It is safe for concurrent use; the returned Regexp must not be modified.
+func Compile(expr string) (*regexp.Regexp, error) {
+	if c, ok := cache.Load(expr); ok {
+		return c.(compiled).re, c.(compiled).err
+	}
+	re, err := regexp.Compile(expr)
+	cache.Store(expr, compiled{re, err})
+	return re, err
+}
@@ -0,0 +1,54 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	re, err := Compile(`^[a-z]+$`)
+	require.NoError(t, err)
+	again, err := Compile(`^[a-z]+$`)
+	require.NoError(t, err)
+	assert.Same(t, re, again)
+	assert.True(t, re.MatchString("abc"))
+
+	_, err = Compile(`(?=x)`)
+	assert.Error(t, err)
+	_, errAgain := Compile(`(?=x)`)
+	assert.Equal(t, err, errAgain)
+}
+
+func TestTranslate(t *testing.T) {
+	for _, tt := range []struct {
+		pattern, want string
+		matches       []string
+	}{
+		{pattern: `^[a-z]+$`, want: `^[a-z]+$`},
+		{pattern: `^\u00e9\u{1F600}\uD83D\uDE00$`, want: `^\x{E9}\x{1F600}\x{1F600}$`, matches: []string{"é😀😀"}},
+		{pattern: `^\cJ[\b]$`, want: `^\x{0A}[\x08]$`, matches: []string{"\n\b"}},
+		{pattern: `^[\d-x]+[a-\w]$`, want: `^[\d\-x]+[a\-\w]$`, matches: []string{"1-x-"}},
+		{pattern: `\d[a-z]\\d-[\\d-z]`, want: `\d[a-z]\\d-[\\d-z]`, matches: []string{`1a\d-z`}},
+		{pattern: `^a[^]b[]?$`, want: `^a[\x00-\x{10FFFF}]b[^\x00-\x{10FFFF}]?$`, matches: []string{"a\nb"}},
+		{pattern: `^(?<year>\d{4})\\1$`, want: `^(?<year>\d{4})\\1$`, matches: []string{`2024\1`}},
+	} {
+		got, err := Translate(tt.pattern)
+		require.NoError(t, err, tt.pattern)
+		assert.Equal(t, tt.want, got)
+		re, err := Compile(got)
+		require.NoError(t, err, got)
+		for _, s := range tt.matches {
+			assert.True(t, re.MatchString(s), "%s must match %q", got, s)
+		}
+	}
+
+	_, err := Translate(`^(?=.*\d)(?!x)(?<=a)(?<!b)(a)\1\1\k<name>$`)
+	var unsupported *UnsupportedError
+	require.ErrorAs(t, err, &unsupported)
+	assert.Equal(t, []string{
+		"lookahead (?=", "negative lookahead (?!", "lookbehind (?<=", "negative lookbehind (?<!",
+		`backreference \1`, `backreference \k<name>`,
+	}, unsupported.Constructs)
+}
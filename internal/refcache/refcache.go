@@ -0,0 +1,102 @@
+// Package refcache speeds up loading specs sharing referenced files, e.g. a common
+// error.yaml, in a batch: the YAML files read by the kin-openapi loader are converted to
+// JSON, which the loader parses much faster, once per content.
+package refcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oasdiff/yaml"
+)
+
+// Cache holds the JSON conversions of the files read, by location and content hash, so
+// that a file changed between two loads is converted again.
+type Cache struct {
+	// dir keeps the conversions on disk across runs, when not empty.
+	dir string
+
+	mu   sync.Mutex
+	docs map[string][]byte
+}
+
+// New returns a cache kept in memory, and in dir when not empty.
+func New(dir string) *Cache {
+	return &Cache{dir: dir, docs: make(map[string][]byte)}
+}
+
+// Reader returns a ReadFromURIFunc reading with next, returning the cached JSON of the
+// YAML files read.
+func (c *Cache) Reader(next openapi3.ReadFromURIFunc) openapi3.ReadFromURIFunc {
+	return func(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+		data, err := next(loader, location)
+		if err != nil {
+			return nil, err
+		}
+		return c.json(location.String(), data), nil
+	}
+}
+
+// json returns the JSON of the YAML data read at location, or data when it is not YAML,
+// leaving the error to the loader.
+func (c *Cache) json(location string, data []byte) []byte {
+	sum := sha256.Sum256(append([]byte(location+"\x00"), data...))
+	key := hex.EncodeToString(sum[:])
+
+	c.mu.Lock()
+	doc, ok := c.docs[key]
+	c.mu.Unlock()
+	if ok {
+		return doc
+	}
+
+	path := filepath.Join(c.dir, key+".json")
+	if c.dir != "" {
+		if doc, err := os.ReadFile(path); err == nil {
+			c.store(key, doc)
+			return doc
+		}
+	}
+	doc, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return data
+	}
+	c.store(key, doc)
+	if c.dir != "" {
+		// A failed write only costs a conversion in the next run.
+		_ = writeFile(path, doc)
+	}
+	return doc
+}
+
+func (c *Cache) store(key string, doc []byte) {
+	c.mu.Lock()
+	c.docs[key] = doc
+	c.mu.Unlock()
+}
+
+// writeFile writes data to path through a temporary file, so that concurrent runs never
+// read a partial file.
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,75 @@
+package refcache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	specs, cacheDir := t.TempDir(), t.TempDir()
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(specs, name), []byte(content), 0644))
+	}
+	write("common.yaml", `
+components:
+  schemas:
+    Error:
+      type: object
+      properties:
+        code: {type: string, maxLength: 10}
+`)
+	write("api.yaml", `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /users:
+    get:
+      responses:
+        200:
+          description: OK
+        default:
+          description: Error
+          content:
+            application/json:
+              schema: {$ref: 'common.yaml#/components/schemas/Error'}
+`)
+
+	load := func(c *Cache) *openapi3.T {
+		loader := openapi3.NewLoader()
+		loader.IsExternalRefsAllowed = true
+		loader.ReadFromURIFunc = c.Reader(openapi3.ReadFromFile)
+		doc, err := loader.LoadFromFile(filepath.Join(specs, "api.yaml"))
+		require.NoError(t, err)
+		return doc
+	}
+	maxLength := func(doc *openapi3.T) uint64 {
+		s := doc.Paths.Value("/users").Get.Responses.Default().Value.Content["application/json"].Schema.Value
+		return *s.Properties["code"].Value.MaxLength
+	}
+
+	assert.Equal(t, uint64(10), maxLength(load(New(cacheDir))))
+	cached, err := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	require.NoError(t, err)
+	assert.Len(t, cached, 2)
+
+	// A new run reads the conversions of the previous one.
+	c := New(cacheDir)
+	assert.Equal(t, uint64(10), maxLength(load(c)))
+	assert.Len(t, c.docs, 2)
+
+	// A changed file is converted again.
+	write("common.yaml", `
+components:
+  schemas:
+    Error:
+      type: object
+      properties:
+        code: {type: string, maxLength: 20}
+`)
+	assert.Equal(t, uint64(20), maxLength(load(c)))
+}
@@ -0,0 +1,202 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+)
+
+// flatten returns s with the keywords of its allOf members, theirs flattened first,
+// merged in. The nullability and extensions are those of s, which the field is generated
+// from. Members declaring the same items, values or property differently have them
+// merged in turn; of the bounds they set differently, the tighter applies, which the
+// values satisfying both satisfy. Another keyword, e.g. a type, format or pattern, set to
+// different values by the schema and a member is a conflict, a *KeywordError of the
+// member. The schemas being flattened are on path, to stop at recursive compositions.
+func flatten(s *schema.Schema, path map[*schema.Schema]bool) (*schema.Schema, error) {
+	if len(s.AllOf) == 0 || path[s] {
+		return s, nil
+	}
+	path[s] = true
+	defer delete(path, s)
+
+	f := *s
+	f.AllOf = nil
+	f.Properties = maps.Clone(s.Properties)
+	f.Required = slices.Clone(s.Required)
+	f.PrefixItems = slices.Clone(s.PrefixItems)
+	f.DependentRequired = maps.Clone(s.DependentRequired)
+	m := &merger{}
+	for i, member := range s.AllOf {
+		member, err := flatten(member, path)
+		m.errs = append(m.errs, within("allOf", within(strconv.Itoa(i), err)))
+		m.member = strconv.Itoa(i)
+		m.merge(&f, member)
+	}
+	return &f, errors.Join(m.errs...)
+}
+
+// merger merges a member of an allOf into the flattened schema, collecting conflicts.
+type merger struct {
+	member string
+	errs   []error
+}
+
+func (m *merger) merge(f, member *schema.Schema) {
+	if len(member.Types) > 0 {
+		if len(f.Types) == 0 {
+			f.Types = member.Types
+		} else if !slices.Equal(f.Types, member.Types) {
+			m.conflict("type", f.Types, member.Types)
+		}
+	}
+	mergeValue(m, "format", &f.Format, member.Format)
+	mergeValue(m, "pattern", &f.Pattern, member.Pattern)
+	mergeValue(m, "contentEncoding", &f.ContentEncoding, member.ContentEncoding)
+	mergeValue(m, "contentMediaType", &f.ContentMediaType, member.ContentMediaType)
+	if len(member.Enum) > 0 {
+		if len(f.Enum) == 0 {
+			f.Enum = member.Enum
+		} else if !reflect.DeepEqual(f.Enum, member.Enum) {
+			m.conflict("enum", f.Enum, member.Enum)
+		}
+	}
+	if member.Const != nil {
+		if f.Const == nil {
+			f.Const = member.Const
+		} else if !reflect.DeepEqual(f.Const, member.Const) {
+			m.conflict("const", f.Const, member.Const)
+		}
+	}
+
+	mergeBound(&f.Minimum, &f.ExclusiveMinimum, member.Minimum, member.ExclusiveMinimum, 1)
+	mergeBound(&f.Maximum, &f.ExclusiveMaximum, member.Maximum, member.ExclusiveMaximum, -1)
+	mergeMultipleOf(m, &f.MultipleOf, member.MultipleOf)
+
+	f.MinLength = max(f.MinLength, member.MinLength)
+	mergeMax(&f.MaxLength, member.MaxLength)
+
+	f.MinItems = max(f.MinItems, member.MinItems)
+	mergeMax(&f.MaxItems, member.MaxItems)
+	f.UniqueItems = f.UniqueItems || member.UniqueItems
+	f.Items = both(f.Items, member.Items)
+	for i, item := range member.PrefixItems {
+		if i < len(f.PrefixItems) {
+			f.PrefixItems[i] = both(f.PrefixItems[i], item)
+		} else {
+			f.PrefixItems = append(f.PrefixItems, item)
+		}
+	}
+	f.ClosedItems = f.ClosedItems || member.ClosedItems
+
+	for _, name := range member.Required {
+		if !slices.Contains(f.Required, name) {
+			f.Required = append(f.Required, name)
+		}
+	}
+	for name, dependents := range member.DependentRequired {
+		if f.DependentRequired == nil {
+			f.DependentRequired = make(map[string][]string)
+		}
+		for _, dependent := range dependents {
+			if !slices.Contains(f.DependentRequired[name], dependent) {
+				f.DependentRequired[name] = append(slices.Clip(f.DependentRequired[name]), dependent)
+			}
+		}
+	}
+	for name, prop := range member.Properties {
+		if f.Properties == nil {
+			f.Properties = make(map[string]*schema.Schema)
+		}
+		f.Properties[name] = both(f.Properties[name], prop)
+	}
+
+	f.MinProperties = max(f.MinProperties, member.MinProperties)
+	mergeMax(&f.MaxProperties, member.MaxProperties)
+	f.AdditionalProperties = f.AdditionalProperties || member.AdditionalProperties
+	f.Values = both(f.Values, member.Values)
+	f.PropertyNames = both(f.PropertyNames, member.PropertyNames)
+}
+
+func (m *merger) conflict(keyword string, flattened, member any) {
+	m.errs = append(m.errs, &KeywordError{
+		Keyword: keyword,
+		Schema:  []string{"allOf", m.member},
+		Err:     fmt.Errorf("conflict: allOf member sets '%s' to %v, where another member or the schema sets it to %v", keyword, member, flattened),
+	})
+}
+
+// mergeValue sets the keyword of the flattened schema to that of the member, unless one
+// is unset.
+func mergeValue[T comparable](m *merger, keyword string, f *T, member T) {
+	var zero T
+	switch {
+	case member == zero:
+	case *f == zero:
+		*f = member
+	case *f != member:
+		m.conflict(keyword, *f, member)
+	}
+}
+
+// mergePointer is mergeValue for the keywords without a zero value.
+func mergePointer[T comparable](m *merger, keyword string, f **T, member *T) {
+	switch {
+	case member == nil:
+	case *f == nil:
+		*f = member
+	case **f != *member:
+		m.conflict(keyword, **f, *member)
+	}
+}
+
+// mergeMultipleOf sets the multipleOf of the flattened schema to the member's when it is
+// a multiple of its own, and keeps its own when it is a multiple of the member's: their
+// multiples are those of both. Other divisors are a conflict.
+func mergeMultipleOf(m *merger, f **float64, member *float64) {
+	switch {
+	case member == nil:
+	case *f == nil || math.Mod(*member, **f) == 0:
+		*f = member
+	case math.Mod(**f, *member) != 0:
+		m.conflict("multipleOf", **f, *member)
+	}
+}
+
+// mergeMax sets an upper bound of the flattened schema to the lesser of its own and the
+// member's.
+func mergeMax(f **uint64, member *uint64) {
+	if member != nil && (*f == nil || *member < **f) {
+		*f = member
+	}
+}
+
+// mergeBound sets a bound of numbers of the flattened schema and its exclusiveness to the
+// tighter of its own and the member's: the greater for a minimum, of sign 1, and the
+// lesser for a maximum, of sign -1. Of equal bounds, an exclusive one is the tighter.
+func mergeBound(f **float64, exclusive *bool, member *float64, memberExclusive bool, sign float64) {
+	switch {
+	case member == nil:
+	case *f == nil || sign*(*member-**f) > 0:
+		*f, *exclusive = member, memberExclusive
+	case *member == **f:
+		*exclusive = *exclusive || memberExclusive
+	}
+}
+
+// both returns the schema of the values matching a and b, either being nil when unset.
+func both(a, b *schema.Schema) *schema.Schema {
+	switch {
+	case a == nil || a == b:
+		return b
+	case b == nil:
+		return a
+	}
+	return &schema.Schema{Nullable: a.Nullable && b.Nullable, AllOf: []*schema.Schema{a, b}}
+}
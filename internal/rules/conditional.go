@@ -0,0 +1,129 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+)
+
+// IfThenElse returns the rules of the properties s declares, by name, that the then and
+// else of its if require or forbid, when the if requires a single property of s and
+// checks it against a const or enum, e.g. required_if=Kind business. Those then requires
+// are required_if the property has one of the values, and those it forbids with not:
+// {required: [...]} excluded_if; those else requires are required_unless it has the
+// value, and those it forbids excluded_unless, a single value only. An if that does not
+// require the property is met when it is absent, which the rules do not express.
+//
+// The translation is best-effort: its error joins the *KeywordError of every condition or
+// branch no rule expresses, to be reported as a warning rather than a failure, the rules
+// of the others being returned regardless.
+func IfThenElse(s *schema.Schema) (map[string][]string, error) {
+	if s.If == nil || s.Then == nil && s.Else == nil {
+		return nil, nil
+	}
+	name, values, ok := condition(s)
+	if !ok {
+		return nil, &KeywordError{
+			Keyword: "if",
+			Err:     errors.New("validation keyword 'if' is only translated when it requires a single property of the schema and checks it against a const or enum"),
+		}
+	}
+	field := naming.TypeName(name)
+	conditions := make(map[string][]string)
+	add := func(props []string, rule string) {
+		for _, prop := range props {
+			if prop == name || s.Properties[prop] == nil || slices.Contains(s.Required, prop) {
+				continue
+			}
+			if !slices.Contains(conditions[prop], rule) {
+				conditions[prop] = append(conditions[prop], rule)
+			}
+		}
+	}
+	var errs []error
+	if s.Then != nil {
+		required, forbidden, err := branch("then", s.Then)
+		errs = append(errs, err)
+		for _, value := range values {
+			add(required, fmt.Sprintf("required_if=%s %s", field, value))
+			add(forbidden, fmt.Sprintf("excluded_if=%s %s", field, value))
+		}
+	}
+	if s.Else != nil {
+		required, forbidden, err := branch("else", s.Else)
+		errs = append(errs, err)
+		if len(values) > 1 && len(required)+len(forbidden) > 0 {
+			// A rule per value would require the field unless the property has all of them.
+			errs = append(errs, &KeywordError{
+				Keyword: "else",
+				Err:     errors.New("validation keyword 'else' is only translated when its if allows a single value"),
+			})
+		} else {
+			add(required, fmt.Sprintf("required_unless=%s %s", field, values[0]))
+			add(forbidden, fmt.Sprintf("excluded_unless=%s %s", field, values[0]))
+		}
+	}
+	return conditions, errors.Join(errs...)
+}
+
+// condition returns the property the if of s checks and the values, as rule parameters,
+// that meet it, if it requires a single property of s and checks it against a const or
+// enum.
+func condition(s *schema.Schema) (name string, values []string, ok bool) {
+	if len(s.If.Properties) != 1 || !only(s.If, func(c *schema.Schema) { c.Properties, c.Required = nil, nil }) {
+		return "", nil, false
+	}
+	name = slices.Collect(maps.Keys(s.If.Properties))[0]
+	if s.Properties[name] == nil || !slices.Contains(s.If.Required, name) || slices.ContainsFunc(s.If.Required, func(r string) bool { return r != name }) {
+		return "", nil, false
+	}
+	allowed := s.If.Properties[name].Enum
+	if c := s.If.Properties[name].Const; c != nil {
+		allowed = []any{c}
+	}
+	for _, v := range allowed {
+		value, ok := param(v)
+		if !ok {
+			return "", nil, false
+		}
+		if value, ok = word(value); !ok {
+			return "", nil, false
+		}
+		values = append(values, value)
+	}
+	return name, values, len(values) > 0
+}
+
+// branch returns the properties the then or else s requires, and those it forbids with
+// not: {required: [...]}. Its error is the *KeywordError of the branch when it has other
+// keywords, which no rule checks.
+func branch(keyword string, s *schema.Schema) (required, forbidden []string, err error) {
+	if s.Not != nil && only(s.Not, func(c *schema.Schema) { c.Required = nil }) {
+		forbidden = s.Not.Required
+	}
+	if !only(s, func(c *schema.Schema) {
+		c.Required = nil
+		if forbidden != nil {
+			c.Not = nil
+		}
+	}) {
+		err = &KeywordError{
+			Keyword: keyword,
+			Err:     fmt.Errorf("validation keyword '%s' is only translated for the properties it requires or forbids with not: {required: [...]}, its other keywords are not checked", keyword),
+		}
+	}
+	return s.Required, forbidden, err
+}
+
+// only reports whether s has no keywords but those unset clears from a copy.
+func only(s *schema.Schema, unset func(*schema.Schema)) bool {
+	c := *s
+	c.Extensions = nil
+	unset(&c)
+	return reflect.ValueOf(c).IsZero()
+}
@@ -0,0 +1,51 @@
+package rules
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+)
+
+// RequiredWith returns the required_with rules of the properties s declares without
+// requiring them, by name, that its dependentRequired requires when another property is
+// present, e.g. required_with=CardNumber.
+func RequiredWith(s *schema.Schema) map[string][]string {
+	conditions := make(map[string][]string)
+	for _, name := range slices.Sorted(maps.Keys(s.DependentRequired)) {
+		if s.Properties[name] == nil {
+			continue
+		}
+		rule := "required_with=" + naming.TypeName(name)
+		for _, dependent := range s.DependentRequired[name] {
+			if dependent == name || s.Properties[dependent] == nil || slices.Contains(s.Required, dependent) {
+				continue
+			}
+			if !slices.Contains(conditions[dependent], rule) {
+				conditions[dependent] = append(conditions[dependent], rule)
+			}
+		}
+	}
+	return conditions
+}
+
+// Conditions returns the rules making the properties of s required, or forbidden,
+// depending on the others, by name: those of RequiredIf, then those of RequiredWith, then
+// those of IfThenElse. The keywords of the allOf members of s count, the struct generated
+// from s holding their fields. Its error is that of IfThenElse, a warning.
+func Conditions(s *schema.Schema) (map[string][]string, error) {
+	s, _ = flatten(s, make(map[*schema.Schema]bool))
+	conditions := RequiredIf(s)
+	if conditions == nil {
+		conditions = make(map[string][]string)
+	}
+	for name, rules := range RequiredWith(s) {
+		conditions[name] = append(conditions[name], rules...)
+	}
+	ifThenElse, err := IfThenElse(s)
+	for name, rules := range ifThenElse {
+		conditions[name] = append(conditions[name], rules...)
+	}
+	return conditions, err
+}
@@ -0,0 +1,66 @@
+package rules
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+)
+
+// RequiredIf returns the required_if rules of the properties s declares without requiring
+// them, by name, that variants of its discriminated oneOf require: the struct generated
+// from s holds the fields of every variant, and a field is required when the
+// discriminator selects a variant requiring it, e.g. required_if=PetType dog. The values
+// of an inline variant are those its discriminator property allows, from its const or
+// single-valued enum.
+func RequiredIf(s *schema.Schema) map[string][]string {
+	d := s.Discriminator
+	if d == nil || s.Properties[d.PropertyName] == nil {
+		return nil
+	}
+	field := naming.TypeName(d.PropertyName)
+	conditions := make(map[string][]string)
+	for i, variant := range s.OneOf {
+		var values []string
+		if i < len(d.Values) {
+			values = d.Values[i]
+		}
+		if len(values) == 0 {
+			values = inlineValues(variant.Properties[d.PropertyName])
+		}
+		for _, name := range variant.Required {
+			if name == d.PropertyName || s.Properties[name] == nil || slices.Contains(s.Required, name) {
+				continue
+			}
+			for _, value := range values {
+				value, ok := word(Escape(value))
+				if !ok {
+					continue
+				}
+				rule := fmt.Sprintf("required_if=%s %s", field, value)
+				if !slices.Contains(conditions[name], rule) {
+					conditions[name] = append(conditions[name], rule)
+				}
+			}
+		}
+	}
+	return conditions
+}
+
+// inlineValues returns the value the discriminator property s of an inline variant
+// allows, if it allows a single string.
+func inlineValues(s *schema.Schema) []string {
+	if s == nil {
+		return nil
+	}
+	if value, ok := s.Const.(string); ok {
+		return []string{value}
+	}
+	if len(s.Enum) == 1 {
+		if value, ok := s.Enum[0].(string); ok {
+			return []string{value}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package rules
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// TagKey is the extension holding the struct tags of a field, the validate tag included.
+const TagKey = "x-oapi-codegen-extra-tags"
+
+// KeywordError is a keyword of a schema no rule can be generated from.
+type KeywordError struct {
+	// Keyword is the offending keyword, e.g. pattern, or TagKey for a conflicting tag.
+	Keyword string
+	// Schema is the path of the subschema holding the keyword, e.g. ["items"] for the
+	// items of an array; empty for the schema itself.
+	Schema []string
+	Err    error
+}
+
+func (e *KeywordError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *KeywordError) Unwrap() error {
+	return e.Err
+}
+
+// PropertyError locates an error of a schema of a spec, typically a property.
+type PropertyError struct {
+	// Pointer is the JSON Pointer of the offending keyword, e.g.
+	// /components/schemas/User/properties/name/pattern, or of the schema when the
+	// keyword is unknown.
+	Pointer string
+	// Keyword is the offending keyword, if known.
+	Keyword string
+	Err     error
+}
+
+func (e *PropertyError) Error() string {
+	return e.Pointer + ": " + e.Err.Error()
+}
+
+func (e *PropertyError) Unwrap() error {
+	return e.Err
+}
+
+// Locate returns the errors of the schema at pointer joined in err as *PropertyError,
+// one per offending keyword.
+func Locate(pointer string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, err := range joined.Unwrap() {
+			errs = append(errs, Locate(pointer, err))
+		}
+		return errors.Join(errs...)
+	}
+	var located *PropertyError
+	if errors.As(err, &located) {
+		return err
+	}
+	located = &PropertyError{Pointer: pointer, Err: err}
+	var kw *KeywordError
+	if errors.As(err, &kw) {
+		located.Pointer, located.Keyword = Pointer(pointer, append(slices.Clone(kw.Schema), kw.Keyword)...), kw.Keyword
+	}
+	return located
+}
+
+// within moves the *KeywordError joined in err to the subschema at token.
+func within(token string, err error) error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			within(token, err)
+		}
+	} else if kw, ok := err.(*KeywordError); ok {
+		kw.Schema = slices.Insert(kw.Schema, 0, token)
+	}
+	return err
+}
+
+// Pointer appends the escaped tokens to the JSON Pointer parent.
+func Pointer(parent string, tokens ...string) string {
+	var sb strings.Builder
+	sb.WriteString(parent)
+	for _, t := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(t, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
@@ -0,0 +1,799 @@
+// Package rules generates the validate rules of struct fields from the constraints of
+// their schemas, whatever the backend reading the spec.
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+	"github.com/hadrienk/oapi-codegen-validator/internal/patterns"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+)
+
+// Formats are the validate rules of the formats, by default. rfc3339, idn_hostname and
+// iso8601duration are custom validations, registered by the middleware and generated by
+// the registrations command.
+var Formats = map[string]string{
+	"email":        "email",
+	"uuid":         "uuid",
+	"ipv4":         "ipv4",
+	"ipv6":         "ipv6",
+	"uri":          "url",
+	"url":          "url",
+	"date":         "datetime=2006-01-02",
+	"date-time":    "rfc3339",
+	"time":         "datetime=15:04:05Z07:00",
+	"hostname":     "hostname_rfc1123",
+	"idn-hostname": "idn_hostname",
+	"byte":         "base64",
+	"duration":     "iso8601duration",
+}
+
+// contentEncodings are the validate rules of the content encodings of strings.
+var contentEncodings = map[string]string{
+	"base16":    "hexadecimal",
+	"base32":    "base32",
+	"base64":    "base64",
+	"base64url": "base64url",
+}
+
+// Options tune the rules generated. The zero Options generate the default rules.
+type Options struct {
+	// OptionalNullable leads the tags of required nullable fields with omitempty rather
+	// than required, accepting an explicit null.
+	OptionalNullable bool
+	// SkipUnsupported ignores the keywords no rule can check, such as patterns that are
+	// not RE2 or minProperties on a struct, rather than failing.
+	SkipUnsupported bool
+	// Formats are the rules of the formats, Formats when nil. Other formats are ignored.
+	Formats map[string]string
+	// AnchorPatterns wraps the patterns that are not anchored in ^(?:...)$, so that they
+	// match the whole value rather than any part of it, as JSON Schema has it. Schemas
+	// with an AnchorKey extension set to false keep their pattern as is.
+	AnchorPatterns bool
+	// OmitNil leads the tags of optional nullable fields, generated as pointers, with
+	// omitnil rather than omitempty, so that only null or a missing value skips the
+	// rules, and an explicit empty value is checked.
+	OmitNil bool
+}
+
+// AnchorKey is the extension opting a schema out of Options.AnchorPatterns.
+const AnchorKey = "x-pattern-anchor"
+
+// UniqueByKey is the extension of an array of unique objects naming the property telling
+// them apart, e.g. x-unique-by: id for unique=ID.
+const UniqueByKey = "x-unique-by"
+
+// skipPointerKey is the extension of oapi-codegen generating a value rather than a
+// pointer for an optional field.
+const skipPointerKey = "x-go-type-skip-optional-pointer"
+
+// Generate returns the validate rules generated from the constraints of s, with the
+// default options.
+func Generate(s *schema.Schema) ([]string, error) {
+	return Options{}.Generate(s)
+}
+
+// Field returns the validate tag of the struct field generated from s, with the default
+// options.
+func Field(s *schema.Schema, existing string, required bool) (string, error) {
+	return Options{}.Field(s, existing, required)
+}
+
+// Generate returns the validate rules generated from the constraints of s, its allOf
+// members included, without the leading required or omitempty. Its error joins a
+// *KeywordError per keyword no rule can be generated from; the rules of the other
+// keywords are returned regardless.
+func (o Options) Generate(s *schema.Schema) ([]string, error) {
+	return o.generate(s, make(map[*schema.Schema]bool))
+}
+
+// generate is Generate, the schemas of the items and values being checked for fields to
+// validate on path to stop at recursive ones.
+func (o Options) generate(s *schema.Schema, path map[*schema.Schema]bool) ([]string, error) {
+	var tags []string
+	var errs []error
+
+	// The members of an allOf constrain the values as the schema itself does.
+	s, err := flatten(s, make(map[*schema.Schema]bool))
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if s.Pattern != "" {
+		// JSON Schema patterns are ECMA-262 regexes: the constructs RE2 cannot express
+		// fail, listed.
+		pattern, err := patterns.Translate(s.Pattern)
+		if anchor, ok := s.Extensions[AnchorKey].(bool); err == nil && o.AnchorPatterns && (anchor || !ok) {
+			pattern = anchored(pattern)
+		}
+		if err == nil {
+			_, err = patterns.Compile(pattern)
+		}
+		if err != nil {
+			if !o.SkipUnsupported {
+				errs = append(errs, &KeywordError{Keyword: "pattern", Err: fmt.Errorf("validation keyword 'pattern' '%s' is not a valid Go RE2 regex: %w", s.Pattern, err)})
+			}
+		} else {
+			tags = append(tags, "regex="+Escape(literalHex.Replace(pattern)))
+		}
+	}
+
+	// min and max bound the length of strings, and the number of items of arrays and of
+	// properties of maps, the validator telling them apart by the kind of the field; gte
+	// and lte bound numbers. The bounds of a type s does not have do not apply.
+	applies, err := o.bounded(s)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if s.MinLength > 0 && applies[schema.TypeString] {
+		tags = append(tags, fmt.Sprintf("min=%d", s.MinLength))
+	}
+
+	if s.MaxLength != nil && applies[schema.TypeString] {
+		tags = append(tags, fmt.Sprintf("max=%d", *s.MaxLength))
+	}
+
+	if s.Minimum != nil && applies[schema.TypeNumber] {
+		op := "gte"
+		if s.ExclusiveMinimum {
+			op = "gt"
+		}
+		tags = append(tags, op+"="+bound(s, *s.Minimum, op == "gte"))
+	}
+
+	if s.Maximum != nil && applies[schema.TypeNumber] {
+		op := "lte"
+		if s.ExclusiveMaximum {
+			op = "lt"
+		}
+		tags = append(tags, op+"="+bound(s, *s.Maximum, op == "lt"))
+	}
+
+	// multipleof is a custom validation, registered by the middleware and generated by
+	// the registrations command.
+	if s.MultipleOf != nil {
+		tags = append(tags, "multipleof="+strconv.FormatFloat(*s.MultipleOf, 'f', -1, 64))
+	}
+
+	if s.MinItems > 0 && applies[schema.TypeArray] {
+		tags = append(tags, fmt.Sprintf("min=%d", s.MinItems))
+	}
+	if maxItems := maxItems(s); maxItems != nil && applies[schema.TypeArray] {
+		tags = append(tags, fmt.Sprintf("max=%d", *maxItems))
+	}
+	if s.UniqueItems {
+		rule, err := o.unique(s)
+		if err != nil {
+			errs = append(errs, err)
+		} else if rule != "" {
+			tags = append(tags, rule)
+		}
+	}
+
+	// The bounds of the number of properties are those of the length of the map an
+	// object with additional properties only is generated as; a struct has none.
+	if !applies[schema.TypeObject] {
+		// Not bounded, or bounded ambiguously.
+	} else if s.IsMap() {
+		if s.MinProperties > 0 {
+			tags = append(tags, fmt.Sprintf("min=%d", s.MinProperties))
+		}
+		if s.MaxProperties != nil {
+			tags = append(tags, fmt.Sprintf("max=%d", *s.MaxProperties))
+		}
+	} else if (s.MinProperties > 0 || s.MaxProperties != nil) && !o.SkipUnsupported {
+		keyword := "minProperties"
+		if s.MinProperties == 0 {
+			keyword = "maxProperties"
+		}
+		errs = append(errs, &KeywordError{Keyword: keyword, Err: fmt.Errorf("validation keyword '%s' is only supported on objects generated as maps, with additionalProperties and no properties", keyword)})
+	}
+
+	formats := o.Formats
+	if formats == nil {
+		formats = Formats
+	}
+	if rule := formats[s.Format]; rule != "" {
+		tags = append(tags, rule)
+	}
+	if rule := contentEncodings[strings.ToLower(s.ContentEncoding)]; rule != "" && !slices.Contains(tags, rule) {
+		tags = append(tags, rule)
+	}
+	// The media type of encoded content is that of the decoded bytes, which no rule
+	// checks.
+	if isJSON(s.ContentMediaType) && s.ContentEncoding == "" {
+		tags = append(tags, "json")
+	}
+
+	if rule := oneOf(s); rule != "" {
+		tags = append(tags, rule)
+	}
+
+	if value, ok := param(s.Const); ok {
+		tags = append(tags, "eq="+value)
+	}
+
+	// A dive applies the same rules to every item, which those of the prefix items,
+	// depending on the position, cannot follow.
+	if len(s.PrefixItems) > 0 {
+		constrained := false
+		for i, item := range s.PrefixItems {
+			items, err := o.generate(item, path)
+			errs = append(errs, within("prefixItems", within(strconv.Itoa(i), err)))
+			constrained = constrained || len(items) > 0
+		}
+		if s.Items != nil {
+			items, err := o.generate(s.Items, path)
+			errs = append(errs, within("items", err))
+			constrained = constrained || len(items) > 0
+		}
+		if constrained && !o.SkipUnsupported {
+			errs = append(errs, &KeywordError{Keyword: "prefixItems", Err: errors.New("validation keyword 'prefixItems' is only supported without rules on its items and the items following them, a dive applying the same rules to every item")})
+		}
+	} else if s.Items != nil {
+		// The rules of the items follow a dive, the nullable ones being pointers. Items
+		// generated as structs with fields to validate need a dive alone, the validator
+		// not walking into the items otherwise.
+		items, err := o.generate(s.Items, path)
+		if err != nil {
+			errs = append(errs, within("items", err))
+		}
+		if len(items) > 0 || o.validatesFields(s.Items, path) {
+			tags = append(tags, "dive")
+			if s.Items.Nullable {
+				tags = append(tags, "omitempty")
+			}
+			tags = append(tags, items...)
+		}
+	}
+
+	// The rules of the values of a map follow a dive, those of its keys being enclosed
+	// in keys and endkeys. Values generated as structs with fields to validate need a
+	// dive alone, as items do.
+	if s.IsMap() {
+		var keys, values []string
+		if s.PropertyNames != nil {
+			var err error
+			if keys, err = o.generate(s.PropertyNames, path); err != nil {
+				errs = append(errs, within("propertyNames", err))
+			}
+		}
+		if s.Values != nil {
+			var err error
+			if values, err = o.generate(s.Values, path); err != nil {
+				errs = append(errs, within("additionalProperties", err))
+			}
+		}
+		if len(keys) > 0 || len(values) > 0 || s.Values != nil && o.validatesFields(s.Values, path) {
+			tags = append(tags, "dive")
+			if len(keys) > 0 {
+				tags = append(tags, "keys")
+				tags = append(tags, keys...)
+				tags = append(tags, "endkeys")
+			}
+			if len(values) > 0 && s.Values.Nullable {
+				tags = append(tags, "omitempty")
+			}
+			tags = append(tags, values...)
+		}
+	}
+
+	return tags, errors.Join(errs...)
+}
+
+// validatesFields reports whether s is generated as a struct with fields the validator
+// checks: required, conditional or with rules, its nested structs included. The schemas
+// on path are being checked already, and count as without.
+func (o Options) validatesFields(s *schema.Schema, path map[*schema.Schema]bool) bool {
+	if path[s] {
+		return false
+	}
+	path[s] = true
+	defer delete(path, s)
+
+	f, _ := flatten(s, make(map[*schema.Schema]bool))
+	if len(f.Properties) == 0 {
+		return false
+	}
+	if conditions, _ := Conditions(f); len(f.Required) > 0 || len(conditions) > 0 {
+		return true
+	}
+	for _, prop := range f.Properties {
+		if rules, _ := o.generate(prop, path); len(rules) > 0 || o.validatesFields(prop, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Field returns the validate tag of the struct field generated from s: the rules of its
+// existing tag merged with the generated ones, led by required or omitempty, but for a
+// required number allowing 0, which required would reject. It returns an empty tag when
+// the field is neither required nor constrained. Its error joins the *KeywordError of
+// every offending keyword, the existing tag included.
+func (o Options) Field(s *schema.Schema, existing string, required bool) (string, error) {
+	return o.FieldRequiredIf(s, existing, required, nil)
+}
+
+// FieldRequiredIf is Field for a field also required, or forbidden, under the conditions
+// of its required_if, required_with, required_unless, excluded_if and excluded_unless
+// rules, see Conditions. They lead the tag, since omitempty skips the rules following it,
+// unless the field is required regardless.
+func (o Options) FieldRequiredIf(s *schema.Schema, existing string, required bool, requiredIf []string) (string, error) {
+	oapiRules, genErr := o.Generate(s)
+
+	var validatorRules []string
+	conditions := slices.Clone(requiredIf)
+	dived := false
+	for part := range strings.SplitSeq(existing, ",") {
+		switch part = strings.TrimSpace(part); {
+		case part == "":
+		case slices.Contains(conditionRules, getTagKey(part)):
+			if !slices.Contains(conditions, part) {
+				conditions = append(conditions, part)
+			}
+		default:
+			dived = dived || part == "dive"
+			if op, ok := numericBounds[getTagKey(part)]; ok && !dived && isNumeric(s) {
+				// Bounds of numbers are generated as gte and lte, min and max checking
+				// the same on a number.
+				part = op + strings.TrimPrefix(part, getTagKey(part))
+			}
+			validatorRules = append(validatorRules, part)
+		}
+	}
+
+	rules, err := Merge(validatorRules, oapiRules)
+	if err := errors.Join(genErr, err); err != nil {
+		return "", err
+	}
+
+	required = required && !(o.OptionalNullable && s.Nullable)
+	if required && ZeroAllowed(s) {
+		// required rejects the zero value of a number that is not a pointer, 0, which s
+		// allows, e.g. with minimum: 0: the other rules check it instead, e.g. gte=0.
+		if len(rules) > 0 && rules[0] == "required" {
+			// Enriched before.
+			rules = rules[1:]
+		}
+		return strings.Join(rules, ","), nil
+	}
+	lead := "omitempty"
+	if skip, _ := s.Extensions[skipPointerKey].(bool); o.OmitNil && s.Nullable && !skip {
+		lead = "omitnil"
+	}
+	if len(rules) > 0 && !required && lead == "omitnil" && rules[0] == "omitempty" {
+		// Already enriched without OmitNil.
+		rules[0] = lead
+	}
+	if required {
+		lead = "required"
+	}
+
+	// NB. Order is important for these tags, so we prepend them after generateTags & injectTags.
+	if len(rules) > 0 && rules[0] == lead {
+		// Already enriched, e.g. a schema shared by several components.
+		oapiRules = rules
+	} else if required {
+		oapiRules = slices.Insert(rules, 0, "required")
+	} else if len(rules) > 0 {
+		oapiRules = slices.Insert(rules, 0, lead)
+	} else {
+		oapiRules = nil
+	}
+	if !required {
+		oapiRules = slices.Insert(oapiRules, 0, conditions...)
+	}
+	if len(oapiRules) == 0 {
+		// No rules and not required: nothing useful to emit.
+		return "", nil
+	}
+	return strings.Join(oapiRules, ","), nil
+}
+
+// conditionRules are the rules of the conditions of FieldRequiredIf.
+var conditionRules = []string{"required_if", "required_with", "required_unless", "excluded_if", "excluded_unless"}
+
+// Struct tags holding the rules of one direction, see Options.Directional.
+const (
+	RequestTag  = "validateRequest"
+	ResponseTag = "validateResponse"
+)
+
+// Directional returns the tags of the field generated from s for requests and responses,
+// like FieldRequiredIf, given their existing tags: a readOnly field, sent in responses
+// only, is optional in requests, and a writeOnly field, sent in requests only, is not
+// validated in responses, its tag being "-".
+func (o Options) Directional(s *schema.Schema, existingRequest, existingResponse string, required bool, requiredIf []string) (request, response string, err error) {
+	if s.ReadOnly {
+		request, err = o.FieldRequiredIf(s, existingRequest, false, nil)
+	} else {
+		request, err = o.FieldRequiredIf(s, existingRequest, required, requiredIf)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if s.WriteOnly {
+		return request, "-", nil
+	}
+	response, err = o.FieldRequiredIf(s, existingResponse, required, requiredIf)
+	if err != nil {
+		return "", "", err
+	}
+	return request, response, nil
+}
+
+// isJSON reports whether the media type is JSON, e.g. application/json or
+// application/problem+json.
+func isJSON(mediaType string) bool {
+	mediaType, _, _ = strings.Cut(strings.ToLower(mediaType), ";")
+	mediaType = strings.TrimSpace(mediaType)
+	return mediaType == "application/json" || strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")
+}
+
+// bounded returns the types whose bounds apply to the field generated from s: the bounds
+// of the types of s, that of integers being number, or else all of them. The bounds of
+// several types of a schema of none, or of several, are ambiguous, the kind of its field
+// telling none apart: none applies, and the error is a *KeywordError of the second,
+// unless SkipUnsupported is set.
+func (o Options) bounded(s *schema.Schema) (map[string]bool, error) {
+	applies := make(map[string]bool)
+	for _, t := range s.Types {
+		if t == schema.TypeInteger {
+			t = schema.TypeNumber
+		}
+		applies[t] = true
+	}
+	if len(applies) == 1 {
+		return applies, nil
+	}
+
+	var keywords []string
+	for _, b := range []struct {
+		t, min, max string
+		hasMin      bool
+		hasMax      bool
+	}{
+		{schema.TypeString, "minLength", "maxLength", s.MinLength > 0, s.MaxLength != nil},
+		{schema.TypeNumber, "minimum", "maximum", s.Minimum != nil, s.Maximum != nil},
+		{schema.TypeArray, "minItems", "maxItems", s.MinItems > 0, maxItems(s) != nil},
+		{schema.TypeObject, "minProperties", "maxProperties", s.MinProperties > 0, s.MaxProperties != nil},
+	} {
+		switch {
+		case b.hasMin:
+			keywords = append(keywords, b.min)
+		case b.hasMax:
+			keywords = append(keywords, b.max)
+		default:
+			continue
+		}
+		applies[b.t] = true
+	}
+	if len(keywords) < 2 {
+		return applies, nil
+	}
+	if o.SkipUnsupported {
+		return nil, nil
+	}
+	return nil, &KeywordError{Keyword: keywords[1], Err: fmt.Errorf("validation keyword '%s' is ambiguous with '%s': the field of a schema without a single type cannot tell them apart, set its type", keywords[1], keywords[0])}
+}
+
+// unique returns the rule of the uniqueItems of s. The validator compares the items as
+// map keys: the structs generated from objects, holding pointers, slices or maps, are
+// compared by the field of the property of UniqueByKey, or else of their single required
+// property. Arrays and maps, which cannot be compared, are a *KeywordError, as are
+// objects without such a property, unless SkipUnsupported is set.
+func (o Options) unique(s *schema.Schema) (string, error) {
+	var items *schema.Schema
+	if s.Items != nil {
+		items, _ = flatten(s.Items, make(map[*schema.Schema]bool))
+	}
+	var err error
+	switch {
+	case items == nil:
+		return "unique", nil
+	case items.IsMap() || items.Is(schema.TypeArray):
+		err = errors.New("validation keyword 'uniqueItems' is not supported on arrays of arrays or maps, which the validator cannot compare")
+	case items.Is(schema.TypeObject) || len(items.Properties) > 0:
+		by, ok := s.Extensions[UniqueByKey].(string)
+		if !ok && len(items.Required) == 1 {
+			by, ok = items.Required[0], true
+		}
+		switch {
+		case !ok:
+			err = fmt.Errorf("validation keyword 'uniqueItems' on objects needs %s naming the property telling them apart", UniqueByKey)
+		case items.Properties[by] == nil:
+			err = fmt.Errorf("%s '%s' is not a property of the items", UniqueByKey, by)
+		default:
+			return "unique=" + naming.TypeName(by), nil
+		}
+	default:
+		return "unique", nil
+	}
+	if o.SkipUnsupported {
+		return "", nil
+	}
+	return "", &KeywordError{Keyword: "uniqueItems", Err: err}
+}
+
+// ZeroAllowed reports whether the field generated from s, when required, is a number that
+// is not a pointer, s not being nullable, whose zero value s allows. Fields of an
+// x-go-type have an unknown zero value.
+func ZeroAllowed(s *schema.Schema) bool {
+	if _, ok := s.Extensions["x-go-type"]; ok || s.Nullable {
+		return false
+	}
+	s, _ = flatten(s, make(map[*schema.Schema]bool))
+	if !isNumeric(s) {
+		return false
+	}
+	isZero := func(v any) bool {
+		p, ok := param(v)
+		return ok && p == "0"
+	}
+	return (s.Minimum == nil || *s.Minimum < 0 || *s.Minimum == 0 && !s.ExclusiveMinimum) &&
+		(s.Maximum == nil || *s.Maximum > 0 || *s.Maximum == 0 && !s.ExclusiveMaximum) &&
+		(len(s.Enum) == 0 || slices.ContainsFunc(s.Enum, isZero)) &&
+		(s.Const == nil || isZero(s.Const))
+}
+
+// numericBounds are the rules of the bounds of numbers, by the rule checking the same on a
+// number.
+var numericBounds = map[string]string{"min": "gte", "max": "lte"}
+
+// isNumeric reports whether the values of s are numbers only.
+func isNumeric(s *schema.Schema) bool {
+	return len(s.Types) > 0 && !slices.ContainsFunc(s.Types, func(t string) bool {
+		return t != schema.TypeInteger && t != schema.TypeNumber
+	})
+}
+
+// maxItems returns the maximum number of items of s: an array whose items are its prefix
+// items only has no more items than those.
+func maxItems(s *schema.Schema) *uint64 {
+	if closed := uint64(len(s.PrefixItems)); s.ClosedItems && (s.MaxItems == nil || *s.MaxItems > closed) {
+		return &closed
+	}
+	return s.MaxItems
+}
+
+// bound returns the parameter of the rule of the bound v of s, at full precision. That of
+// an integer, which the rule parses as an integer, is rounded to an integer keeping the
+// same values: up for gte and lt, down for lte and gt.
+func bound(s *schema.Schema, v float64, up bool) string {
+	if s.Is(schema.TypeInteger) && !s.Is(schema.TypeNumber) {
+		if up {
+			v = math.Ceil(v)
+		} else {
+			v = math.Floor(v)
+		}
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// oneOf returns the oneof rule of the enum of a string or integer schema, if it has one,
+// and the oneofnumber rule of a number schema: the oneof of validator/v10 panics on the
+// floats of numbers. Values with spaces are quoted, and the commas and pipes separating
+// rules escaped. An enum with a value holding a single quote, which the rule cannot
+// express, gets none.
+func oneOf(s *schema.Schema) string {
+	if len(s.Enum) == 0 || !s.Is(schema.TypeString) && !s.Is(schema.TypeInteger) && !s.Is(schema.TypeNumber) {
+		return ""
+	}
+	rule := "oneof"
+	if s.Is(schema.TypeNumber) {
+		// oneofnumber is a custom validation, registered by the middleware and generated
+		// by the registrations command.
+		rule = "oneofnumber"
+	}
+	var values []string
+	for _, v := range s.Enum {
+		if v == nil {
+			// Allowed by nullable, checked by required and omitempty.
+			continue
+		}
+		value, ok := param(v)
+		if ok {
+			value, ok = word(value)
+		}
+		if !ok {
+			return ""
+		}
+		values = append(values, value)
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return rule + "=" + strings.Join(values, " ")
+}
+
+// word returns the parameter value as a word of the parameters of a rule, separated by
+// spaces: quoted when empty or holding spaces. A value holding a single quote cannot be
+// one.
+func word(value string) (string, bool) {
+	if strings.Contains(value, "'") {
+		return "", false
+	}
+	if value == "" || strings.ContainsFunc(value, unicode.IsSpace) {
+		value = "'" + value + "'"
+	}
+	return value, true
+}
+
+// anchored returns pattern matching whole values only: as is when it begins with ^ and
+// ends with $ at its top level, wrapped in ^(?:...)$ otherwise, e.g. ^a|b$.
+func anchored(pattern string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err == nil && re.Op == syntax.OpConcat && len(re.Sub) > 1 &&
+		re.Sub[0].Op == syntax.OpBeginText && re.Sub[len(re.Sub)-1].Op == syntax.OpEndText {
+		return pattern
+	}
+	return "^(?:" + pattern + ")$"
+}
+
+// Escape escapes the commas and pipes of the parameter of a rule, which would otherwise
+// separate rules, as validator/v10 unescapes them: 0x2C and 0x7C. Equals signs need no
+// escaping, a rule being split at its first one.
+func Escape(param string) string {
+	return escaper.Replace(param)
+}
+
+// Unescape returns the parameter of a rule as validator/v10 passes it to validations.
+func Unescape(param string) string {
+	return unescaper.Replace(param)
+}
+
+var (
+	escaper   = strings.NewReplacer(",", "0x2C", "|", "0x7C")
+	unescaper = strings.NewReplacer("0x2C", ",", "0x7C", "|")
+	// literalHex rewrites the text of a pattern that Unescape would turn into a comma or
+	// a pipe to an equivalent pattern: x is matched by its \x78 escape.
+	literalHex = strings.NewReplacer("0x2C", `0\x782C`, "0x7C", `0\x787C`)
+)
+
+// QuoteTag returns tag as the extensions hold it: escaped as the value of a struct tag,
+// which oapi-codegen writes between double quotes as is and reflect.StructTag.Lookup reads
+// with strconv.Unquote. Its backslashes and double quotes are escaped, as are its
+// backquotes, as \x60, which would end the raw string of the struct tags.
+func QuoteTag(tag string) string {
+	quoted := strconv.Quote(tag)
+	return strings.ReplaceAll(quoted[1:len(quoted)-1], "`", `\x60`)
+}
+
+// UnquoteTag returns the tag QuoteTag escaped, or tag as is when it is not a valid
+// escaped one, e.g. a tag set by hand with a lone backslash.
+func UnquoteTag(tag string) string {
+	if unquoted, err := strconv.Unquote(`"` + tag + `"`); err == nil {
+		return unquoted
+	}
+	return tag
+}
+
+// param returns the JSON value v as the parameter of a rule, escaping the commas and
+// pipes separating rules. Only strings, numbers and booleans have one.
+func param(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return Escape(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	}
+	return "", false
+}
+
+// Merge appends the generated rules to the existing ones, failing when a rule of both
+// differs, e.g. min=3 and min=5. The rules following a dive, those of the items or
+// values, are merged with the rules following the same dive, and the rules of the keys
+// with those of the same keys. The error joins a *KeywordError per conflict.
+func Merge(existingRules, newRules []string) (rules []string, err error) {
+	existing, generated := diveLevels(existingRules), diveLevels(newRules)
+	var errs []error
+	for i := range max(len(existing), len(generated)) {
+		var e, g level
+		if i < len(existing) {
+			e = existing[i]
+		}
+		if i < len(generated) {
+			g = generated[i]
+		}
+		keys, err := mergeLevel(e.keys, g.keys)
+		errs = append(errs, err)
+		values, err := mergeLevel(e.rules, g.rules)
+		errs = append(errs, err)
+		if i > 0 {
+			rules = append(rules, "dive")
+		}
+		if len(keys) > 0 {
+			rules = append(rules, "keys")
+			rules = append(rules, keys...)
+			rules = append(rules, "endkeys")
+		}
+		rules = append(rules, values...)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// level holds the rules between two dives, and those of the keys of a map enclosed in
+// keys and endkeys after the first.
+type level struct {
+	rules, keys []string
+}
+
+// diveLevels splits rules at their dives.
+func diveLevels(rules []string) []level {
+	levels := []level{{}}
+	inKeys := false
+	for _, rule := range rules {
+		l := &levels[len(levels)-1]
+		switch r := strings.TrimSpace(rule); {
+		case r == "dive":
+			levels = append(levels, level{})
+		case r == "keys":
+			inKeys = true
+		case r == "endkeys":
+			inKeys = false
+		case inKeys:
+			l.keys = append(l.keys, rule)
+		default:
+			l.rules = append(l.rules, rule)
+		}
+	}
+	return levels
+}
+
+func mergeLevel(existingRules, newRules []string) (rules []string, err error) {
+	existingKeys := make(map[string]string)
+	var conflicts []error
+
+	for _, part := range existingRules {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		rules = append(rules, part)
+		key := getTagKey(part)
+		existingKeys[key] = part
+	}
+
+	for _, tag := range newRules {
+		key := getTagKey(tag)
+		if existingTag, exists := existingKeys[key]; exists {
+			// Conflict check
+			if existingTag != tag {
+				conflicts = append(conflicts, &KeywordError{Keyword: TagKey, Err: fmt.Errorf("conflict: manual tag '%s' differs from generated tag '%s'", existingTag, tag)})
+			}
+		} else {
+			rules = append(rules, tag)
+			existingKeys[key] = tag
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, errors.Join(conflicts...)
+	}
+	return rules, nil
+}
+
+func getTagKey(tag string) string {
+	if idx := strings.Index(tag, "="); idx != -1 {
+		return tag[:idx]
+	}
+	return tag
+}
@@ -0,0 +1,543 @@
+package rules
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-playground/validator/v10"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestBackends checks the rules do not depend on the backend the schema is read with.
+func TestBackends(t *testing.T) {
+	tests := []struct {
+		name    string
+		openapi string
+		json    string
+		want    string
+	}{
+		{
+			name:    "string",
+			openapi: `{"type": "string", "format": "email", "minLength": 3, "maxLength": 50}`,
+			json:    `{"type": "string", "format": "email", "minLength": 3, "maxLength": 50}`,
+			want:    "required,min=3,max=50,email",
+		},
+		{
+			name:    "exclusive bounds",
+			openapi: `{"type": "integer", "minimum": 0, "exclusiveMinimum": true, "maximum": 10, "exclusiveMaximum": true}`,
+			json:    `{"type": "integer", "exclusiveMinimum": 0, "exclusiveMaximum": 10}`,
+			want:    "required,gt=0,lt=10",
+		},
+		{
+			name:    "fractional bounds",
+			openapi: `{"type": "number", "minimum": 0.5, "maximum": 99.99}`,
+			json:    `{"type": "number", "minimum": 0.5, "maximum": 99.99}`,
+			want:    "required,gte=0.5,lte=99.99",
+		},
+		{
+			name:    "fractional integer bounds",
+			openapi: `{"type": "integer", "minimum": 0.5, "maximum": 10.5, "exclusiveMaximum": true}`,
+			json:    `{"type": "integer", "minimum": 0.5, "exclusiveMaximum": 10.5}`,
+			want:    "required,gte=1,lt=11",
+		},
+		{
+			name:    "nullable array",
+			openapi: `{"type": "array", "nullable": true, "minItems": 1, "uniqueItems": true, "items": {"type": "string"}}`,
+			json:    `{"type": ["array", "null"], "minItems": 1, "uniqueItems": true, "items": {"type": "string"}}`,
+			want:    "required,min=1,unique",
+		},
+		{
+			name:    "string enum",
+			openapi: `{"type": "string", "nullable": true, "enum": ["light", "dark blue", "a,b", null]}`,
+			json:    `{"type": ["string", "null"], "enum": ["light", "dark blue", "a,b", null]}`,
+			want:    "required,oneof=light 'dark blue' a0x2Cb",
+		},
+		{
+			name:    "numeric enum",
+			openapi: `{"type": "number", "enum": [1, 2.5, -3]}`,
+			json:    `{"type": "number", "enum": [1, 2.5, -3]}`,
+			want:    "required,oneofnumber=1 2.5 -3",
+		},
+		{
+			name:    "const",
+			openapi: `{"type": "string", "const": "dog,cat"}`,
+			json:    `{"type": "string", "const": "dog,cat"}`,
+			want:    "required,eq=dog0x2Ccat",
+		},
+		{
+			name:    "map",
+			openapi: `{"type": "object", "additionalProperties": {"type": "string"}, "minProperties": 1, "maxProperties": 10}`,
+			json:    `{"type": "object", "additionalProperties": {"type": "string"}, "minProperties": 1, "maxProperties": 10}`,
+			want:    "required,min=1,max=10",
+		},
+		{
+			name:    "array items",
+			openapi: `{"type": "array", "maxItems": 5, "items": {"type": "array", "items": {"type": "string", "nullable": true, "maxLength": 64}}}`,
+			json:    `{"type": "array", "maxItems": 5, "items": {"type": "array", "items": {"type": ["string", "null"], "maxLength": 64}}}`,
+			want:    "required,max=5,dive,dive,omitempty,max=64",
+		},
+		{
+			name:    "unique objects",
+			openapi: `{"type": "array", "uniqueItems": true, "x-unique-by": "user_id", "items": {"type": "object", "properties": {"user_id": {"type": "string"}, "tags": {"type": "array", "items": {"type": "string"}}}}}`,
+			json:    `{"type": "array", "uniqueItems": true, "x-unique-by": "user_id", "items": {"type": "object", "properties": {"user_id": {"type": "string"}, "tags": {"type": "array", "items": {"type": "string"}}}}}`,
+			want:    "required,unique=UserId",
+		},
+		{
+			name:    "map keys and values",
+			openapi: `{"type": "object", "additionalProperties": {"type": "string", "maxLength": 64}, "propertyNames": {"type": "string", "maxLength": 10}}`,
+			json:    `{"type": "object", "additionalProperties": {"type": "string", "maxLength": 64}, "propertyNames": {"type": "string", "maxLength": 10}}`,
+			want:    "required,dive,keys,max=10,endkeys,max=64",
+		},
+		{
+			name:    "all of",
+			openapi: `{"allOf": [{"type": "string", "minLength": 1, "allOf": [{"pattern": "^[a-z]+$"}]}, {"maxLength": 50}]}`,
+			json:    `{"allOf": [{"type": "string", "minLength": 1, "allOf": [{"pattern": "^[a-z]+$"}]}, {"maxLength": 50}]}`,
+			want:    "required,regex=^[a-z]+$,min=1,max=50",
+		},
+		{
+			name:    "base64",
+			openapi: `{"type": "string", "format": "byte", "contentEncoding": "base64"}`,
+			json:    `{"type": "string", "contentEncoding": "base64"}`,
+			want:    "required,base64",
+		},
+		{
+			name:    "base64url",
+			openapi: `{"type": "string", "contentEncoding": "base64url"}`,
+			json:    `{"type": "string", "contentEncoding": "base64url"}`,
+			want:    "required,base64url",
+		},
+		{
+			name:    "base16",
+			openapi: `{"type": "string", "contentEncoding": "base16"}`,
+			json:    `{"type": "string", "contentEncoding": "base16"}`,
+			want:    "required,hexadecimal",
+		},
+		{
+			name:    "json content",
+			openapi: `{"type": "string", "contentMediaType": "application/json"}`,
+			json:    `{"type": "string", "contentMediaType": "application/problem+json; charset=utf-8"}`,
+			want:    "required,json",
+		},
+		{
+			name:    "encoded json content",
+			openapi: `{"type": "string", "contentEncoding": "base64", "contentMediaType": "application/json"}`,
+			json:    `{"type": "string", "contentEncoding": "base64", "contentMediaType": "application/json"}`,
+			want:    "required,base64",
+		},
+		{
+			name:    "type array",
+			openapi: `{"type": "string", "nullable": true, "maxLength": 10, "const": "dog"}`,
+			json:    `{"type": ["string", "null"], "maxLength": 10, "const": "dog"}`,
+			want:    "required,max=10,eq=dog",
+		},
+		{
+			name:    "prefix items",
+			openapi: `{"type": "array", "minItems": 1, "maxItems": 2, "prefixItems": [{"type": "string"}, {"type": "integer"}], "items": {}}`,
+			json:    `{"type": "array", "minItems": 1, "prefixItems": [{"type": "string"}, {"type": "integer"}], "items": false}`,
+			want:    "required,min=1,max=2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kin openapi3.Schema
+			require.NoError(t, kin.UnmarshalJSON([]byte(tt.openapi)))
+			require.NoError(t, kin.Validate(context.Background(), openapi3.AllowExtraSiblingFields("const", "contentEncoding", "contentMediaType", "propertyNames", "prefixItems")))
+			fromKin, err := Field(schema.FromKin(&kin), "", true)
+			require.NoError(t, err)
+
+			s, err := schema.ParseJSON([]byte(tt.json))
+			require.NoError(t, err)
+			fromJSON, err := Field(s, "", true)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, fromKin)
+			assert.Equal(t, tt.want, fromJSON)
+		})
+	}
+}
+
+func TestField(t *testing.T) {
+	maxLength, otherLength := uint64(10), uint64(50)
+	zero, one, ten, hundred := 0.0, 1.0, 10.0, 100.0
+	node := &schema.Schema{Types: []string{schema.TypeObject}}
+	node.Properties = map[string]*schema.Schema{"children": {Types: []string{schema.TypeArray}, Items: node}}
+	tests := []struct {
+		name     string
+		schema   schema.Schema
+		existing string
+		required bool
+		options  Options
+		want     string
+		err      string
+	}{
+		{name: "unconstrained", schema: schema.Schema{Types: []string{schema.TypeString}}},
+		{name: "required", schema: schema.Schema{Types: []string{schema.TypeString}}, required: true, want: "required"},
+		{name: "optional", schema: schema.Schema{MaxLength: &maxLength}, want: "omitempty,max=10"},
+		{name: "merged", schema: schema.Schema{MaxLength: &maxLength}, existing: "alphanum", want: "omitempty,alphanum,max=10"},
+		{name: "enriched", schema: schema.Schema{MaxLength: &maxLength}, existing: "omitempty,max=10", want: "omitempty,max=10"},
+		{name: "conflict", schema: schema.Schema{MaxLength: &maxLength}, existing: "max=5", err: "conflict"},
+		{name: "invalid pattern", schema: schema.Schema{Pattern: "(?=x)"}, err: "not a valid Go RE2 regex"},
+		{name: "struct properties", schema: schema.Schema{Types: []string{schema.TypeObject}, Properties: map[string]*schema.Schema{"a": {}}, AdditionalProperties: true, MinProperties: 1}, err: "'minProperties' is only supported on objects generated as maps"},
+		{name: "enriched items", schema: schema.Schema{MinItems: 1, Items: &schema.Schema{MaxLength: &maxLength}}, existing: "omitempty,min=1,dive,max=10", want: "omitempty,min=1,dive,max=10"},
+		{name: "items conflict", schema: schema.Schema{Items: &schema.Schema{MaxLength: &maxLength}}, existing: "max=10,dive,max=5", err: "conflict"},
+		{name: "enriched keys", schema: schema.Schema{AdditionalProperties: true, Values: &schema.Schema{MaxLength: &maxLength}, PropertyNames: &schema.Schema{Pattern: "^[a-z]+$"}}, existing: "dive,keys,max=5,endkeys,max=10", want: "omitempty,dive,keys,max=5,regex=^[a-z]+$,endkeys,max=10"},
+		{name: "keys conflict", schema: schema.Schema{AdditionalProperties: true, PropertyNames: &schema.Schema{MaxLength: &maxLength}}, existing: "dive,keys,max=5,endkeys", err: "conflict"},
+		{name: "all of tighter length", schema: schema.Schema{AllOf: []*schema.Schema{{Types: []string{schema.TypeString}, MaxLength: &otherLength}, {MaxLength: &maxLength}}}, want: "omitempty,max=10"},
+		{name: "all of tighter bounds", schema: schema.Schema{Types: []string{schema.TypeNumber}, Minimum: &zero, Maximum: &hundred, AllOf: []*schema.Schema{{Minimum: &one, Maximum: &hundred, ExclusiveMaximum: true}, {Minimum: &zero, ExclusiveMinimum: true}}}, want: "omitempty,gte=1,lt=100"},
+		{name: "all of tighter items", schema: schema.Schema{MinItems: 1, AllOf: []*schema.Schema{{Types: []string{schema.TypeArray}, MinItems: 2, MaxItems: &otherLength}, {MaxItems: &maxLength}}}, want: "omitempty,min=2,max=10"},
+		{name: "all of multiples", schema: schema.Schema{Types: []string{schema.TypeInteger}, MultipleOf: &hundred, AllOf: []*schema.Schema{{MultipleOf: &ten}}}, want: "omitempty,multipleof=100"},
+		{name: "all of conflict", schema: schema.Schema{Pattern: "^[a-z]+$", AllOf: []*schema.Schema{{Pattern: "^[a-z]+$"}, {Pattern: "^[0-9]+$"}}}, err: "conflict: allOf member sets 'pattern' to ^[0-9]+$, where another member or the schema sets it to ^[a-z]+$"},
+		{name: "prefix items", schema: schema.Schema{PrefixItems: []*schema.Schema{{MaxLength: &maxLength}}}, err: "validation keyword 'prefixItems' is only supported without rules on its items"},
+		{name: "prefix items skipped", schema: schema.Schema{MinItems: 1, PrefixItems: []*schema.Schema{{MaxLength: &maxLength}}, Items: &schema.Schema{MaxLength: &maxLength}}, options: Options{SkipUnsupported: true}, want: "omitempty,min=1"},
+		{name: "untyped number", schema: schema.Schema{Minimum: &zero}, want: "omitempty,gte=0"},
+		{name: "typed bounds", schema: schema.Schema{Types: []string{schema.TypeString}, MinLength: 3, Minimum: &zero}, want: "omitempty,min=3"},
+		{name: "ambiguous bounds", schema: schema.Schema{Types: []string{schema.TypeString, schema.TypeNumber}, MinLength: 3, Minimum: &zero}, err: "validation keyword 'minimum' is ambiguous with 'minLength'"},
+		{name: "ambiguous bounds skipped", schema: schema.Schema{MinLength: 3, MaxItems: &maxLength}, options: Options{SkipUnsupported: true}},
+		{name: "enriched numbers", schema: schema.Schema{Types: []string{schema.TypeInteger}, Maximum: &hundred}, existing: "omitempty,min=1,max=100", want: "omitempty,gte=1,lte=100"},
+		{name: "numbers conflict", schema: schema.Schema{Types: []string{schema.TypeInteger}, Maximum: &hundred}, existing: "max=10", err: "conflict"},
+		{name: "required zero", schema: schema.Schema{Types: []string{schema.TypeInteger}, Minimum: &zero}, required: true, want: "gte=0"},
+		{name: "required zero enriched", schema: schema.Schema{Types: []string{schema.TypeInteger}, Minimum: &zero}, existing: "required,gte=0", required: true, want: "gte=0"},
+		{name: "required unconstrained number", schema: schema.Schema{Types: []string{schema.TypeNumber}}, required: true},
+		{name: "required positive", schema: schema.Schema{Types: []string{schema.TypeInteger}, Minimum: &one}, required: true, want: "required,gte=1"},
+		{name: "required nonzero enum", schema: schema.Schema{Types: []string{schema.TypeInteger}, Enum: []any{1.0, 2.0}}, required: true, want: "required,oneof=1 2"},
+		{name: "required nullable zero", schema: schema.Schema{Types: []string{schema.TypeInteger}, Nullable: true, Minimum: &zero}, required: true, want: "required,gte=0"},
+		{name: "unique by required", schema: schema.Schema{UniqueItems: true, Items: &schema.Schema{Types: []string{schema.TypeObject}, Required: []string{"id"}, Properties: map[string]*schema.Schema{"id": {}, "name": {}}}}, want: "omitempty,unique=Id,dive"},
+		{name: "struct items", schema: schema.Schema{Items: &schema.Schema{Types: []string{schema.TypeObject}, Properties: map[string]*schema.Schema{"name": {MaxLength: &maxLength}}}}, want: "omitempty,dive"},
+		{name: "nested struct items", schema: schema.Schema{Items: &schema.Schema{Items: &schema.Schema{Types: []string{schema.TypeObject}, Properties: map[string]*schema.Schema{"owner": {Properties: map[string]*schema.Schema{"name": {MaxLength: &maxLength}}}}}}}, required: true, want: "required,dive,dive"},
+		{name: "unconstrained struct items", schema: schema.Schema{Items: &schema.Schema{Types: []string{schema.TypeObject}, Properties: map[string]*schema.Schema{"name": {}}}}},
+		{name: "recursive struct items", schema: schema.Schema{Items: node}},
+		{name: "struct values", schema: schema.Schema{AdditionalProperties: true, Values: &schema.Schema{Types: []string{schema.TypeObject}, Required: []string{"count"}, Properties: map[string]*schema.Schema{"count": {}}}}, want: "omitempty,dive"},
+		{name: "unconstrained struct values", schema: schema.Schema{AdditionalProperties: true, Values: &schema.Schema{Types: []string{schema.TypeObject}, Properties: map[string]*schema.Schema{"count": {}}}}},
+		{name: "unique objects", schema: schema.Schema{UniqueItems: true, Items: &schema.Schema{Types: []string{schema.TypeObject}, Properties: map[string]*schema.Schema{"id": {}}}}, err: "validation keyword 'uniqueItems' on objects needs x-unique-by"},
+		{name: "unique by unknown", schema: schema.Schema{UniqueItems: true, Extensions: map[string]any{UniqueByKey: "key"}, Items: &schema.Schema{Properties: map[string]*schema.Schema{"id": {}}}}, err: "x-unique-by 'key' is not a property of the items"},
+		{name: "unique arrays", schema: schema.Schema{UniqueItems: true, Items: &schema.Schema{Types: []string{schema.TypeArray}}}, err: "not supported on arrays of arrays or maps"},
+		{name: "unique arrays skipped", schema: schema.Schema{MinItems: 1, UniqueItems: true, Items: &schema.Schema{Types: []string{schema.TypeArray}}}, options: Options{SkipUnsupported: true}, want: "omitempty,min=1"},
+		{name: "inexpressible enum", schema: schema.Schema{Types: []string{schema.TypeString}, Enum: []any{"it's", "its"}}, required: true, want: "required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.options.Field(&tt.schema, tt.existing, tt.required)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOneOf(t *testing.T) {
+	s := &schema.Schema{Types: []string{schema.TypeString}, Enum: []any{"light", "dark blue", "a,b", "a|b", ""}}
+	tag, err := Field(s, "", false)
+	require.NoError(t, err)
+
+	v := validator.New()
+	for _, value := range s.Enum {
+		assert.NoError(t, v.Var(value, tag), value)
+	}
+	for _, value := range []string{"dark", "blue", "a", "'dark blue'"} {
+		assert.Error(t, v.Var(value, tag), value)
+	}
+}
+
+func TestEq(t *testing.T) {
+	v := validator.New()
+	for _, tt := range []struct {
+		schema       schema.Schema
+		valid, other any
+	}{
+		{schema: schema.Schema{Types: []string{schema.TypeString}, Const: "a, b|c"}, valid: "a, b|c", other: "a"},
+		{schema: schema.Schema{Types: []string{schema.TypeInteger}, Const: 2.0}, valid: 2, other: 3},
+		{schema: schema.Schema{Types: []string{schema.TypeBoolean}, Const: true}, valid: true, other: false},
+	} {
+		tag, err := Field(&tt.schema, "", true)
+		require.NoError(t, err)
+		assert.NoError(t, v.Var(tt.valid, tag), tag)
+		assert.Error(t, v.Var(tt.other, tag), tag)
+	}
+}
+
+func TestRegexEscaping(t *testing.T) {
+	v := validator.New()
+	require.NoError(t, v.RegisterValidation("regex", func(fl validator.FieldLevel) bool {
+		return regexp.MustCompile(fl.Param()).MatchString(fl.Field().String())
+	}))
+	for _, tt := range []struct {
+		pattern      string
+		valid, other string
+	}{
+		{pattern: `^[a-z]+=(foo|bar),?$`, valid: "key=foo,", other: "key=baz"},
+		{pattern: `^a{1,3}$`, valid: "aa", other: "aaaa"},
+		{pattern: `^0x2C|0x7C$`, valid: "0x7C", other: ","},
+	} {
+		tag, err := Field(&schema.Schema{Types: []string{schema.TypeString}, Pattern: tt.pattern}, "", true)
+		require.NoError(t, err)
+		assert.NoError(t, v.Var(tt.valid, tag), tag)
+		assert.Error(t, v.Var(tt.other, tag), tag)
+	}
+}
+
+func TestQuoteTag(t *testing.T) {
+	for _, pattern := range []string{`^\d+$`, `^say "\w+"$`, "^a`b$", `^0x2C$`, `^[\d\-]+\x{E9}$`} {
+		tag, err := Field(&schema.Schema{Types: []string{schema.TypeString}, Pattern: pattern}, "", true)
+		require.NoError(t, err)
+		quoted := QuoteTag(tag)
+		assert.NotContains(t, quoted, "`", "the struct tags are a raw string")
+		got, ok := reflect.StructTag(`validate:"` + quoted + `"`).Lookup("validate")
+		assert.True(t, ok, quoted)
+		assert.Equal(t, tag, got)
+		assert.Equal(t, tag, UnquoteTag(quoted))
+	}
+	assert.Equal(t, `regex=^\d$`, UnquoteTag(`regex=^\d$`), "not escaped")
+}
+
+func TestAnchorPatterns(t *testing.T) {
+	for _, tt := range []struct {
+		pattern    string
+		extensions map[string]any
+		want       string
+	}{
+		{pattern: "[a-z]+", want: "regex=^(?:[a-z]+)$"},
+		{pattern: "^[a-z]+$", want: "regex=^[a-z]+$"},
+		{pattern: "^a|b$", want: "regex=^(?:^a0x7Cb$)$"},
+		{pattern: "[a-z]+", extensions: map[string]any{AnchorKey: false}, want: "regex=[a-z]+"},
+	} {
+		got, err := Options{AnchorPatterns: true}.Generate(&schema.Schema{Types: []string{schema.TypeString}, Pattern: tt.pattern, Extensions: tt.extensions})
+		require.NoError(t, err)
+		assert.Equal(t, []string{tt.want}, got, tt.pattern)
+	}
+
+	got, err := Generate(&schema.Schema{Types: []string{schema.TypeString}, Pattern: "[a-z]+"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"regex=[a-z]+"}, got)
+}
+
+func TestOmitNil(t *testing.T) {
+	maxLength := uint64(10)
+	tests := []struct {
+		name     string
+		schema   schema.Schema
+		existing string
+		required bool
+		want     string
+	}{
+		{name: "nullable", schema: schema.Schema{Nullable: true, MaxLength: &maxLength}, want: "omitnil,max=10"},
+		{name: "not nullable", schema: schema.Schema{MaxLength: &maxLength}, want: "omitempty,max=10"},
+		{name: "required", schema: schema.Schema{Nullable: true, MaxLength: &maxLength}, required: true, want: "required,max=10"},
+		{name: "enriched", schema: schema.Schema{Nullable: true, MaxLength: &maxLength}, existing: "omitempty,max=10", want: "omitnil,max=10"},
+		{name: "value field", schema: schema.Schema{Nullable: true, MaxLength: &maxLength, Extensions: map[string]any{"x-go-type-skip-optional-pointer": true}}, want: "omitempty,max=10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Options{OmitNil: true}.Field(&tt.schema, tt.existing, tt.required)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRequiredIf(t *testing.T) {
+	s, err := schema.ParseJSON([]byte(`{
+		"type": "object",
+		"required": ["petType"],
+		"properties": {
+			"petType": {"type": "string"},
+			"bark": {"type": "string", "maxLength": 5},
+			"meow": {"type": "string"},
+			"name": {"type": "string"}
+		},
+		"oneOf": [
+			{"$ref": "#/components/schemas/Dog"},
+			{"$ref": "#/components/schemas/Cat"},
+			{"required": ["petType", "meow", "name"], "properties": {"petType": {"const": "big cat"}}}
+		],
+		"discriminator": {
+			"propertyName": "petType",
+			"mapping": {"dog": "Dog", "hound": "#/components/schemas/Dog"}
+		}
+	}`))
+	require.NoError(t, err)
+	// The references are not resolved: the variants are declared by hand.
+	s.OneOf[0].Required = []string{"bark"}
+	s.OneOf[1].Required = []string{"meow"}
+
+	requiredIf := RequiredIf(s)
+	assert.Equal(t, map[string][]string{
+		"bark": {"required_if=PetType dog", "required_if=PetType hound"},
+		"meow": {"required_if=PetType Cat", "required_if=PetType 'big cat'"},
+		"name": {"required_if=PetType 'big cat'"},
+	}, requiredIf)
+
+	tag, err := Options{}.FieldRequiredIf(s.Properties["bark"], "", false, requiredIf["bark"])
+	require.NoError(t, err)
+	assert.Equal(t, "required_if=PetType dog,required_if=PetType hound,omitempty,max=5", tag)
+
+	// Enriching again keeps the tag.
+	again, err := Options{}.FieldRequiredIf(s.Properties["bark"], tag, false, requiredIf["bark"])
+	require.NoError(t, err)
+	assert.Equal(t, tag, again)
+
+	tag, err = Options{}.FieldRequiredIf(s.Properties["bark"], "", true, requiredIf["bark"])
+	require.NoError(t, err)
+	assert.Equal(t, "required,max=5", tag)
+}
+
+func TestRequiredWith(t *testing.T) {
+	s, err := schema.ParseJSON([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"name": {"type": "string"},
+			"cardNumber": {"type": "string"},
+			"billingAddress": {"type": "string", "maxLength": 100},
+			"cvc": {"type": "string"}
+		},
+		"dependentRequired": {
+			"cardNumber": ["billingAddress", "cvc", "name"],
+			"cvc": ["billingAddress"],
+			"undeclared": ["cvc"]
+		}
+	}`))
+	require.NoError(t, err)
+
+	requiredWith := RequiredWith(s)
+	assert.Equal(t, map[string][]string{
+		"billingAddress": {"required_with=CardNumber", "required_with=Cvc"},
+		"cvc":            {"required_with=CardNumber"},
+	}, requiredWith)
+	conditions, err := Conditions(s)
+	require.NoError(t, err)
+	assert.Equal(t, requiredWith, conditions)
+
+	tag, err := Options{}.FieldRequiredIf(s.Properties["billingAddress"], "", false, requiredWith["billingAddress"])
+	require.NoError(t, err)
+	assert.Equal(t, "required_with=CardNumber,required_with=Cvc,omitempty,max=100", tag)
+
+	// Enriching again keeps the tag.
+	again, err := Options{}.FieldRequiredIf(s.Properties["billingAddress"], tag, false, requiredWith["billingAddress"])
+	require.NoError(t, err)
+	assert.Equal(t, tag, again)
+}
+
+func TestIfThenElse(t *testing.T) {
+	s, err := schema.ParseJSON([]byte(`{
+		"type": "object",
+		"required": ["kind"],
+		"properties": {
+			"kind": {"type": "string", "enum": ["business", "personal", "joint account"]},
+			"vatNumber": {"type": "string", "maxLength": 20},
+			"birthDate": {"type": "string"},
+			"coOwner": {"type": "string"}
+		},
+		"if": {"required": ["kind"], "properties": {"kind": {"enum": ["business", "joint account"]}}},
+		"then": {"required": ["vatNumber"], "not": {"required": ["birthDate"]}}
+	}`))
+	require.NoError(t, err)
+
+	conditions, err := IfThenElse(s)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"vatNumber": {"required_if=Kind business", "required_if=Kind 'joint account'"},
+		"birthDate": {"excluded_if=Kind business", "excluded_if=Kind 'joint account'"},
+	}, conditions)
+
+	tag, err := Options{}.FieldRequiredIf(s.Properties["vatNumber"], "", false, conditions["vatNumber"])
+	require.NoError(t, err)
+	assert.Equal(t, "required_if=Kind business,required_if=Kind 'joint account',omitempty,max=20", tag)
+
+	// Enriching again keeps the tag.
+	again, err := Options{}.FieldRequiredIf(s.Properties["vatNumber"], tag, false, conditions["vatNumber"])
+	require.NoError(t, err)
+	assert.Equal(t, tag, again)
+
+	t.Run("else", func(t *testing.T) {
+		s, err := schema.ParseJSON([]byte(`{
+			"type": "object",
+			"properties": {"kind": {"type": "string"}, "vatNumber": {"type": "string"}, "birthDate": {"type": "string"}},
+			"if": {"required": ["kind"], "properties": {"kind": {"const": "business"}}},
+			"then": {"required": ["vatNumber"]},
+			"else": {"required": ["birthDate"], "not": {"required": ["vatNumber"]}}
+		}`))
+		require.NoError(t, err)
+		conditions, err := Conditions(s)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{
+			"vatNumber": {"required_if=Kind business", "excluded_unless=Kind business"},
+			"birthDate": {"required_unless=Kind business"},
+		}, conditions)
+	})
+
+	for name, tc := range map[string]struct {
+		schema  string
+		keyword string
+		want    map[string][]string
+	}{
+		"condition on two properties": {
+			schema:  `"if": {"properties": {"kind": {"const": "business"}, "vatNumber": {"const": "none"}}}, "then": {"required": ["birthDate"]}`,
+			keyword: "if",
+		},
+		"condition met by an absent property": {
+			schema:  `"if": {"properties": {"kind": {"const": "business"}}}, "then": {"required": ["vatNumber"]}`,
+			keyword: "if",
+		},
+		"condition on a pattern": {
+			schema:  `"if": {"properties": {"kind": {"pattern": "^b"}}}, "then": {"required": ["birthDate"]}`,
+			keyword: "if",
+		},
+		"branch with other keywords": {
+			schema:  `"if": {"required": ["kind"], "properties": {"kind": {"const": "business"}}}, "then": {"required": ["vatNumber"], "properties": {"vatNumber": {"minLength": 5}}}`,
+			keyword: "then",
+			want:    map[string][]string{"vatNumber": {"required_if=Kind business"}},
+		},
+		"else of several values": {
+			schema:  `"if": {"required": ["kind"], "properties": {"kind": {"enum": ["business", "charity"]}}}, "then": {"required": ["vatNumber"]}, "else": {"required": ["birthDate"]}`,
+			keyword: "else",
+			want:    map[string][]string{"vatNumber": {"required_if=Kind business", "required_if=Kind charity"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, err := schema.ParseJSON([]byte(`{
+				"type": "object",
+				"properties": {"kind": {"type": "string"}, "vatNumber": {"type": "string"}, "birthDate": {"type": "string"}},
+				` + tc.schema + `
+			}`))
+			require.NoError(t, err)
+			conditions, err := IfThenElse(s)
+			var kw *KeywordError
+			require.ErrorAs(t, err, &kw)
+			assert.Equal(t, tc.keyword, kw.Keyword)
+			if tc.want != nil {
+				assert.Equal(t, tc.want, conditions)
+			} else {
+				assert.Empty(t, conditions)
+			}
+		})
+	}
+}
+
+func TestDirectional(t *testing.T) {
+	maxLength := uint64(10)
+	tests := []struct {
+		name              string
+		schema            schema.Schema
+		request, response string
+	}{
+		{name: "both", schema: schema.Schema{MaxLength: &maxLength}, request: "required,max=10", response: "required,max=10"},
+		{name: "read only", schema: schema.Schema{ReadOnly: true, MaxLength: &maxLength}, request: "omitempty,max=10", response: "required,max=10"},
+		{name: "write only", schema: schema.Schema{WriteOnly: true, MaxLength: &maxLength}, request: "required,max=10", response: "-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, response, err := Options{}.Directional(&tt.schema, "", "", true, nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.request, request)
+			assert.Equal(t, tt.response, response)
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// FromJSON converts a JSON Schema, as decoded by encoding/json, of draft 4, whose
+// exclusiveMinimum and exclusiveMaximum are booleans, as in OpenAPI 3.0, or of a later
+// draft, whose exclusive bounds are numbers, as in OpenAPI 3.1. References are not
+// resolved: the schemas holding one are left without keywords.
+func FromJSON(doc map[string]any) (*Schema, error) {
+	s := &Schema{Extensions: make(map[string]any)}
+	// The exclusive bounds are set last, as they may replace the inclusive ones.
+	for _, last := range []bool{false, true} {
+		for _, key := range slices.Sorted(maps.Keys(doc)) {
+			if strings.HasPrefix(key, "exclusive") != last {
+				continue
+			}
+			if err := s.set(key, doc[key]); err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+		}
+	}
+	if s.Discriminator != nil {
+		s.Discriminator.Values = jsonDiscriminatorValues(doc)
+	}
+	return s, nil
+}
+
+// jsonDiscriminatorValues returns the values selecting each variant of the oneOf of doc,
+// whose references are left unresolved by FromJSON.
+func jsonDiscriminatorValues(doc map[string]any) [][]string {
+	d, _ := doc["discriminator"].(map[string]any)
+	raw, _ := d["mapping"].(map[string]any)
+	mapping := make(map[string]string, len(raw))
+	for value, target := range raw {
+		mapping[value], _ = target.(string)
+	}
+	variants, _ := doc["oneOf"].([]any)
+	values := make([][]string, len(variants))
+	for i, variant := range variants {
+		variant, _ := variant.(map[string]any)
+		ref, _ := variant["$ref"].(string)
+		values[i] = DiscriminatorValues(mapping, ref)
+	}
+	return values
+}
+
+// ParseJSON converts the JSON Schema data, see FromJSON.
+func ParseJSON(data []byte) (*Schema, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return FromJSON(doc)
+}
+
+func (s *Schema) set(key string, v any) error {
+	var err error
+	switch key {
+	case "type":
+		types, ok := v.([]any)
+		if !ok {
+			types = []any{v}
+		}
+		for _, t := range types {
+			t, ok := t.(string)
+			if !ok {
+				return fmt.Errorf("not a string: %v", t)
+			}
+			if t == "null" {
+				s.Nullable = true
+			} else {
+				s.Types = append(s.Types, t)
+			}
+		}
+	case "nullable":
+		s.Nullable, err = boolean(v)
+	case "format":
+		s.Format, err = str(v)
+	case "pattern":
+		s.Pattern, err = str(v)
+	case "readOnly":
+		s.ReadOnly, err = boolean(v)
+	case "writeOnly":
+		s.WriteOnly, err = boolean(v)
+	case "contentEncoding":
+		s.ContentEncoding, err = str(v)
+	case "contentMediaType":
+		s.ContentMediaType, err = str(v)
+	case "enum":
+		values, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("not a list: %v", v)
+		}
+		s.Enum = values
+	case "const":
+		s.Const = v
+	case "minimum":
+		s.Minimum, err = number(v)
+	case "maximum":
+		s.Maximum, err = number(v)
+	case "exclusiveMinimum":
+		s.ExclusiveMinimum, s.Minimum, err = exclusive(v, s.ExclusiveMinimum, s.Minimum)
+	case "exclusiveMaximum":
+		s.ExclusiveMaximum, s.Maximum, err = exclusive(v, s.ExclusiveMaximum, s.Maximum)
+	case "multipleOf":
+		s.MultipleOf, err = number(v)
+	case "minLength":
+		s.MinLength, err = count(v)
+	case "maxLength":
+		s.MaxLength, err = optionalCount(v)
+	case "minItems":
+		s.MinItems, err = count(v)
+	case "maxItems":
+		s.MaxItems, err = optionalCount(v)
+	case "uniqueItems":
+		s.UniqueItems, err = boolean(v)
+	case "propertyNames", "not", "if", "then", "else":
+		doc, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("not an object: %v", v)
+		}
+		sub, err := FromJSON(doc)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "propertyNames":
+			s.PropertyNames = sub
+		case "not":
+			s.Not = sub
+		case "if":
+			s.If = sub
+		case "then":
+			s.Then = sub
+		default:
+			s.Else = sub
+		}
+	case "minProperties":
+		s.MinProperties, err = count(v)
+	case "maxProperties":
+		s.MaxProperties, err = optionalCount(v)
+	case "additionalProperties":
+		switch v := v.(type) {
+		case bool:
+			s.AdditionalProperties = v
+		case map[string]any:
+			s.AdditionalProperties = true
+			s.Values, err = FromJSON(v)
+		default:
+			return fmt.Errorf("not a boolean or an object: %v", v)
+		}
+	case "required":
+		list, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("not a list: %v", v)
+		}
+		for _, name := range list {
+			name, err := str(name)
+			if err != nil {
+				return err
+			}
+			s.Required = append(s.Required, name)
+		}
+	case "allOf":
+		members, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("not a list: %v", v)
+		}
+		for i, member := range members {
+			doc, ok := member.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%d: not an object: %v", i, member)
+			}
+			m, err := FromJSON(doc)
+			if err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+			s.AllOf = append(s.AllOf, m)
+		}
+	case "oneOf":
+		members, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("not a list: %v", v)
+		}
+		for i, member := range members {
+			doc, ok := member.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%d: not an object: %v", i, member)
+			}
+			m, err := FromJSON(doc)
+			if err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+			s.OneOf = append(s.OneOf, m)
+		}
+	case "discriminator":
+		doc, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("not an object: %v", v)
+		}
+		s.Discriminator = &Discriminator{}
+		if s.Discriminator.PropertyName, err = str(doc["propertyName"]); err != nil {
+			return fmt.Errorf("propertyName: %w", err)
+		}
+	case "items":
+		switch v := v.(type) {
+		case bool:
+			s.ClosedItems = !v
+		case map[string]any:
+			s.Items, err = FromJSON(v)
+		}
+		// Tuples of draft 4 to 2019-09 are not supported.
+	case "prefixItems":
+		items, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("not a list: %v", v)
+		}
+		for i, item := range items {
+			doc, ok := item.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%d: not an object: %v", i, item)
+			}
+			m, err := FromJSON(doc)
+			if err != nil {
+				return fmt.Errorf("%d: %w", i, err)
+			}
+			s.PrefixItems = append(s.PrefixItems, m)
+		}
+	case "dependentRequired":
+		dependents, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("not an object: %v", v)
+		}
+		s.DependentRequired = make(map[string][]string, len(dependents))
+		for name, list := range dependents {
+			list, ok := list.([]any)
+			if !ok {
+				return fmt.Errorf("%s: not a list: %v", name, list)
+			}
+			for _, dependent := range list {
+				dependent, err := str(dependent)
+				if err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				s.DependentRequired[name] = append(s.DependentRequired[name], dependent)
+			}
+		}
+	case "properties":
+		props, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("not an object: %v", v)
+		}
+		s.Properties = make(map[string]*Schema, len(props))
+		for name, prop := range props {
+			doc, ok := prop.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s: not an object: %v", name, prop)
+			}
+			if s.Properties[name], err = FromJSON(doc); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	default:
+		if strings.HasPrefix(key, "x-") {
+			s.Extensions[key] = v
+		}
+	}
+	return err
+}
+
+// exclusive returns the exclusive bound v, a boolean making bound exclusive or the
+// bound itself.
+func exclusive(v any, excl bool, bound *float64) (bool, *float64, error) {
+	if b, ok := v.(bool); ok {
+		return b, bound, nil
+	}
+	n, err := number(v)
+	if err != nil {
+		return excl, bound, err
+	}
+	return true, n, nil
+}
+
+func str(v any) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("not a string: %v", v)
+	}
+	return s, nil
+}
+
+func boolean(v any) (bool, error) {
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("not a boolean: %v", v)
+	}
+	return b, nil
+}
+
+func number(v any) (*float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return &n, nil
+	case json.Number:
+		f, err := n.Float64()
+		return &f, err
+	}
+	return nil, fmt.Errorf("not a number: %v", v)
+}
+
+func count(v any) (uint64, error) {
+	n, err := number(v)
+	if err != nil {
+		return 0, err
+	}
+	if *n < 0 || *n != float64(uint64(*n)) {
+		return 0, fmt.Errorf("not a non-negative integer: %v", v)
+	}
+	return uint64(*n), nil
+}
+
+func optionalCount(v any) (*uint64, error) {
+	n, err := count(v)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
@@ -0,0 +1,116 @@
+package schema
+
+import "github.com/getkin/kin-openapi/openapi3"
+
+// KinConverter converts kin-openapi schemas, each once: converting a schema again returns
+// the same Schema, so that recursive schemas terminate and shared ones stay shared.
+type KinConverter struct {
+	schemas map[*openapi3.Schema]*Schema
+}
+
+func NewKinConverter() *KinConverter {
+	return &KinConverter{schemas: make(map[*openapi3.Schema]*Schema)}
+}
+
+// FromKin converts s with a new KinConverter.
+func FromKin(s *openapi3.Schema) *Schema {
+	return NewKinConverter().Convert(s)
+}
+
+// Convert returns the Schema of s. Its extensions are those of s, not a copy.
+func (c *KinConverter) Convert(s *openapi3.Schema) *Schema {
+	if m, ok := c.schemas[s]; ok {
+		return m
+	}
+	m := &Schema{
+		Nullable:         s.Nullable,
+		Format:           s.Format,
+		Pattern:          s.Pattern,
+		ReadOnly:         s.ReadOnly,
+		WriteOnly:        s.WriteOnly,
+		Enum:             s.Enum,
+		Minimum:          s.Min,
+		Maximum:          s.Max,
+		ExclusiveMinimum: s.ExclusiveMin,
+		ExclusiveMaximum: s.ExclusiveMax,
+		MultipleOf:       s.MultipleOf,
+		MinLength:        s.MinLength,
+		MaxLength:        s.MaxLength,
+		MinItems:         s.MinItems,
+		MaxItems:         s.MaxItems,
+		UniqueItems:      s.UniqueItems,
+		Required:         s.Required,
+		MinProperties:    s.MinProps,
+		MaxProperties:    s.MaxProps,
+		Extensions:       s.Extensions,
+	}
+	c.schemas[s] = m
+	// kin-openapi reads OpenAPI 3.0: it keeps the keywords of 3.1 with the extensions.
+	m.Const = s.Extensions["const"]
+	m.AdditionalProperties = s.AdditionalProperties.Schema != nil ||
+		s.AdditionalProperties.Has != nil && *s.AdditionalProperties.Has
+	m.ContentEncoding, _ = s.Extensions["contentEncoding"].(string)
+	m.ContentMediaType, _ = s.Extensions["contentMediaType"].(string)
+	// The numeric exclusive bounds of 3.1 replace the inclusive ones, as in FromJSON.
+	if v, ok := s.Extensions["exclusiveMinimum"]; ok {
+		m.ExclusiveMinimum, m.Minimum, _ = exclusive(v, m.ExclusiveMinimum, m.Minimum)
+	}
+	if v, ok := s.Extensions["exclusiveMaximum"]; ok {
+		m.ExclusiveMaximum, m.Maximum, _ = exclusive(v, m.ExclusiveMaximum, m.Maximum)
+	}
+	for _, t := range s.Type.Slice() {
+		if t == "null" {
+			m.Nullable = true
+		} else {
+			m.Types = append(m.Types, t)
+		}
+	}
+	if s.Items != nil && s.Items.Value != nil {
+		m.Items = c.Convert(s.Items.Value)
+	}
+	if ref := s.AdditionalProperties.Schema; ref != nil && ref.Value != nil {
+		m.Values = c.Convert(ref.Value)
+	}
+	if s.Not != nil && s.Not.Value != nil {
+		m.Not = c.Convert(s.Not.Value)
+	}
+	// The schemas and lists of OpenAPI 3.1, hence extensions, are converted as JSON
+	// Schema, as is the boolean items.
+	doc := make(map[string]any)
+	for _, key := range []string{"propertyNames", "prefixItems", "dependentRequired", "items", "if", "then", "else"} {
+		if v, ok := s.Extensions[key]; ok {
+			doc[key] = v
+		}
+	}
+	if j, err := FromJSON(doc); len(doc) > 0 && err == nil {
+		m.PropertyNames, m.PrefixItems, m.DependentRequired, m.ClosedItems = j.PropertyNames, j.PrefixItems, j.DependentRequired, j.ClosedItems
+		m.If, m.Then, m.Else = j.If, j.Then, j.Else
+	}
+	for _, ref := range s.AllOf {
+		if ref.Value != nil {
+			m.AllOf = append(m.AllOf, c.Convert(ref.Value))
+		}
+	}
+	for _, ref := range s.OneOf {
+		if ref.Value != nil {
+			m.OneOf = append(m.OneOf, c.Convert(ref.Value))
+		}
+	}
+	if d := s.Discriminator; d != nil {
+		m.Discriminator = &Discriminator{PropertyName: d.PropertyName}
+		for _, ref := range s.OneOf {
+			if ref.Value != nil {
+				m.Discriminator.Values = append(m.Discriminator.Values, DiscriminatorValues(d.Mapping, ref.Ref))
+			}
+		}
+	}
+	if len(s.Properties) > 0 {
+		m.Properties = make(map[string]*Schema, len(s.Properties))
+		for name, ref := range s.Properties {
+			if ref.Value != nil {
+				m.Properties[name] = c.Convert(ref.Value)
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,133 @@
+// Package schema is the model of the schemas the rules are generated from, decoupled from
+// the library reading the spec: each backend converts its schemas to it, the keywords of
+// OpenAPI 3.1 and JSON Schema to their OpenAPI 3.0 form.
+package schema
+
+import (
+	"slices"
+	"strings"
+)
+
+// Types of JSON values.
+const (
+	TypeArray   = "array"
+	TypeBoolean = "boolean"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeObject  = "object"
+	TypeString  = "string"
+)
+
+// Schema holds the keywords of a schema the rules depend on.
+type Schema struct {
+	// Types are the types of the values, null excepted: a schema allowing null is
+	// Nullable.
+	Types    []string
+	Nullable bool
+	Format   string
+	Pattern  string
+	// ContentEncoding is the encoding of the string, e.g. base64, and ContentMediaType the
+	// media type of its content, e.g. application/json, OpenAPI 3.1 only.
+	ContentEncoding  string
+	ContentMediaType string
+	// ReadOnly values are sent in responses only, WriteOnly ones in requests only.
+	ReadOnly  bool
+	WriteOnly bool
+	// Enum holds the allowed values, decoded from JSON or YAML.
+	Enum []any
+	// Const is the only allowed value, nil when there is none.
+	Const any
+
+	Minimum          *float64
+	Maximum          *float64
+	ExclusiveMinimum bool
+	ExclusiveMaximum bool
+	MultipleOf       *float64
+
+	MinLength uint64
+	MaxLength *uint64
+
+	MinItems    uint64
+	MaxItems    *uint64
+	UniqueItems bool
+	Items       *Schema
+	// PrefixItems are the schemas of the first items, by position, OpenAPI 3.1 only;
+	// Items is then that of the items following them.
+	PrefixItems []*Schema
+	// ClosedItems reports whether items is false: the array has no items but those of
+	// PrefixItems.
+	ClosedItems bool
+
+	Required   []string
+	Properties map[string]*Schema
+
+	MinProperties uint64
+	MaxProperties *uint64
+	// AdditionalProperties reports whether the object allows properties it does not
+	// declare, additionalProperties being true or a schema.
+	AdditionalProperties bool
+	// Values is the schema of the additional properties, nil when any value is allowed.
+	Values *Schema
+	// PropertyNames is the schema of the names of the properties, nil when there is none.
+	PropertyNames *Schema
+	// DependentRequired holds the properties required when a property is present, by
+	// name of that property, OpenAPI 3.1 only.
+	DependentRequired map[string][]string
+
+	// AllOf are the schemas the values must also match, merged into the schema by the
+	// rules.
+	AllOf []*Schema
+	// OneOf are the variants of the values, exactly one of which they must match.
+	OneOf []*Schema
+	// Discriminator tells the variants of OneOf apart, nil when there is none.
+	Discriminator *Discriminator
+	// Not is the schema the values must not match, nil when there is none.
+	Not *Schema
+	// If is the condition selecting the schema the values must also match: Then when
+	// they match it, Else otherwise, either nil when unset; OpenAPI 3.1 only.
+	If   *Schema
+	Then *Schema
+	Else *Schema
+
+	// Extensions holds the x- keywords, decoded from JSON or YAML.
+	Extensions map[string]any
+}
+
+// Discriminator is the property whose value selects the variant of a oneOf.
+type Discriminator struct {
+	PropertyName string
+	// Values are the values selecting each variant, by index: the keys of the mapping to
+	// its reference, or else the name of the schema it references. Those of an inline
+	// variant are unknown.
+	Values [][]string
+}
+
+// DiscriminatorValues returns the values selecting the variant at ref, given the mapping
+// of the discriminator from values to schema names or references.
+func DiscriminatorValues(mapping map[string]string, ref string) []string {
+	if ref == "" {
+		return nil
+	}
+	var values []string
+	for value, target := range mapping {
+		if target == ref || "#/components/schemas/"+target == ref {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return []string{ref[strings.LastIndex(ref, "/")+1:]}
+	}
+	slices.Sort(values)
+	return values
+}
+
+// IsMap reports whether s is an object generated as a map: one with additional
+// properties but none declared.
+func (s *Schema) IsMap() bool {
+	return s.AdditionalProperties && len(s.Properties) == 0 && (len(s.Types) == 0 || s.Is(TypeObject))
+}
+
+// Is reports whether the values of s may be of type t.
+func (s *Schema) Is(t string) bool {
+	return slices.Contains(s.Types, t)
+}
@@ -0,0 +1,53 @@
+// Package swagger converts Swagger 2.0 specs to OpenAPI 3.0 with kin-openapi as they are
+// read, so that they are enriched, and written, as OpenAPI 3 specs. The references of a
+// converted spec to other files are left as they are: the definitions they point to must
+// already be OpenAPI 3 schemas.
+package swagger
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oasdiff/yaml"
+)
+
+// Is reports whether the spec data, YAML or JSON, is a Swagger 2.0 document.
+func Is(data []byte) bool {
+	if !bytes.Contains(data, []byte("swagger")) {
+		return false
+	}
+	var root struct {
+		Swagger string `json:"swagger"`
+	}
+	return yaml.Unmarshal(data, &root) == nil && root.Swagger == "2.0"
+}
+
+// Convert returns the Swagger 2.0 spec data, YAML or JSON, converted to OpenAPI 3.0, as
+// YAML.
+func Convert(data []byte) ([]byte, error) {
+	var doc openapi2.T
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("swagger 2.0: %w", err)
+	}
+	converted, err := openapi2conv.ToV3(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("converting swagger 2.0 to openapi 3: %w", err)
+	}
+	return yaml.Marshal(converted)
+}
+
+// Reader returns a ReadFromURIFunc reading with next, converting the Swagger 2.0 files
+// read.
+func Reader(next openapi3.ReadFromURIFunc) openapi3.ReadFromURIFunc {
+	return func(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+		data, err := next(loader, location)
+		if err != nil || !Is(data) {
+			return data, err
+		}
+		return Convert(data)
+	}
+}
@@ -0,0 +1,47 @@
+package swagger
+
+import (
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	data := []byte(`
+swagger: "2.0"
+info: {title: Test, version: 1.0.0}
+paths:
+  /users:
+    get:
+      parameters:
+        - {in: query, name: limit, type: integer, maximum: 100}
+      responses:
+        '200': {description: OK, schema: {$ref: '#/definitions/User'}}
+definitions:
+  User:
+    type: object
+    properties:
+      name: {type: string, maxLength: 50}
+`)
+	require.True(t, Is(data))
+	converted, err := Convert(data)
+	require.NoError(t, err)
+	assert.False(t, Is(converted))
+
+	doc, err := openapi3.NewLoader().LoadFromData(converted)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(t.Context()))
+	user := doc.Components.Schemas["User"].Value
+	assert.Equal(t, uint64(50), *user.Properties["name"].Value.MaxLength)
+	op := doc.Paths.Find("/users").Get
+	assert.Equal(t, 100.0, *op.Parameters[0].Value.Schema.Value.Max)
+	assert.Equal(t, user, op.Responses.Status(200).Value.Content.Get("application/json").Schema.Value)
+}
+
+func TestIs(t *testing.T) {
+	assert.False(t, Is([]byte("openapi: 3.0.0\ninfo: {title: swagger, version: 1.0.0}\n")))
+	assert.False(t, Is([]byte(`{"swagger": "1.2"}`)))
+	assert.True(t, Is([]byte(`{"swagger": "2.0"}`)))
+}
@@ -26,3 +26,30 @@ func PreOrder[T any](roots iter.Seq[T], getChildren func(T) iter.Seq[T]) iter.Se
 		}
 	}
 }
+
+// PreOrderUnique is PreOrder visiting the nodes of a same key once, e.g. schemas by
+// pointer, skipping their children the next times: the walk of a graph with cycles, such
+// as a recursive schema, ends.
+func PreOrderUnique[T any, K comparable](roots iter.Seq[T], getChildren func(T) iter.Seq[T], key func(T) K) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		visited := make(map[K]bool)
+		unvisited := func(seq iter.Seq[T]) iter.Seq[T] {
+			return func(yield func(T) bool) {
+				for n := range seq {
+					if visited[key(n)] {
+						continue
+					}
+					visited[key(n)] = true
+					if !yield(n) {
+						return
+					}
+				}
+			}
+		}
+		for n := range PreOrder(unvisited(roots), func(n T) iter.Seq[T] { return unvisited(getChildren(n)) }) {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
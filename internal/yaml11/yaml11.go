@@ -0,0 +1,93 @@
+// Package yaml11 guards the YAML the tool writes against YAML 1.1 parsers, which resolve
+// plain scalars such as on, NO or 080 to booleans and numbers where YAML 1.2 resolves
+// strings: the country code of Norway would read false.
+package yaml11
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// resolved matches the plain scalars YAML 1.1 resolves to another type than a string:
+// bool, null, int, float, timestamp, merge and value, see https://yaml.org/type/. It is
+// defensive: any run of digits is an int, as some parsers read 080 as 80, and an exponent
+// makes a float.
+var resolved = regexp.MustCompile(`^(?:` +
+	`y|Y|yes|Yes|YES|n|N|no|No|NO|true|True|TRUE|false|False|FALSE|on|On|ON|off|Off|OFF` +
+	`|~|null|Null|NULL|` +
+	`|[-+]?0b[0-1_]+|[-+]?[0-9][0-9_]*|[-+]?0x[0-9a-fA-F_]+|[-+]?[0-9][0-9_]*(?::[0-5]?[0-9])+` +
+	`|[-+]?[0-9][0-9_]*\.[0-9_]*(?:[eE][-+]?[0-9]+)?|[-+]?\.[0-9][0-9_]*(?:[eE][-+]?[0-9]+)?|[-+]?[0-9][0-9_]*[eE][-+]?[0-9]+` +
+	`|[-+]?[0-9][0-9_]*(?::[0-5]?[0-9])+\.[0-9_]*|[-+]?\.(?:inf|Inf|INF)|\.(?:nan|NaN|NAN)` +
+	`|[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}(?:(?:[Tt]|[ \t]+)[0-9]{1,2}:[0-9]{2}:[0-9]{2}(?:\.[0-9]*)?(?:[ \t]*(?:Z|[-+][0-9]{1,2}(?::[0-9]{2})?))?)?` +
+	`|<<|=` +
+	`)$`)
+
+// Ambiguous reports whether YAML 1.1 resolves the plain scalar s to another type than a
+// string.
+func Ambiguous(s string) bool {
+	return resolved.MatchString(s)
+}
+
+// Quote double-quotes the plain string scalars of the tree of n, keys included, that
+// are Ambiguous. Other scalars keep their style.
+func Quote(n *yaml.Node) {
+	if n == nil {
+		return
+	}
+	if n.Kind == yaml.ScalarNode && n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 &&
+		n.ShortTag() == "!!str" && Ambiguous(n.Value) {
+		n.Style |= yaml.DoubleQuotedStyle
+	}
+	for _, c := range n.Content {
+		Quote(c)
+	}
+}
+
+// Encoder writes values as YAML documents with the defaults of yaml.v3, quoting the
+// Ambiguous strings.
+type Encoder struct {
+	dumper *yaml.Dumper
+}
+
+// NewEncoder returns an Encoder writing to w.
+func NewEncoder(w io.Writer) (*Encoder, error) {
+	d, err := yaml.NewDumper(w, yaml.WithV3Defaults())
+	if err != nil {
+		return nil, err
+	}
+	return &Encoder{dumper: d}, nil
+}
+
+// Encode writes v as a YAML document.
+func (e *Encoder) Encode(v any) error {
+	var n yaml.Node
+	if err := n.Encode(v); err != nil {
+		return err
+	}
+	Quote(&n)
+	return e.dumper.Dump(&n)
+}
+
+// Close flushes the documents written.
+func (e *Encoder) Close() error {
+	return e.dumper.Close()
+}
+
+// Marshal returns v as a YAML document, like an Encoder.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc, err := NewEncoder(&buf)
+	if err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
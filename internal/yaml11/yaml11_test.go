@@ -0,0 +1,58 @@
+package yaml11
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.yaml.in/yaml/v4"
+)
+
+var (
+	ambiguous = []string{"on", "Off", "no", "NO", "y", "N", "yes", "True", "~", "null", "", "080", "0777", "1_000", "0b101", "0x1F", "+12",
+		"1:20", "190:20:30.15", "1.", ".5", "12e3", "-.inf", ".NaN", "2001-12-14", "2001-12-14 21:59:43.10 -5", "<<", "="}
+	unambiguous = []string{"SE", "nope", "1.0.0", "v1", "12:ab", "e3", "hello world", "2001-12", "0x", "on-call"}
+)
+
+func TestAmbiguous(t *testing.T) {
+	for _, s := range ambiguous {
+		assert.True(t, Ambiguous(s), s)
+	}
+	for _, s := range unambiguous {
+		assert.False(t, Ambiguous(s), s)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	values := make(map[string]string)
+	for _, s := range append(ambiguous, unambiguous...) {
+		values[s] = s
+	}
+	var out strings.Builder
+	enc, err := NewEncoder(&out)
+	require.NoError(t, err)
+	require.NoError(t, enc.Encode(values))
+	require.NoError(t, enc.Close())
+
+	var got map[string]string
+	require.NoError(t, yaml.Unmarshal([]byte(out.String()), &got))
+	assert.Equal(t, values, got)
+	for line := range strings.Lines(out.String()) {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), ": ")
+		for _, scalar := range []string{key, value} {
+			if Ambiguous(strings.Trim(scalar, `"'`)) {
+				assert.Regexp(t, `^(".*"|'.*')$`, scalar, "plain in %q", line)
+			}
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	var n yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("country: NO\ncodes: [SE, on, '080', 42]\nversion: 1.0.0\n"), &n))
+	Quote(&n)
+	out, err := yaml.Marshal(&n)
+	require.NoError(t, err)
+	assert.Equal(t, "country: \"NO\"\ncodes: [SE, \"on\", '080', 42]\nversion: 1.0.0\n", string(out))
+}
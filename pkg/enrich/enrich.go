@@ -0,0 +1,280 @@
+// Package enrich injects validate tags, derived from the constraints of the schemas of an
+// OpenAPI spec, in the x-oapi-codegen-extra-tags extensions oapi-codegen turns into struct
+// tags.
+package enrich
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/celrules"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+	"github.com/hadrienk/oapi-codegen-validator/internal/tree"
+)
+
+const (
+	tagKey   = rules.TagKey
+	validate = "validate"
+)
+
+// Tag returns the validate tag injected in s, if any, as the validator reads it from the
+// struct tag.
+func Tag(s *openapi3.Schema) string {
+	ext, _ := s.Extensions[tagKey].(map[string]any)
+	tag, _ := ext[validate].(string)
+	return rules.UnquoteTag(tag)
+}
+
+// PropertyError locates an error of the enrichment: the JSON Pointer of the offending
+// keyword of a schema, typically a property, e.g.
+// /components/schemas/User/properties/name/pattern.
+type PropertyError = rules.PropertyError
+
+// SchemaContext is a schema of a spec with its name, e.g. "User.address" for a property,
+// and its JSON Pointer, e.g. "/components/schemas/User/properties/address".
+type SchemaContext struct {
+	Schema  *openapi3.Schema
+	Name    string
+	Pointer string
+}
+
+func toSchemaContext(schemas openapi3.Schemas) iter.Seq[SchemaContext] {
+	return func(yield func(SchemaContext) bool) {
+		for _, name := range slices.Sorted(maps.Keys(schemas)) {
+			ref := schemas[name]
+			if ref.Value != nil {
+				ref.Ref = "" // Force inline so modifications persist
+				ctx := SchemaContext{Schema: ref.Value, Name: name, Pointer: rules.Pointer("/components/schemas", name)}
+				if !yield(ctx) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Children yields the properties of the schema of ctx, by name, then its items, e.g.
+// "User.addresses[]", and the schema of its additional properties, e.g. "User.labels{}",
+// then its oneOf and anyOf variants, which oapi-codegen generates as types of their own,
+// e.g. "Pet.oneOf[0]".
+func Children(ctx SchemaContext) iter.Seq[SchemaContext] {
+	return func(yield func(SchemaContext) bool) {
+		for _, propName := range slices.Sorted(maps.Keys(ctx.Schema.Properties)) {
+			if propRef := ctx.Schema.Properties[propName]; propRef.Value != nil {
+				childCtx := SchemaContext{
+					Schema:  propRef.Value,
+					Name:    ctx.Name + "." + propName,
+					Pointer: rules.Pointer(ctx.Pointer, "properties", propName),
+				}
+				if !yield(childCtx) {
+					return
+				}
+			}
+		}
+		if items := ctx.Schema.Items; items != nil && items.Value != nil {
+			childCtx := SchemaContext{
+				Schema:  items.Value,
+				Name:    ctx.Name + "[]",
+				Pointer: rules.Pointer(ctx.Pointer, "items"),
+			}
+			if !yield(childCtx) {
+				return
+			}
+		}
+		if values := ctx.Schema.AdditionalProperties.Schema; values != nil && values.Value != nil {
+			childCtx := SchemaContext{
+				Schema:  values.Value,
+				Name:    ctx.Name + "{}",
+				Pointer: rules.Pointer(ctx.Pointer, "additionalProperties"),
+			}
+			if !yield(childCtx) {
+				return
+			}
+		}
+		for _, composition := range []struct {
+			keyword  string
+			variants openapi3.SchemaRefs
+		}{{"oneOf", ctx.Schema.OneOf}, {"anyOf", ctx.Schema.AnyOf}} {
+			for i, ref := range composition.variants {
+				if ref.Value == nil {
+					continue
+				}
+				childCtx := SchemaContext{
+					Schema:  ref.Value,
+					Name:    fmt.Sprintf("%s.%s[%d]", ctx.Name, composition.keyword, i),
+					Pointer: rules.Pointer(ctx.Pointer, composition.keyword, strconv.Itoa(i)),
+				}
+				if !yield(childCtx) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Spec injects the validate tags of the fields oapi-codegen generates from the component
+// schemas, the request bodies, responses and parameters of the operations and the
+// response headers of doc, as x-oapi-codegen-extra-tags extensions, merging them with the
+// tags already there. It enriches with the Default profile. The error joins a
+// *PropertyError per offending keyword of the spec.
+func Spec(doc *openapi3.T) error {
+	return Default.Spec(doc)
+}
+
+// Spec enriches doc like the Spec function, with the options of p.
+func (p Profile) Spec(doc *openapi3.T) error {
+	var schemas openapi3.Schemas
+	if doc.Components != nil {
+		schemas = doc.Components.Schemas
+	}
+	var err error
+	if p.Workers > 1 {
+		err = p.parallel(schemas)
+	} else {
+		err = newEnricher(p).components(schemas)
+	}
+	return errors.Join(err, newEnricher(p).paths(doc), newEnricher(p).parameters(doc), newEnricher(p).headers(doc))
+}
+
+// enricher enriches the schemas of a spec, converted once to the model of the rules.
+type enricher struct {
+	models  *schema.KinConverter
+	profile Profile
+	rules   rules.Options
+}
+
+func newEnricher(p Profile) *enricher {
+	return &enricher{
+		models:  schema.NewKinConverter(),
+		profile: p,
+		rules:   p.Options(),
+	}
+}
+
+// components enriches schemas and their properties, each schema once: shared schemas
+// reachable from several parents would otherwise be again, reporting their errors each
+// time, and recursive ones forever.
+func (e *enricher) components(schemas openapi3.Schemas) (errs error) {
+	for ctx := range tree.PreOrderUnique(toSchemaContext(schemas), Children, schemaOf) {
+		if err := e.node(ctx); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+// schemaOf returns the schema of ctx, which tells schemas reached through several paths
+// apart.
+func schemaOf(ctx SchemaContext) *openapi3.Schema {
+	return ctx.Schema
+}
+
+func (e *enricher) node(ctx SchemaContext) (errs error) {
+	// The CEL rules are evaluated by the middleware: they are compiled here so that a typo
+	// fails the generation rather than the requests.
+	if _, err := celrules.Compile(e.models.Convert(ctx.Schema)); err != nil {
+		errs = rules.Locate(ctx.Pointer, &rules.KeywordError{Keyword: celrules.Keyword, Err: err})
+	}
+	if e.profile.RejectUnknownFields {
+		closeObject(ctx.Schema)
+	}
+
+	// The properties required by some variants of a discriminated oneOf are required
+	// depending on the discriminator, and the dependent ones on the presence of others.
+	requiredIf, untranslated := rules.Conditions(e.models.Convert(ctx.Schema))
+	if err := rules.Locate(ctx.Pointer, untranslated); err != nil && e.profile.Warn != nil {
+		e.profile.Warn(err)
+	}
+	// We iterate the properties of the current schema to calculate and inject tags.
+	for _, propName := range slices.Sorted(maps.Keys(ctx.Schema.Properties)) {
+		propRef := ctx.Schema.Properties[propName]
+		if propRef.Value == nil {
+			continue
+		}
+
+		if err := e.field(propRef.Value, slices.Contains(ctx.Schema.Required, propName), requiredIf[propName]); err != nil {
+			errs = errors.Join(errs, rules.Locate(rules.Pointer(ctx.Pointer, "properties", propName), err))
+		}
+	}
+
+	return errs
+}
+
+// headers injects tags in the schemas of the response headers, which oapi-codegen turns
+// into the fields of the typed response headers structs.
+func (e *enricher) headers(doc *openapi3.T) (errs error) {
+	if doc.Components == nil {
+		return nil
+	}
+	enrich := func(pointer string, headers openapi3.Headers) {
+		for _, headerName := range slices.Sorted(maps.Keys(headers)) {
+			ref := headers[headerName]
+			if ref.Value == nil || ref.Value.Schema == nil || ref.Value.Schema.Value == nil {
+				continue
+			}
+			if err := e.field(ref.Value.Schema.Value, ref.Value.Required, nil); err != nil {
+				errs = errors.Join(errs, rules.Locate(rules.Pointer(pointer, headerName, "schema"), err))
+			}
+		}
+	}
+	enrich("/components/headers", doc.Components.Headers)
+	for _, respName := range slices.Sorted(maps.Keys(doc.Components.Responses)) {
+		if ref := doc.Components.Responses[respName]; ref.Value != nil {
+			enrich(rules.Pointer("/components/responses", respName, "headers"), ref.Value.Headers)
+		}
+	}
+	return errs
+}
+
+// field injects the validate tag of the struct field generated from s, also required
+// under the conditions of requiredIf, and its directional tags if the profile has them.
+func (e *enricher) field(s *openapi3.Schema, required bool, requiredIf []string) error {
+	m := e.models.Convert(s)
+	extMap, _ := s.Extensions[tagKey].(map[string]any)
+	tags := make(map[string]string)
+	var err error
+	tags[validate], err = e.rules.FieldRequiredIf(m, Tag(s), required, requiredIf)
+	if err != nil {
+		return err
+	}
+	if e.profile.Directional {
+		request, _ := extMap[rules.RequestTag].(string)
+		response, _ := extMap[rules.ResponseTag].(string)
+		tags[rules.RequestTag], tags[rules.ResponseTag], err = e.rules.Directional(m, rules.UnquoteTag(request), rules.UnquoteTag(response), required, requiredIf)
+		if err != nil {
+			return err
+		}
+	}
+	if tags[validate] == "" && tags[rules.RequestTag] == "" && tags[rules.ResponseTag] == "" {
+		delete(s.Extensions, tagKey)
+		return nil
+	}
+
+	if s.Extensions == nil {
+		s.Extensions = make(map[string]any)
+	}
+	if extMap == nil {
+		extMap = make(map[string]any)
+	}
+	for key, tag := range tags {
+		if tag == "" {
+			delete(extMap, key)
+		} else {
+			extMap[key] = rules.QuoteTag(tag)
+		}
+	}
+	s.Extensions[tagKey] = extMap
+	return nil
+}
+
+// Rules returns the validate rules generated from the constraints of s, without the
+// leading required or omitempty.
+func Rules(s *openapi3.Schema) ([]string, error) {
+	return rules.Generate(schema.FromKin(s))
+}
@@ -0,0 +1,492 @@
+package enrich
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-playground/validator/v10"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+	"github.com/hadrienk/oapi-codegen-validator/internal/tree"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecSharedSchemas(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Address:
+      type: object
+      properties:
+        zip:
+          type: string
+          maxLength: 5
+          x-oapi-codegen-extra-tags: {validate: max=10}
+    Home: {type: object, properties: {address: {$ref: '#/components/schemas/Address'}}}
+    Office: {type: object, properties: {address: {$ref: '#/components/schemas/Address'}}}
+    Node:
+      type: object
+      properties:
+        name: {type: string, maxLength: 10}
+        next: {$ref: '#/components/schemas/Node'}
+`))
+	require.NoError(t, err)
+
+	err = Spec(doc)
+	require.Error(t, err)
+	assert.Equal(t, 1, strings.Count(err.Error(), "conflict"), "the shared Address must be reported once: %v", err)
+	assert.Equal(t, "omitempty,max=10", Tag(doc.Components.Schemas["Node"].Value.Properties["name"].Value))
+}
+
+func TestSpecErrors(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Order:
+      type: object
+      properties:
+        quantity: {type: integer, multipleOf: 5, maximum: 100, x-oapi-codegen-extra-tags: {validate: max=10}}
+        code: {type: string, pattern: '(?=x)'}
+        address:
+          type: object
+          properties:
+            zip: {type: string, pattern: '(?!x)'}
+        tags: {type: array, items: {type: string, pattern: '(?<=x)'}}
+`))
+	require.NoError(t, err)
+
+	err = Spec(doc)
+	var got []string
+	var walk func(error)
+	walk = func(err error) {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range joined.Unwrap() {
+				walk(err)
+			}
+			return
+		}
+		var pe *PropertyError
+		require.True(t, errors.As(err, &pe), "not located: %v", err)
+		got = append(got, pe.Keyword+" "+pe.Pointer)
+	}
+	walk(err)
+	assert.Equal(t, []string{
+		"pattern /components/schemas/Order/properties/code/pattern",
+		"x-oapi-codegen-extra-tags /components/schemas/Order/properties/quantity/x-oapi-codegen-extra-tags",
+		"pattern /components/schemas/Order/properties/tags/items/pattern",
+		"pattern /components/schemas/Order/properties/address/properties/zip/pattern",
+	}, got)
+}
+
+func TestSpecAllOf(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Name: {type: string, minLength: 1, maxLength: 100}
+    User:
+      type: object
+      properties:
+        name:
+          allOf: [{$ref: '#/components/schemas/Name'}, {maxLength: 50}]
+        nickname:
+          allOf: [{$ref: '#/components/schemas/Name'}, {pattern: '^[a-z]+$'}]
+        code:
+          allOf: [{$ref: '#/components/schemas/Name'}, {type: integer}]
+`))
+	require.NoError(t, err)
+
+	err = Spec(doc)
+	var pe *PropertyError
+	require.True(t, errors.As(err, &pe), "not located: %v", err)
+	assert.Equal(t, "/components/schemas/User/properties/code/allOf/1/type", pe.Pointer)
+	user := doc.Components.Schemas["User"].Value
+	assert.Equal(t, "omitempty,min=1,max=50", Tag(user.Properties["name"].Value), "the tighter maxLength applies")
+	assert.Equal(t, "omitempty,regex=^[a-z]+$,min=1,max=100", Tag(user.Properties["nickname"].Value))
+}
+
+func TestSpecVariants(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Pet:
+      oneOf:
+        - type: object
+          properties:
+            bark: {type: string, maxLength: 10}
+        - type: object
+          properties:
+            meow: {type: string, pattern: '(?=x)'}
+      anyOf:
+        - type: object
+          properties:
+            name: {type: string, minLength: 1}
+`))
+	require.NoError(t, err)
+
+	err = Spec(doc)
+	var pe *PropertyError
+	require.True(t, errors.As(err, &pe), "not located: %v", err)
+	assert.Equal(t, "/components/schemas/Pet/oneOf/1/properties/meow/pattern", pe.Pointer)
+	pet := doc.Components.Schemas["Pet"].Value
+	assert.Equal(t, "omitempty,max=10", Tag(pet.OneOf[0].Value.Properties["bark"].Value))
+	assert.Equal(t, "omitempty,min=1", Tag(pet.AnyOf[0].Value.Properties["name"].Value))
+
+	var names []string
+	for ctx := range Children(SchemaContext{Schema: pet, Name: "Pet"}) {
+		names = append(names, ctx.Name)
+	}
+	assert.Equal(t, []string{"Pet.oneOf[0]", "Pet.oneOf[1]", "Pet.anyOf[0]"}, names)
+}
+
+func TestSpecArrayItems(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        addresses:
+          type: array
+          items:
+            type: object
+            required: [zip]
+            properties:
+              zip: {type: string, pattern: '^[0-9]{5}$'}
+              lines: {type: array, items: {type: array, items: {type: object, properties: {text: {type: string, maxLength: 80}}}}}
+        contacts:
+          type: array
+          items: {$ref: '#/components/schemas/Contact'}
+    Contact:
+      type: object
+      properties:
+        email: {type: string, format: email}
+`))
+	require.NoError(t, err)
+
+	require.NoError(t, Spec(doc))
+	user := doc.Components.Schemas["User"].Value
+	assert.Equal(t, "omitempty,dive", Tag(user.Properties["addresses"].Value))
+	assert.Equal(t, "omitempty,dive", Tag(user.Properties["contacts"].Value))
+	address := user.Properties["addresses"].Value.Items.Value
+	assert.Equal(t, `required,regex=^[0-9]{5}$`, Tag(address.Properties["zip"].Value))
+	assert.Equal(t, "omitempty,dive,dive", Tag(address.Properties["lines"].Value))
+	line := address.Properties["lines"].Value.Items.Value.Items.Value
+	assert.Equal(t, "omitempty,max=80", Tag(line.Properties["text"].Value))
+
+	// The structs oapi-codegen generates, with the tags above.
+	type Line struct {
+		Text *string `validate:"omitempty,max=80"`
+	}
+	type Address struct {
+		Zip   string    `validate:"required,regex=^[0-9]{5}$"`
+		Lines *[][]Line `validate:"omitempty,dive,dive"`
+	}
+	type User struct {
+		Addresses *[]Address `validate:"omitempty,dive"`
+	}
+	validate := validator.New()
+	require.NoError(t, validate.RegisterValidation("regex", func(fl validator.FieldLevel) bool {
+		return regexp.MustCompile(fl.Param()).MatchString(fl.Field().String())
+	}))
+	long := strings.Repeat("x", 81)
+	assert.NoError(t, validate.Struct(User{Addresses: &[]Address{{Zip: "12345"}}}))
+	var errs validator.ValidationErrors
+	require.ErrorAs(t, validate.Struct(User{Addresses: &[]Address{{Zip: "12345"}, {Zip: "abc"}}}), &errs)
+	assert.Equal(t, "User.Addresses[1].Zip", errs[0].Namespace())
+	require.ErrorAs(t, validate.Struct(User{Addresses: &[]Address{{Zip: "12345", Lines: &[][]Line{{{Text: &long}}}}}}), &errs)
+	assert.Equal(t, "User.Addresses[0].Lines[0][0].Text", errs[0].Namespace())
+
+	var names, pointers []string
+	for ctx := range tree.PreOrderUnique(toSchemaContext(doc.Components.Schemas), Children, schemaOf) {
+		names, pointers = append(names, ctx.Name), append(pointers, ctx.Pointer)
+	}
+	assert.Contains(t, names, "User.addresses[].lines[][].text")
+	assert.Contains(t, pointers, "/components/schemas/User/properties/addresses/items/properties/zip")
+}
+
+func TestSpecAdditionalProperties(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Inventory:
+      type: object
+      properties:
+        stock:
+          type: object
+          additionalProperties:
+            type: object
+            required: [count]
+            properties:
+              count: {type: integer, minimum: 1}
+              sku: {type: string, maxLength: 12}
+    Price:
+      type: object
+      properties:
+        amount: {type: number}
+    Prices:
+      type: object
+      additionalProperties: {$ref: '#/components/schemas/Price'}
+`))
+	require.NoError(t, err)
+
+	require.NoError(t, Spec(doc))
+	stock := doc.Components.Schemas["Inventory"].Value.Properties["stock"].Value
+	assert.Equal(t, "omitempty,dive", Tag(stock))
+	item := stock.AdditionalProperties.Schema.Value
+	assert.Equal(t, "required,gte=1", Tag(item.Properties["count"].Value))
+	assert.Equal(t, "omitempty,max=12", Tag(item.Properties["sku"].Value))
+
+	// The structs oapi-codegen generates, with the tags above.
+	type Item struct {
+		Count int     `validate:"required,gte=1"`
+		Sku   *string `validate:"omitempty,max=12"`
+	}
+	type Inventory struct {
+		Stock *map[string]Item `validate:"omitempty,dive"`
+	}
+	assert.NoError(t, validator.New().Struct(Inventory{Stock: &map[string]Item{"apple": {Count: 3}}}))
+	var errs validator.ValidationErrors
+	require.ErrorAs(t, validator.New().Struct(Inventory{Stock: &map[string]Item{"apple": {Count: 3}, "pear": {Count: 0}}}), &errs)
+	assert.Equal(t, "Inventory.Stock[pear].Count", errs[0].Namespace())
+
+	var names []string
+	for ctx := range Children(SchemaContext{Schema: doc.Components.Schemas["Prices"].Value, Name: "Prices"}) {
+		names = append(names, ctx.Name)
+	}
+	assert.Equal(t, []string{"Prices{}"}, names)
+
+	groups := independent(doc.Components.Schemas)
+	assert.Len(t, groups, 2, "Prices reaches Price through its additional properties")
+}
+
+func TestSpecRecursive(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Category:
+      type: object
+      properties:
+        name: {type: string, maxLength: 20}
+        children:
+          type: array
+          items: {$ref: '#/components/schemas/Category'}
+        parent:
+          allOf: [{$ref: '#/components/schemas/Category'}]
+`))
+	require.NoError(t, err)
+
+	require.NoError(t, Spec(doc))
+	category := doc.Components.Schemas["Category"].Value
+	assert.Equal(t, "omitempty,max=20", Tag(category.Properties["name"].Value))
+
+	var names []string
+	for ctx := range tree.PreOrderUnique(toSchemaContext(doc.Components.Schemas), Children, schemaOf) {
+		names = append(names, ctx.Name)
+	}
+	assert.Equal(t, []string{"Category", "Category.children", "Category.name", "Category.parent"}, names)
+}
+
+func TestSpecOperations(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /users:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                code: {type: string, pattern: '(?=x)'}
+                address: {$ref: '#/components/schemas/Address'}
+      responses:
+        "200": {description: OK}
+components:
+  schemas:
+    Address:
+      type: object
+      properties:
+        zip: {type: string, pattern: '(?=y)'}
+`))
+	require.NoError(t, err)
+
+	err = Spec(doc)
+	var pointers []string
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var pe *PropertyError
+		require.ErrorAs(t, err, &pe)
+		pointers = append(pointers, pe.Pointer)
+	}
+	// The component reached from the request body is enriched, and reported, once.
+	assert.ElementsMatch(t, []string{
+		"/components/schemas/Address/properties/zip/pattern",
+		"/paths/~1users/post/requestBody/content/application~1json/schema/properties/code/pattern",
+	}, pointers)
+
+	var names []string
+	for ctx := range operationSchemas(doc) {
+		names = append(names, ctx.Name)
+	}
+	assert.Equal(t, []string{"POST /users.requestBody"}, names)
+}
+
+func TestSpecParameters(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /users:
+    get:
+      parameters:
+        - {name: page, in: query, schema: {type: integer, minimum: 1}}
+        - {name: code, in: query, schema: {type: string, pattern: '(?=x)'}}
+      responses:
+        "200": {description: OK}
+`))
+	require.NoError(t, err)
+
+	err = Spec(doc)
+	var pe *PropertyError
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, "/paths/~1users/get/parameters/1/schema/pattern", pe.Pointer)
+	page := doc.Paths.Value("/users").Get.Parameters[0].Value
+	assert.Equal(t, map[string]any{"validate": "omitempty,gte=1"}, page.Extensions[tagKey])
+	assert.Empty(t, Tag(page.Schema.Value), "the tag is set on the parameter, not its schema")
+}
+
+func TestSpecDiscriminator(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [petType]
+      properties:
+        petType: {type: string}
+        bark: {type: string, maxLength: 5}
+        meow: {type: string}
+      oneOf:
+        - $ref: '#/components/schemas/Dog'
+        - $ref: '#/components/schemas/Cat'
+      discriminator:
+        propertyName: petType
+        mapping: {dog: '#/components/schemas/Dog'}
+    Dog:
+      type: object
+      required: [petType, bark]
+      properties:
+        petType: {type: string}
+        bark: {type: string}
+    Cat:
+      type: object
+      required: [petType, meow]
+      properties:
+        petType: {type: string}
+        meow: {type: string}
+`))
+	require.NoError(t, err)
+
+	require.NoError(t, Spec(doc))
+	pet := doc.Components.Schemas["Pet"].Value
+	assert.Equal(t, "required_if=PetType dog,omitempty,max=5", Tag(pet.Properties["bark"].Value))
+	assert.Equal(t, "required_if=PetType Cat", Tag(pet.Properties["meow"].Value))
+	assert.Equal(t, "required", Tag(doc.Components.Schemas["Dog"].Value.Properties["bark"].Value))
+}
+
+func TestSpecDependentRequired(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.1.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Payment:
+      type: object
+      properties:
+        creditCard: {type: string}
+        billingAddress: {type: string, maxLength: 100}
+        cvc: {type: string}
+      dependentRequired:
+        creditCard: [billingAddress]
+      allOf:
+        - dependentRequired:
+            creditCard: [cvc]
+`))
+	require.NoError(t, err)
+
+	require.NoError(t, Spec(doc))
+	payment := doc.Components.Schemas["Payment"].Value
+	assert.Equal(t, "required_with=CreditCard,omitempty,max=100", Tag(payment.Properties["billingAddress"].Value))
+	assert.Equal(t, "required_with=CreditCard", Tag(payment.Properties["cvc"].Value))
+	assert.Empty(t, Tag(payment.Properties["creditCard"].Value))
+}
+
+func TestSpecIfThenElse(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.1.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Account:
+      type: object
+      properties:
+        kind: {type: string}
+        vatNumber: {type: string, maxLength: 20}
+        birthDate: {type: string}
+      if: {required: [kind], properties: {kind: {const: business}}}
+      then: {required: [vatNumber]}
+      else: {required: [birthDate]}
+    Shipment:
+      type: object
+      properties:
+        weight: {type: number}
+        carrier: {type: string}
+      if: {properties: {weight: {minimum: 30}}}
+      then: {required: [carrier]}
+`))
+	require.NoError(t, err)
+
+	var warnings []error
+	profile := Default
+	profile.Warn = func(err error) { warnings = append(warnings, err) }
+	require.NoError(t, profile.Spec(doc))
+	account := doc.Components.Schemas["Account"].Value
+	assert.Equal(t, "required_if=Kind business,omitempty,max=20", Tag(account.Properties["vatNumber"].Value))
+	assert.Equal(t, "required_unless=Kind business", Tag(account.Properties["birthDate"].Value))
+	// The condition of Shipment is not translated, which is not a failure.
+	assert.Empty(t, Tag(doc.Components.Schemas["Shipment"].Value.Properties["carrier"].Value))
+	require.Len(t, warnings, 1)
+	var located *rules.PropertyError
+	require.ErrorAs(t, warnings[0], &located)
+	assert.Equal(t, "/components/schemas/Shipment/if", located.Pointer)
+}
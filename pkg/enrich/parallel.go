@@ -0,0 +1,93 @@
+package enrich
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// parallel enriches the component schemas of doc with p.Workers goroutines. Components
+// reaching a same schema, e.g. through a shared $ref, are enriched by the same goroutine,
+// in turn, since their tags are merged in place.
+func (p Profile) parallel(schemas openapi3.Schemas) error {
+	groups := independent(schemas)
+	errs := make([]error, len(groups))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(p.Workers, len(groups)) {
+		wg.Go(func() {
+			for i := range next {
+				errs[i] = newEnricher(p).components(groups[i])
+			}
+		})
+	}
+	for i := range groups {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// independent partitions schemas into groups whose schemas, and those they reach through
+// their properties, items, additional properties, not and allOf, oneOf and anyOf members,
+// are not shared with another group.
+func independent(schemas openapi3.Schemas) []openapi3.Schemas {
+	names := slices.Sorted(maps.Keys(schemas))
+	parent := make([]int, len(names))
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	owners := make(map[*openapi3.Schema]int)
+	for i, name := range names {
+		parent[i] = i
+		var walk func(ref *openapi3.SchemaRef)
+		walk = func(ref *openapi3.SchemaRef) {
+			if ref == nil || ref.Value == nil {
+				return
+			}
+			owner, ok := owners[ref.Value]
+			if ok {
+				// Reached from a previous component, or visited already.
+				parent[find(owner)] = find(i)
+				return
+			}
+			owners[ref.Value] = i
+			// Every schema the model of ref.Value is converted from, the merged allOf
+			// members and the not included: the 3.1 keywords kept in its extensions are
+			// decoded from values of its own, which no other schema shares.
+			walk(ref.Value.Items)
+			walk(ref.Value.AdditionalProperties.Schema)
+			walk(ref.Value.Not)
+			for _, prop := range ref.Value.Properties {
+				walk(prop)
+			}
+			for _, member := range slices.Concat(ref.Value.AllOf, ref.Value.OneOf, ref.Value.AnyOf) {
+				walk(member)
+			}
+		}
+		walk(schemas[name])
+	}
+
+	var groups []openapi3.Schemas
+	index := make(map[int]int)
+	for i, name := range names {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, make(openapi3.Schemas))
+		}
+		groups[g][name] = schemas[name]
+	}
+	return groups
+}
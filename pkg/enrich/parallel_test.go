@@ -0,0 +1,89 @@
+package enrich
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallel(t *testing.T) {
+	var spec strings.Builder
+	spec.WriteString(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Name: {type: string, maxLength: 50}
+`)
+	for i := range 50 {
+		fmt.Fprintf(&spec, `    Model%d:
+      type: object
+      required: [name]
+      properties:
+        name: {$ref: '#/components/schemas/Name'}
+        code: {type: string, minLength: %d}
+`, i, i+1)
+		fmt.Fprintf(&spec, `    Other%d:
+      type: object
+      properties:
+        tags: {type: array, maxItems: %d, items: {type: string}}
+`, i, i+1)
+	}
+	load := func() *openapi3.T {
+		doc, err := openapi3.NewLoader().LoadFromData([]byte(spec.String()))
+		require.NoError(t, err)
+		return doc
+	}
+
+	groups := independent(load().Components.Schemas)
+	assert.Len(t, groups, 51, "the models sharing Name must be grouped")
+
+	sequential, parallel := load(), load()
+	require.NoError(t, Spec(sequential))
+	require.NoError(t, Profile{Workers: 8}.Spec(parallel))
+	want, err := sequential.MarshalJSON()
+	require.NoError(t, err)
+	got, err := parallel.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got))
+}
+
+// TestParallelAllOf is meant for go test -race: the allOf members are read by the
+// components merging them while the component declaring them is enriched.
+func TestParallelAllOf(t *testing.T) {
+	var spec strings.Builder
+	spec.WriteString(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Base:
+      type: object
+      properties:
+        id: {type: string, maxLength: 10}
+    Excluded:
+      type: object
+      properties:
+        code: {type: string, minLength: 2}
+`)
+	for i := range 50 {
+		fmt.Fprintf(&spec, `    Model%d:
+      type: object
+      properties:
+        base: {allOf: [{$ref: '#/components/schemas/Base'}]}
+        other: {not: {$ref: '#/components/schemas/Excluded'}}
+`, i)
+	}
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec.String()))
+	require.NoError(t, err)
+
+	assert.Len(t, independent(doc.Components.Schemas), 1, "the models reach Base through allOf and Excluded through not")
+	require.NoError(t, Profile{Workers: 8}.Spec(doc))
+	assert.Equal(t, "omitempty,max=10", Tag(doc.Components.Schemas["Base"].Value.Properties["id"].Value))
+}
@@ -0,0 +1,166 @@
+package enrich
+
+import (
+	"errors"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+	"github.com/hadrienk/oapi-codegen-validator/internal/tree"
+)
+
+// operation is an operation of a spec with its name, its operationId or else its method
+// and path, e.g. "POST /users", and its JSON Pointer, e.g. "/paths/~1users/post".
+type operation struct {
+	*openapi3.Operation
+	Name    string
+	Pointer string
+}
+
+// operations yields the operations of doc, by path then method.
+func operations(doc *openapi3.T) iter.Seq[operation] {
+	return func(yield func(operation) bool) {
+		if doc.Paths == nil {
+			return
+		}
+		paths := doc.Paths.Map()
+		for _, path := range slices.Sorted(maps.Keys(paths)) {
+			ops := paths[path].Operations()
+			for _, method := range slices.Sorted(maps.Keys(ops)) {
+				op := operation{Operation: ops[method], Name: operationName(method, path, ops[method]), Pointer: rules.Pointer("/paths", path, strings.ToLower(method))}
+				if !yield(op) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func operationName(method, path string, op *openapi3.Operation) string {
+	if op.OperationID != "" {
+		return op.OperationID
+	}
+	return method + " " + path
+}
+
+// operationSchemas yields the schemas of the request bodies and responses of the
+// operations of doc, by media type, e.g. "createUser.requestBody" or
+// "createUser.responses.201", which oapi-codegen generates types from.
+func operationSchemas(doc *openapi3.T) iter.Seq[SchemaContext] {
+	return func(yield func(SchemaContext) bool) {
+		content := func(name, pointer string, content openapi3.Content) bool {
+			for _, mediaType := range slices.Sorted(maps.Keys(content)) {
+				if mt := content[mediaType]; mt != nil && mt.Schema != nil && mt.Schema.Value != nil {
+					ctx := SchemaContext{Schema: mt.Schema.Value, Name: name, Pointer: rules.Pointer(pointer, "content", mediaType, "schema")}
+					if !yield(ctx) {
+						return false
+					}
+				}
+			}
+			return true
+		}
+		for op := range operations(doc) {
+			if body := op.RequestBody; body != nil && body.Value != nil {
+				if !content(op.Name+".requestBody", rules.Pointer(op.Pointer, "requestBody"), body.Value.Content) {
+					return
+				}
+			}
+			if op.Responses == nil {
+				continue
+			}
+			responses := op.Responses.Map()
+			for _, status := range slices.Sorted(maps.Keys(responses)) {
+				if resp := responses[status]; resp.Value != nil {
+					if !content(op.Name+".responses."+status, rules.Pointer(op.Pointer, "responses", status), resp.Value.Content) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// paths enriches the schemas of the request bodies and responses of the operations of
+// doc, declared inline, and the schemas they reach, each once. Those the component
+// schemas reach are left to components.
+func (e *enricher) paths(doc *openapi3.T) (errs error) {
+	enriched := make(map[*openapi3.Schema]bool)
+	if doc.Components != nil {
+		for ctx := range tree.PreOrderUnique(toSchemaContext(doc.Components.Schemas), Children, schemaOf) {
+			enriched[ctx.Schema] = true
+		}
+	}
+	for ctx := range tree.PreOrderUnique(operationSchemas(doc), Children, schemaOf) {
+		if enriched[ctx.Schema] {
+			continue
+		}
+		if err := e.node(ctx); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+// parameters injects the validate tags of the fields oapi-codegen generates from the
+// parameters of doc, in the Params structs of their operations: those of the components,
+// then those declared inline by the paths and operations. The tags are set in the
+// extensions of the parameters, where oapi-codegen reads them, rather than of their
+// schemas. The strict middleware only validates the Body of the request objects, not
+// their Params: the tags are for the validator Params are passed to explicitly.
+func (e *enricher) parameters(doc *openapi3.T) (errs error) {
+	enrich := func(pointer string, p *openapi3.ParameterRef) {
+		if p == nil || p.Ref != "" || p.Value == nil || p.Value.Schema == nil || p.Value.Schema.Value == nil {
+			return
+		}
+		if err := e.parameter(p.Value); err != nil {
+			errs = errors.Join(errs, rules.Locate(rules.Pointer(pointer, "schema"), err))
+		}
+	}
+	if doc.Components != nil {
+		for _, name := range slices.Sorted(maps.Keys(doc.Components.Parameters)) {
+			enrich(rules.Pointer("/components/parameters", name), doc.Components.Parameters[name])
+		}
+	}
+	if doc.Paths == nil {
+		return errs
+	}
+	paths := doc.Paths.Map()
+	for _, path := range slices.Sorted(maps.Keys(paths)) {
+		for i, p := range paths[path].Parameters {
+			enrich(rules.Pointer("/paths", path, "parameters", strconv.Itoa(i)), p)
+		}
+	}
+	for op := range operations(doc) {
+		for i, p := range op.Parameters {
+			enrich(rules.Pointer(op.Pointer, "parameters", strconv.Itoa(i)), p)
+		}
+	}
+	return errs
+}
+
+// parameter injects the validate tag of the field generated from p in its extensions.
+func (e *enricher) parameter(p *openapi3.Parameter) error {
+	extMap, _ := p.Extensions[tagKey].(map[string]any)
+	existing, _ := extMap[validate].(string)
+	tag, err := e.rules.Field(e.models.Convert(p.Schema.Value), rules.UnquoteTag(existing), p.Required)
+	if err != nil {
+		return err
+	}
+	if tag == "" {
+		delete(p.Extensions, tagKey)
+		return nil
+	}
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]any)
+	}
+	if extMap == nil {
+		extMap = make(map[string]any)
+	}
+	extMap[validate] = rules.QuoteTag(tag)
+	p.Extensions[tagKey] = extMap
+	return nil
+}
@@ -0,0 +1,140 @@
+package enrich
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+	"gopkg.in/yaml.v3"
+)
+
+// Profile bundles the choices of an enrichment, so that adopters pick a preset rather
+// than each option.
+type Profile struct {
+	Name string
+	// OptionalNullable leads the tags of required nullable fields with omitempty rather
+	// than required, accepting an explicit null.
+	OptionalNullable bool
+	// SkipUnsupported ignores the keywords no rule can check, such as patterns that are
+	// not RE2, rather than failing.
+	SkipUnsupported bool
+	// Formats are the validate rules of the formats, the default ones when nil.
+	Formats map[string]string
+	// AnchorPatterns makes the patterns match whole values, see rules.Options. Schemas
+	// opt out with x-pattern-anchor: false.
+	AnchorPatterns bool
+	// OmitNil leads the tags of optional nullable fields with omitnil rather than
+	// omitempty, checking their explicit empty values, see rules.Options.
+	OmitNil bool
+	// Directional adds tags validating requests and responses, rules.RequestTag and
+	// rules.ResponseTag, to the validate tag, for middleware.WithDirectionalTags: readOnly
+	// fields are optional in requests and writeOnly fields unchecked in responses.
+	Directional bool
+	// RejectUnknownFields sets additionalProperties: false on the object schemas that do
+	// not declare it, so that middleware.WithUnknownFieldRejection rejects the properties
+	// they do not declare.
+	RejectUnknownFields bool
+	// Workers is the number of goroutines enriching the component schemas, sequentially
+	// when below 2. Components sharing schemas are enriched by the same goroutine.
+	Workers int
+	// Warn, when set, is called with the *rules.PropertyError of every keyword translated
+	// on a best-effort basis that no rule expresses, such as an if/then/else condition,
+	// the enrichment going on. It is called concurrently when Workers is above 1.
+	Warn func(error)
+}
+
+var (
+	// Default is the profile of Spec: required fields are required, unsupported keywords
+	// fail and unknown fields are allowed.
+	Default = Profile{Name: "default"}
+
+	// StrictInternal suits services called by trusted clients, which should be told of
+	// any mistake: unsupported keywords fail and unknown fields are rejected.
+	StrictInternal = Profile{
+		Name:                "strict-internal",
+		RejectUnknownFields: true,
+	}
+
+	// LenientPublic suits public APIs, whose clients should not break on details: null
+	// is accepted for required nullable fields, unsupported keywords are ignored and
+	// uri formats, often holding relative references, are not checked.
+	LenientPublic = Profile{
+		Name:             "lenient-public",
+		OptionalNullable: true,
+		SkipUnsupported:  true,
+		Formats: map[string]string{
+			"email": "email",
+			"uuid":  "uuid",
+			"ipv4":  "ipv4",
+			"ipv6":  "ipv6",
+		},
+	}
+)
+
+// Profiles are the presets, by name.
+var Profiles = map[string]Profile{
+	Default.Name:        Default,
+	StrictInternal.Name: StrictInternal,
+	LenientPublic.Name:  LenientPublic,
+}
+
+// WithFormats returns p checking the formats of extra with their validate rules, e.g.
+// "ksuid" or "regex=^E[0-9]{6}$", in addition to its own formats. An empty rule leaves a
+// format unchecked.
+func (p Profile) WithFormats(extra map[string]string) Profile {
+	formats := p.Formats
+	if formats == nil {
+		formats = rules.Formats
+	}
+	p.Formats = maps.Clone(formats)
+	maps.Copy(p.Formats, extra)
+	return p
+}
+
+// LoadFormats reads a YAML file mapping formats to their validate rules, for
+// Profile.WithFormats:
+//
+//	ksuid: ksuid
+//	employee-id: regex=^E[0-9]{6}$
+//	uri: ""
+func LoadFormats(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var formats map[string]string
+	if err := yaml.Unmarshal(data, &formats); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for format, rule := range formats {
+		if strings.HasPrefix(rule, ",") || strings.HasSuffix(rule, ",") || strings.Contains(rule, ",,") {
+			return nil, fmt.Errorf("%s: format %s: empty rule in %q", path, format, rule)
+		}
+	}
+	return formats, nil
+}
+
+// Options returns the options of the rules generated with p.
+func (p Profile) Options() rules.Options {
+	return rules.Options{
+		OptionalNullable: p.OptionalNullable,
+		SkipUnsupported:  p.SkipUnsupported,
+		Formats:          p.Formats,
+		AnchorPatterns:   p.AnchorPatterns,
+		OmitNil:          p.OmitNil,
+	}
+}
+
+// closeObject sets additionalProperties: false on s when it is an object schema declaring
+// properties but not additionalProperties.
+func closeObject(s *openapi3.Schema) {
+	if !s.Type.Is(openapi3.TypeObject) || len(s.Properties) == 0 ||
+		s.AdditionalProperties.Has != nil || s.AdditionalProperties.Schema != nil {
+		return
+	}
+	closed := false
+	s.AdditionalProperties.Has = &closed
+}
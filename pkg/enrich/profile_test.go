@@ -0,0 +1,155 @@
+package enrich
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const spec = `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    User:
+      type: object
+      required: [nickname]
+      properties:
+        nickname: {type: string, nullable: true, maxLength: 20}
+        homepage: {type: string, format: uri}
+        host: {type: string, format: hostname}
+`
+
+func TestProfiles(t *testing.T) {
+	tests := []struct {
+		profile Profile
+		tags    map[string]string
+		closed  bool
+	}{
+		{
+			profile: Default,
+			tags:    map[string]string{"nickname": "required,max=20", "homepage": "omitempty,url", "host": "omitempty,hostname_rfc1123"},
+		},
+		{
+			profile: StrictInternal,
+			tags:    map[string]string{"nickname": "required,max=20", "homepage": "omitempty,url", "host": "omitempty,hostname_rfc1123"},
+			closed:  true,
+		},
+		{
+			profile: LenientPublic,
+			tags:    map[string]string{"nickname": "omitempty,max=20"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.profile.Name, func(t *testing.T) {
+			doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+			require.NoError(t, err)
+			require.NoError(t, tt.profile.Spec(doc))
+
+			user := doc.Components.Schemas["User"].Value
+			tags := make(map[string]string)
+			for name, prop := range user.Properties {
+				if tag := Tag(prop.Value); tag != "" {
+					tags[name] = tag
+				}
+			}
+			assert.Equal(t, tt.tags, tags)
+			assert.Equal(t, tt.closed, user.AdditionalProperties.Has != nil && !*user.AdditionalProperties.Has)
+		})
+	}
+}
+
+func TestProfileUnsupported(t *testing.T) {
+	load := func() *openapi3.T {
+		doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Price:
+      type: object
+      properties:
+        cents: {type: integer, minimum: 0, multipleOf: 5}
+        currency: {type: string, pattern: '^(?!XXX)[A-Z]{3}$'}
+`))
+		require.NoError(t, err)
+		return doc
+	}
+
+	assert.ErrorContains(t, StrictInternal.Spec(load()), "is not a valid Go RE2 regex")
+
+	doc := load()
+	require.NoError(t, LenientPublic.Spec(doc))
+	price := doc.Components.Schemas["Price"].Value
+	assert.Equal(t, "omitempty,gte=0,multipleof=5", Tag(price.Properties["cents"].Value))
+	assert.Equal(t, "", Tag(price.Properties["currency"].Value))
+}
+
+func TestWithFormats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "formats.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+ksuid: ksuid
+employee-id: regex=^E[0-9]{6}$
+uri: ""
+`), 0644))
+	formats, err := LoadFormats(path)
+	require.NoError(t, err)
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Employee:
+      type: object
+      properties:
+        id: {type: string, format: employee-id}
+        ref: {type: string, format: ksuid}
+        homepage: {type: string, format: uri}
+        email: {type: string, format: email}
+`))
+	require.NoError(t, err)
+	require.NoError(t, Default.WithFormats(formats).Spec(doc))
+
+	props := doc.Components.Schemas["Employee"].Value.Properties
+	assert.Equal(t, "omitempty,regex=^E[0-9]{6}$", Tag(props["id"].Value))
+	assert.Equal(t, "omitempty,ksuid", Tag(props["ref"].Value))
+	assert.Equal(t, "", Tag(props["homepage"].Value))
+	assert.Equal(t, "omitempty,email", Tag(props["email"].Value))
+	assert.Nil(t, Default.Formats, "the profile must not be modified")
+
+	require.NoError(t, os.WriteFile(path, []byte("ksuid: 'ksuid,'\n"), 0644))
+	_, err = LoadFormats(path)
+	assert.ErrorContains(t, err, "empty rule")
+}
+
+func TestProfileDirectional(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, password]
+      properties:
+        id: {type: string, readOnly: true}
+        password: {type: string, writeOnly: true, minLength: 8}
+`))
+	require.NoError(t, err)
+
+	p := Default
+	p.Directional = true
+	require.NoError(t, p.Spec(doc))
+	props := doc.Components.Schemas["User"].Value.Properties
+	assert.Equal(t, map[string]any{"validate": "required", "validateResponse": "required"}, props["id"].Value.Extensions[tagKey])
+	assert.Equal(t, map[string]any{"validate": "required,min=8", "validateRequest": "required,min=8", "validateResponse": "-"}, props["password"].Value.Extensions[tagKey])
+}
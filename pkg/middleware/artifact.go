@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Artifact holds everything WithSpec derives from a spec. It is compiled ahead of time,
+// e.g. by the artifact command of oapi-codegen-validator, and loaded with ReadArtifact at
+// startup, so that the service does not parse the spec at boot.
+//
+// WithCELValidation and WithSpecValidation still need the spec itself.
+type Artifact struct {
+	RequiredBodies  []string
+	MaxBodySizes    map[string]int64
+	SensitiveFields []string
+	Messages        MessageCatalog
+	Docs            FieldDocs
+	Bodies          BodyShapes
+}
+
+// NewArtifact compiles the metadata of doc.
+func NewArtifact(doc *openapi3.T) *Artifact {
+	return &Artifact{
+		RequiredBodies:  RequiredBodies(doc),
+		MaxBodySizes:    MaxBodySizes(doc),
+		SensitiveFields: SensitiveFields(doc),
+		Messages:        ErrorMessages(doc),
+		Docs:            FieldDocumentation(doc),
+		Bodies:          compileBodies(doc),
+	}
+}
+
+// WriteArtifact encodes a to w, in the gob format read by ReadArtifact.
+func WriteArtifact(w io.Writer, a *Artifact) error {
+	return gob.NewEncoder(w).Encode(a)
+}
+
+// ReadArtifact decodes an artifact written by WriteArtifact.
+func ReadArtifact(r io.Reader) (*Artifact, error) {
+	var a Artifact
+	if err := gob.NewDecoder(r).Decode(&a); err != nil {
+		return nil, fmt.Errorf("reading artifact: %w", err)
+	}
+	if len(a.Bodies.Shapes) == 0 || !a.Bodies.valid() {
+		return nil, errors.New("reading artifact: invalid body shapes")
+	}
+	return &a, nil
+}
+
+// WithArtifact applies the options WithSpec would derive from the spec a was compiled from.
+func WithArtifact(a *Artifact) Option {
+	opts := []Option{
+		WithRequiredBodies(a.RequiredBodies...),
+		WithMaxBodySizes(a.MaxBodySizes),
+		WithSensitiveFields(a.SensitiveFields...),
+		WithMessageCatalog(a.Messages),
+		WithFieldDocs(a.Docs),
+	}
+	return func(o *options) {
+		for _, opt := range opts {
+			opt(o)
+		}
+		o.bodies = a.Bodies
+	}
+}
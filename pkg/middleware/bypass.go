@@ -0,0 +1,26 @@
+package middleware
+
+import "context"
+
+type bypassKey struct{}
+
+// SkipValidation returns a copy of ctx whose requests and responses are not validated,
+// for tests and canary traffic. It has no effect unless the middleware is built with
+// AllowBypass(true), so that a context set by mistake cannot disable validation in
+// production.
+func SkipValidation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+// AllowBypass lets contexts marked with SkipValidation through without validation.
+func AllowBypass(allow bool) Option {
+	return func(o *options) {
+		o.allowBypass = allow
+	}
+}
+
+// bypassed reports whether validation is skipped for ctx.
+func (o *options) bypassed(ctx context.Context) bool {
+	skip, _ := ctx.Value(bypassKey{}).(bool)
+	return skip && o.allowBypass
+}
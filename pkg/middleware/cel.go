@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/celrules"
+	"github.com/hadrienk/oapi-codegen-validator/internal/schema"
+)
+
+// WithCELValidation evaluates the x-validate-cel rules of the request body schemas of doc,
+// nested objects included, on the decoded bodies: CEL expressions on the properties of an
+// object, e.g. "endDate > startDate", for the cross-field rules struct tags cannot
+// express. A rule is skipped when a property it uses is absent, and reported as the rule
+// "cel" on the first property it uses, with the tag errors in a single *ValidationError.
+// It panics if a rule does not compile; the enricher reports those when generating.
+func WithCELValidation(doc *openapi3.T) Option {
+	c := &celChecks{rules: make(map[*schema.Schema][]*celrules.Rule), bodies: make(map[string]*schema.Schema), owners: make(map[*schema.Schema]string)}
+	models := schema.NewKinConverter()
+	for s, typ := range componentTypes(doc) {
+		c.owners[models.Convert(s)] = typ
+	}
+	for id, op := range operations(doc) {
+		if s := jsonBodySchema(op); s != nil {
+			if m := models.Convert(s); c.compile(m) {
+				c.bodies[id] = m
+			}
+		}
+	}
+	return func(o *options) {
+		o.cel = c
+	}
+}
+
+// celChecks holds the compiled rules of the body schemas, by schema, the body schemas
+// having rules, by operation ID, and the types of the component schemas, by schema.
+type celChecks struct {
+	rules  map[*schema.Schema][]*celrules.Rule
+	bodies map[string]*schema.Schema
+	owners map[*schema.Schema]string
+}
+
+// compile compiles the rules of s and of its nested schemas, once per schema so that
+// recursive schemas terminate, reporting whether any has rules.
+func (c *celChecks) compile(s *schema.Schema) bool {
+	rules, seen := c.rules[s]
+	if seen {
+		return len(rules) > 0
+	}
+	rules, err := celrules.Compile(s)
+	if err != nil {
+		panic(fmt.Sprintf("middleware: %v", err))
+	}
+	c.rules[s] = rules
+	found := len(rules) > 0
+	for _, prop := range s.Properties {
+		if c.compile(prop) {
+			found = true
+		}
+	}
+	if s.Items != nil && c.compile(s.Items) {
+		found = true
+	}
+	return found
+}
+
+// check evaluates the rules of s on value at the JSON path field.
+func (c *celChecks) check(s *schema.Schema, value any, field string) ([]FieldError, error) {
+	var fields []FieldError
+	var errs error
+	switch v := value.(type) {
+	case map[string]any:
+		for _, rule := range c.rules[s] {
+			ok, applied, err := rule.Eval(s, v)
+			switch {
+			case err != nil:
+				errs = errors.Join(errs, fmt.Errorf("%q: %w", rule.Expr, err))
+			case applied && !ok:
+				name := rule.Vars[0]
+				fields = append(fields, FieldError{Field: joinField(field, name), Rule: "cel", Param: rule.Expr, Value: v[name], Owner: c.owners[s]})
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+			if child, ok := v[name]; ok {
+				fe, err := c.check(s.Properties[name], child, joinField(field, name))
+				fields, errs = append(fields, fe...), errors.Join(errs, err)
+			}
+		}
+	case []any:
+		if s.Items != nil {
+			for i, item := range v {
+				fe, err := c.check(s.Items, item, field+"["+strconv.Itoa(i)+"]")
+				fields, errs = append(fields, fe...), errors.Join(errs, err)
+			}
+		}
+	}
+	return fields, errs
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// withCELErrors adds the broken rules found by evaluating the CEL rules of the body of
+// args to the result of the tag validation.
+func (o *options) withCELErrors(r *http.Request, operationID string, args any, err error) error {
+	var ve *ValidationError
+	if err != nil && !errors.As(err, &ve) {
+		return err
+	}
+	bodySchema := o.cel.bodies[operationID]
+	body, ok := decodedBody(o.planFor(operationID, args), args)
+	if !ok {
+		return err
+	}
+	fields, evalErr := o.cel.check(bodySchema, body, "")
+	if evalErr != nil && o.logger != nil {
+		o.logger.LogAttrs(r.Context(), slog.LevelError, "cel validation failed",
+			slog.String("operation_id", operationID),
+			slog.String("reason", evalErr.Error()),
+		)
+	}
+	if len(fields) == 0 {
+		return err
+	}
+	var celErr error
+	for _, fe := range fields {
+		celErr = errors.Join(celErr, fmt.Errorf("%s breaks %q", fe.Field, fe.Param))
+	}
+	if ve == nil {
+		return o.completeError(operationID, &ValidationError{Fields: fields, err: celErr})
+	}
+	ve.Fields = append(ve.Fields, fields...)
+	ve.err = errors.Join(ve.err, celErr)
+	return o.completeError(operationID, ve)
+}
+
+// decodedBody returns the body of args as decoded JSON, numbers kept as json.Number.
+func decodedBody(p *plan, args any) (any, bool) {
+	if p.body == nil || !p.structBody {
+		return nil, false
+	}
+	val := reflect.ValueOf(args)
+	if p.pointer {
+		if val.IsNil() {
+			return nil, false
+		}
+		val = val.Elem()
+	}
+	field := val.FieldByIndex(p.body)
+	if field.IsZero() {
+		return nil, false
+	}
+	data, err := json.Marshal(field.Interface())
+	if err != nil {
+		// Values failing to encode, e.g. invalid emails, are reported by their tags.
+		return nil, false
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var body any
+	if err := dec.Decode(&body); err != nil {
+		return nil, false
+	}
+	return body, true
+}
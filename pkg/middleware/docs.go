@@ -0,0 +1,39 @@
+package middleware
+
+import "reflect"
+
+// FieldDoc documents a field for the developers of the clients breaking its rules.
+type FieldDoc struct {
+	Title       string
+	Description string
+	// URL links to further documentation.
+	URL string
+}
+
+// FieldDocs maps fields to their documentation. Fields are named either by their JSON
+// name, matching them in any type, or as TypeName.jsonName. See FieldDocumentation to
+// load it from the spec.
+type FieldDocs map[string]FieldDoc
+
+// WithFieldDocs adds the title, description and documentation link of the field to each
+// reported error, so that clients can fix their requests without looking up the spec.
+func WithFieldDocs(docs FieldDocs) Option {
+	return func(o *options) {
+		o.docs = docs
+	}
+}
+
+// lookup returns the documentation of the field of type owner, which may be nil.
+func (d FieldDocs) lookup(owner reflect.Type, field string) (FieldDoc, bool) {
+	if owner != nil {
+		if doc, ok := d[owner.Name()+"."+field]; ok {
+			return doc, true
+		}
+	}
+	doc, ok := d[field]
+	return doc, ok
+}
+
+func (fe *FieldError) document(doc FieldDoc) {
+	fe.Title, fe.Description, fe.Docs = doc.Title, doc.Description, doc.URL
+}
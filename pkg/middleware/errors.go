@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrMissingBody is reported when an operation requires a request body but none was sent.
+var ErrMissingBody = errors.New("request body is required")
+
+// ReflectionError is reported when the arguments handed to the middleware do not have
+// the shape of a generated request object.
+type ReflectionError struct {
+	Type   reflect.Type
+	Reason string
+}
+
+func (e *ReflectionError) Error() string {
+	return fmt.Sprintf("cannot validate arguments of type %v: %s", e.Type, e.Reason)
+}
+
+// ValidationError is reported when the request body breaks the rules of its struct tags.
+// It unwraps to the validator.ValidationErrors it was built from, which still holds the
+// raw submitted values.
+type ValidationError struct {
+	OperationID string
+	Fields      []FieldError
+	// Omitted is the number of field errors left out of Fields, see WithMaxErrors.
+	Omitted int
+	// Response reports whether the error was found in the response returned by the
+	// handler rather than in the request, see WithResponseValidation.
+	Response bool
+	err      error
+}
+
+// FieldError describes one broken rule.
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. "address.street".
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+	Param string `json:"param,omitempty"`
+	// Value is the submitted value, left empty when Redacted.
+	Value    any  `json:"value,omitempty"`
+	Redacted bool `json:"redacted,omitempty"`
+	// Message describes the broken rule for humans, see Message.
+	Message string `json:"message,omitempty"`
+	// Title, Description and Docs document the field, see WithFieldDocs.
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Docs        string `json:"docs,omitempty"`
+	// Owner is the type declaring the field, e.g. User, as oapi-codegen names it, for the
+	// fields of WithSensitiveFields named TypeName.jsonName. It is not serialized.
+	Owner string `json:"-"`
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	if e.Response {
+		b.WriteString("response ")
+	}
+	b.WriteString("validation failed:")
+	for i, fe := range e.Fields {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, " %s (%s)", fe.Field, fe.Rule)
+	}
+	if e.Omitted > 0 {
+		fmt.Fprintf(&b, " and %d more", e.Omitted)
+	}
+	return b.String()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
+
+// validationError converts the error returned by the validator for a value of type root.
+func (o *options) validationError(operationID string, root reflect.Type, err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+	ve := &ValidationError{OperationID: operationID, err: err}
+	if o.maxErrors > 0 && len(verrs) > o.maxErrors {
+		ve.Omitted = len(verrs) - o.maxErrors
+		verrs = verrs[:o.maxErrors]
+	}
+	ve.Fields = make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		var owner reflect.Type
+		if len(o.messages) > 0 || len(o.docs) > 0 {
+			if t, ok := fieldOwner(root, fe.StructNamespace()); ok {
+				owner = t
+			}
+		}
+		field := FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: o.message(owner, fe),
+		}
+		if doc, ok := o.docs.lookup(owner, fe.Field()); ok {
+			field.document(doc)
+		}
+		if o.isSensitive(root, fe) {
+			field.Redacted = true
+		} else {
+			field.Value = plainValue(fe.Value())
+		}
+		ve.Fields = append(ve.Fields, field)
+	}
+	return ve
+}
+
+// completeError completes a *ValidationError built from field errors rather than from
+// the validator, such as those of generated validators or of the spec validation.
+func (o *options) completeError(operationID string, err error) error {
+	ve, ok := err.(*ValidationError)
+	if !ok {
+		return err
+	}
+	ve.OperationID = operationID
+	if o.maxErrors > 0 && len(ve.Fields) > o.maxErrors {
+		ve.Omitted += len(ve.Fields) - o.maxErrors
+		ve.Fields = ve.Fields[:o.maxErrors]
+	}
+	for i, fe := range ve.Fields {
+		name, _, _ := strings.Cut(fe.Field[strings.LastIndex(fe.Field, ".")+1:], "[")
+		if msg, ok := o.messages.lookup(nil, name, fe.Rule); ok {
+			ve.Fields[i].Message = msg
+		} else if fe.Message == "" {
+			ve.Fields[i].Message = cachedMessage(fe.Rule, fe.Param, valueKind(fe.Value))
+		}
+		if doc, ok := o.docs.lookup(nil, name); ok {
+			ve.Fields[i].document(doc)
+		}
+		if o.sensitive[name] || fe.Owner != "" && o.sensitive[fe.Owner+"."+name] {
+			ve.Fields[i].Value, ve.Fields[i].Redacted = nil, true
+		} else {
+			ve.Fields[i].Value = plainValue(fe.Value)
+		}
+	}
+	return ve
+}
+
+// plainValue converts v, when of a named scalar type, to its underlying type, dropping
+// its JSON encoding: the encoding of an invalid value may fail, such as the one of
+// openapi_types.Email.
+func plainValue(v any) any {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Type().PkgPath() == "" {
+		return v
+	}
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String()
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return v
+}
+
+// valueKind returns the kind of v behind pointers, reflect.Invalid for nil.
+func valueKind(v any) reflect.Kind {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	return rv.Kind()
+}
+
+func (o *options) isSensitive(root reflect.Type, fe validator.FieldError) bool {
+	if len(o.sensitive) == 0 {
+		return false
+	}
+	if o.sensitive[fe.Field()] {
+		return true
+	}
+	owner, ok := fieldOwner(root, fe.StructNamespace())
+	return ok && o.sensitive[owner.Name()+"."+fe.Field()]
+}
+
+// fieldOwner returns the struct type declaring the last field of a validator struct
+// namespace such as "User.Addresses[0].Street", starting from root.
+func fieldOwner(root reflect.Type, structNamespace string) (reflect.Type, bool) {
+	segments := strings.Split(structNamespace, ".")
+	if len(segments) < 2 {
+		return nil, false
+	}
+	owner := elemType(root)
+	for _, seg := range segments[1 : len(segments)-1] {
+		name, _, _ := strings.Cut(seg, "[")
+		if owner.Kind() != reflect.Struct {
+			return nil, false
+		}
+		f, ok := owner.FieldByName(name)
+		if !ok {
+			return nil, false
+		}
+		owner = elemType(f.Type)
+	}
+	return owner, owner.Kind() == reflect.Struct
+}
+
+// elemType strips pointers and containers down to the element type.
+func elemType(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+// statusOf returns the HTTP status reported for err.
+func statusOf(err error) int {
+	if _, ok := as[*http.MaxBytesError](err); ok {
+		return http.StatusRequestEntityTooLarge
+	}
+	if _, ok := as[*ReflectionError](err); ok {
+		return http.StatusInternalServerError
+	}
+	if ve, ok := as[*ValidationError](err); ok && ve.Response {
+		return http.StatusInternalServerError
+	}
+	return http.StatusBadRequest
+}
+
+// as is errors.As returning the target, which it only allocates when err wraps it: the
+// errors of rejected requests are rarely wrapped.
+func as[T error](err error) (T, bool) {
+	if t, ok := err.(T); ok {
+		return t, true
+	}
+	var t T
+	ok := errors.As(err, &t)
+	return t, ok
+}
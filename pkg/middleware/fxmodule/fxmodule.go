@@ -0,0 +1,52 @@
+// Package fxmodule provides the validation middleware to fx applications.
+//
+//	fx.New(
+//		fxmodule.Module,
+//		fx.Provide(api.GetSwagger),
+//		fx.Invoke(func(mw middleware.StrictMiddlewareFunc) { ... }),
+//	)
+package fxmodule
+
+import (
+	"log/slog"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/middleware"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/middleware/wireset"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/fx"
+)
+
+// Module provides a shared *middleware.Validator and the middleware.StrictMiddlewareFunc
+// built from Params.
+var Module = fx.Module("oapi-codegen-validator",
+	fx.Provide(NewValidator, NewMiddleware),
+)
+
+// Params are the dependencies of the module, all optional.
+type Params struct {
+	fx.In
+
+	// Spec is the document the API was generated from, see middleware.WithSpec.
+	Spec       *openapi3.T           `optional:"true"`
+	Registerer prometheus.Registerer `optional:"true"`
+	Logger     *slog.Logger          `optional:"true"`
+	// Options are the options provided to the "oapi_validator_options" group, e.g.
+	//
+	//	fx.Provide(fx.Annotate(middleware.WithResponseValidation, fx.ResultTags(`group:"oapi_validator_options"`)))
+	Options []middleware.Option `group:"oapi_validator_options"`
+}
+
+// NewValidator builds the validator from the options of p.
+func NewValidator(p Params) *middleware.Validator {
+	return wireset.NewValidator(p.config())
+}
+
+// NewMiddleware builds the middleware from p, validating with v.
+func NewMiddleware(p Params, v *middleware.Validator) middleware.StrictMiddlewareFunc {
+	return wireset.NewMiddleware(p.config(), v)
+}
+
+func (p Params) config() wireset.Config {
+	return wireset.Config{Spec: p.Spec, Registerer: p.Registerer, Logger: p.Logger, Options: p.Options}
+}
@@ -0,0 +1,40 @@
+package fxmodule
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hadrienk/oapi-codegen-validator/pkg/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+type body struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func TestModule(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	var mw middleware.StrictMiddlewareFunc
+	fxtest.New(t,
+		Module,
+		fx.Supply(fx.Annotate(reg, fx.As(new(prometheus.Registerer)))),
+		fx.Provide(fx.Annotate(middleware.WithFailFast, fx.ResultTags(`group:"oapi_validator_options"`))),
+		fx.Populate(&mw),
+	).RequireStart().RequireStop()
+
+	handler := mw(func(ctx context.Context, w http.ResponseWriter, r *http.Request, args any) (any, error) {
+		return nil, nil
+	}, "CreateTest")
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	w := httptest.NewRecorder()
+	_, _ = handler(r.Context(), w, r, struct{ Body *body }{Body: &body{}})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 1, testutil.CollectAndCount(reg, "oapi_validator_failures_total"))
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+// WithSpecValidation also validates the requests to the given operations against doc with
+// kin-openapi, for the constructs struct tags cannot express such as oneOf or conditional
+// schemas. Its errors are reported with those of the tags in a single *ValidationError.
+// The body is only checked when captured with CaptureBody; parameters always are.
+// Requests are routed with the server URLs of doc. It panics if doc is invalid.
+func WithSpecValidation(doc *openapi3.T, operationIDs ...string) Option {
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		panic(fmt.Sprintf("middleware: cannot route the spec: %v", err))
+	}
+	owners := componentTypes(doc)
+	return func(o *options) {
+		o.router, o.specOwners = router, owners
+		if o.specOperations == nil {
+			o.specOperations = make(map[string]bool)
+		}
+		for _, id := range operationIDs {
+			o.specOperations[id] = true
+		}
+	}
+}
+
+// validateSpec validates r against the spec, returning the broken rules.
+func (o *options) validateSpec(r *http.Request, operationID string) (*ValidationError, error) {
+	route, pathParams, err := o.router.FindRoute(r)
+	if err != nil {
+		return nil, err
+	}
+	data, captured := rawBody(r.Context())
+	if captured {
+		r = r.Clone(r.Context())
+		r.Body = io.NopCloser(bytes.NewReader(data))
+	}
+	err = openapi3filter.ValidateRequest(r.Context(), &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: pathParams,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			ExcludeRequestBody: !captured,
+			MultiError:         true,
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	})
+	if err == nil {
+		return nil, nil
+	}
+	return &ValidationError{OperationID: operationID, Fields: o.specFields(err, "", jsonBodySchema(route.Operation)), err: err}, nil
+}
+
+// withSpecErrors adds the broken rules found by validating r against the spec to the
+// result of the tag validation.
+func (o *options) withSpecErrors(r *http.Request, operationID string, err error) error {
+	var ve *ValidationError
+	if err != nil && !errors.As(err, &ve) {
+		return err
+	}
+	specErr, routeErr := o.validateSpec(r, operationID)
+	if routeErr != nil {
+		if o.logger != nil {
+			o.logger.LogAttrs(r.Context(), slog.LevelError, "spec validation skipped",
+				slog.String("operation_id", operationID),
+				slog.String("reason", routeErr.Error()),
+			)
+		}
+		return err
+	}
+	switch {
+	case specErr == nil:
+		return err
+	case ve == nil:
+		return o.completeError(operationID, specErr)
+	default:
+		ve.Fields = append(ve.Fields, specErr.Fields...)
+		ve.err = errors.Join(ve.err, specErr.err)
+		return o.completeError(operationID, ve)
+	}
+}
+
+// specFields converts the errors of openapi3filter to field errors. param is the name of
+// the parameter the errors belong to, empty for the body of schema body.
+func (o *options) specFields(err error, param string, body *openapi3.Schema) []FieldError {
+	var fields []FieldError
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		for _, err := range e {
+			fields = append(fields, o.specFields(err, param, body)...)
+		}
+	case *openapi3filter.RequestError:
+		if e.Parameter != nil {
+			param = e.Parameter.Name
+		}
+		if e.Err != nil {
+			return o.specFields(e.Err, param, body)
+		}
+		fields = append(fields, FieldError{Field: param, Rule: "schema", Message: e.Reason})
+	case *openapi3.SchemaError:
+		field := param
+		for _, seg := range e.JSONPointer() {
+			switch _, err := strconv.Atoi(seg); {
+			case err == nil:
+				field += "[" + seg + "]"
+			case field == "":
+				field = seg
+			default:
+				field += "." + seg
+			}
+		}
+		fe := FieldError{Field: field, Rule: e.SchemaField, Message: e.Reason}
+		if param == "" {
+			fe.Owner = o.specOwner(body, e.JSONPointer())
+		}
+		// Errors on objects and arrays carry the whole value, which may hold sensitive fields.
+		if k := valueKind(e.Value); k != reflect.Map && k != reflect.Slice {
+			fe.Value = e.Value
+		}
+		fields = append(fields, fe)
+	default:
+		fields = append(fields, FieldError{Field: param, Rule: "schema", Message: strings.TrimSpace(err.Error())})
+	}
+	return fields
+}
+
+// specOwner returns the type of the component schema declaring the field at pointer in
+// the body of schema body, empty when the field is not a property of one.
+func (o *options) specOwner(body *openapi3.Schema, pointer []string) string {
+	if len(pointer) == 0 {
+		return ""
+	}
+	s := body
+	for _, seg := range pointer[:len(pointer)-1] {
+		if s == nil {
+			return ""
+		}
+		_, err := strconv.Atoi(seg)
+		switch {
+		case s.Properties[seg] != nil:
+			s = s.Properties[seg].Value
+		case err == nil && s.Items != nil:
+			s = s.Items.Value
+		case s.AdditionalProperties.Schema != nil:
+			s = s.AdditionalProperties.Schema.Value
+		default:
+			return ""
+		}
+	}
+	if s == nil {
+		return ""
+	}
+	return o.specOwners[s]
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// WithLogger logs every rejected request to l with its operation ID, the offending field
+// paths and the violated rule names. Submitted values are never logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithLogLevel sets the level at which rejected requests are logged. Defaults to slog.LevelInfo.
+func WithLogLevel(level slog.Level) Option {
+	return func(o *options) {
+		o.logLevel = level
+	}
+}
+
+func (o *options) logFailure(ctx context.Context, operationID string, err error) {
+	if o.logger == nil || !o.logger.Enabled(ctx, o.logLevel) {
+		return
+	}
+	msg := "request validation failed"
+	if ve, ok := err.(*ValidationError); ok && ve.Response {
+		msg = "response validation failed"
+	}
+	o.logger.LogAttrs(ctx, o.logLevel, msg,
+		slog.String("operation_id", operationID),
+		slog.Any("fields", failedFields(err)),
+		slog.Any("rules", failedRules(err)),
+	)
+}
+
+// failedFields returns the paths of the fields that err reports as invalid.
+func failedFields(err error) []string {
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		fields := make([]string, 0, len(ve.Fields))
+		for _, fe := range ve.Fields {
+			fields = append(fields, fe.Field)
+		}
+		return fields
+	}
+	var unknown *UnknownFieldsError
+	if errors.As(err, &unknown) {
+		return unknown.Fields
+	}
+	return nil
+}
+
+// fieldPath returns the namespace of fe without the name of the validated root type,
+// e.g. "address.street" rather than "CreateUserJSONRequestBody.address.street".
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if _, path, ok := strings.Cut(ns, "."); ok {
+		return path
+	}
+	return ns
+}
+
+func (o *options) logReflectionError(ctx context.Context, operationID string, err *ReflectionError) {
+	if o.logger == nil {
+		return
+	}
+	o.logger.LogAttrs(ctx, slog.LevelError, "unexpected request object shape",
+		slog.String("operation_id", operationID),
+		slog.String("type", fmt.Sprint(err.Type)),
+		slog.String("reason", err.Reason),
+		slog.Bool("fail_open", o.failOpen),
+	)
+}
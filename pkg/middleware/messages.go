@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// MessageCatalog holds custom messages per field and rule. Fields are named either by
+// their JSON name, matching them in any type, or as TypeName.jsonName; the empty rule
+// gives the message of every rule of the field. See ErrorMessages to load it from the
+// spec.
+type MessageCatalog map[string]map[string]string
+
+// WithMessageCatalog reports broken rules with the messages of catalog. Rules it does not
+// cover keep the default messages.
+func WithMessageCatalog(catalog MessageCatalog) Option {
+	return func(o *options) {
+		o.messages = catalog
+	}
+}
+
+// lookup returns the message of rule for the field of type owner, which may be nil.
+func (c MessageCatalog) lookup(owner reflect.Type, field, rule string) (string, bool) {
+	keys := []string{field}
+	if owner != nil {
+		keys = []string{owner.Name() + "." + field, field}
+	}
+	for _, key := range keys {
+		rules, ok := c[key]
+		if !ok {
+			continue
+		}
+		if msg, ok := rules[rule]; ok {
+			return msg, true
+		}
+		if msg, ok := rules[""]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// message returns the message reporting fe in a field of type owner, which may be nil.
+func (o *options) message(owner reflect.Type, fe validator.FieldError) string {
+	if msg, ok := o.messages.lookup(owner, fe.Field(), fe.Tag()); ok {
+		return msg
+	}
+	return cachedMessage(fe.Tag(), fe.Param(), fe.Kind())
+}
+
+type messageKey struct {
+	rule, param string
+	kind        reflect.Kind
+}
+
+// messages caches the default messages: the rules of a service are few, the requests
+// breaking them may be many.
+var messages = struct {
+	sync.RWMutex
+	m map[messageKey]string
+}{m: make(map[messageKey]string)}
+
+// cachedMessage returns Message(rule, param, kind), built once.
+func cachedMessage(rule, param string, kind reflect.Kind) string {
+	key := messageKey{rule, param, kind}
+	messages.RLock()
+	msg, ok := messages.m[key]
+	messages.RUnlock()
+	if ok {
+		return msg
+	}
+	msg = Message(rule, param, kind)
+	messages.Lock()
+	messages.m[key] = msg
+	messages.Unlock()
+	return msg
+}
+
+// Message describes a broken rule as a sentence to follow the field name, e.g.
+// "must be at least 5 characters" for the rule min=5 on a string. kind is the kind of the
+// field, which tells lengths from counts and values; reflect.Invalid when unknown.
+func Message(rule, param string, kind reflect.Kind) string {
+	switch rule {
+	case "required":
+		return "is required"
+	case "required_if":
+		if field, value, ok := strings.Cut(param, " "); ok {
+			return "is required when " + field + " is " + value
+		}
+		return "is required when " + param
+	case "required_with":
+		return "is required when " + param + " is present"
+	case "required_unless":
+		if field, value, ok := strings.Cut(param, " "); ok {
+			return "is required unless " + field + " is " + value
+		}
+		return "is required unless " + param
+	case "excluded_if":
+		if field, value, ok := strings.Cut(param, " "); ok {
+			return "must be absent when " + field + " is " + value
+		}
+		return "must be absent when " + param
+	case "excluded_unless":
+		if field, value, ok := strings.Cut(param, " "); ok {
+			return "must be absent unless " + field + " is " + value
+		}
+		return "must be absent unless " + param
+	case "min", "gte":
+		return "must be at least " + quantity(param, kind)
+	case "max", "lte":
+		return "must be at most " + quantity(param, kind)
+	case "gt":
+		return "must be more than " + quantity(param, kind)
+	case "lt":
+		return "must be less than " + quantity(param, kind)
+	case "len":
+		return "must be exactly " + quantity(param, kind)
+	case "eq":
+		return "must be equal to " + param
+	case "ne":
+		return "must not be equal to " + param
+	case "oneof", "oneofnumber":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "regex":
+		return "must match the pattern " + param
+	case "multipleof":
+		return "must be a multiple of " + param
+	case "datetime":
+		return "must be a date or time in the layout " + param
+	case "rfc3339":
+		return "must be a valid RFC 3339 date-time"
+	case "unique":
+		return "must not contain duplicate items"
+	case "email":
+		return "must be a valid email address"
+	case "url", "uri":
+		return "must be a valid URL"
+	case "uuid":
+		return "must be a valid UUID"
+	case "hostname_rfc1123", "idn_hostname":
+		return "must be a valid host name"
+	case "iso8601duration":
+		return "must be an ISO 8601 duration"
+	case "base64", "base64url":
+		return "must be valid base64"
+	case "base32":
+		return "must be valid base32"
+	case "hexadecimal":
+		return "must be hexadecimal"
+	case "json":
+		return "must be valid JSON"
+	case "ipv4":
+		return "must be a valid IPv4 address"
+	case "ipv6":
+		return "must be a valid IPv6 address"
+	case "unknown_field":
+		return "is not allowed"
+	case "expr", "cel":
+		return "must satisfy " + param
+	}
+	if param != "" {
+		return fmt.Sprintf("must satisfy %s=%s", rule, param)
+	}
+	return "must satisfy " + rule
+}
+
+// quantity phrases a bound according to what it limits: the length of strings, the
+// number of items of collections, or the value itself.
+func quantity(n string, kind reflect.Kind) string {
+	unit := ""
+	switch kind {
+	case reflect.String:
+		unit = " characters"
+		if n == "1" {
+			unit = " character"
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+		if n == "1" {
+			unit = " item"
+		}
+	}
+	return n + unit
+}
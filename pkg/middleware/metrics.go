@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type metrics struct {
+	failures *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// WithMetrics records validation failures, by operation ID and rule, and validation
+// latency, by operation ID, in reg. Collectors already registered by another middleware
+// instance are shared.
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(o *options) {
+		o.metrics = &metrics{
+			failures: register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: "oapi_validator",
+				Name:      "failures_total",
+				Help:      "Number of requests rejected by the validation middleware.",
+			}, []string{"operation", "rule"})),
+			duration: register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace: "oapi_validator",
+				Name:      "validation_duration_seconds",
+				Help:      "Time spent validating requests.",
+				Buckets:   []float64{.00001, .00005, .0001, .0005, .001, .005, .01},
+			}, []string{"operation"})),
+		}
+	}
+}
+
+func register[C prometheus.Collector](reg prometheus.Registerer, c C) C {
+	if err := reg.Register(c); err != nil {
+		var already prometheus.AlreadyRegisteredError
+		if errors.As(err, &already) {
+			if existing, ok := already.ExistingCollector.(C); ok {
+				return existing
+			}
+		}
+		panic(err)
+	}
+	return c
+}
+
+func (m *metrics) observe(operationID string, elapsed time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.duration.WithLabelValues(operationID).Observe(elapsed.Seconds())
+	for _, rule := range failedRules(err) {
+		m.failures.WithLabelValues(operationID, rule).Inc()
+	}
+}
+
+// failedRules returns the names of the rules that err reports as violated. They only
+// depend on the spec, never on the submitted values, so they are safe to expose.
+func failedRules(err error) []string {
+	if err == nil {
+		return nil
+	}
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		rules := make([]string, 0, len(ve.Fields))
+		for _, fe := range ve.Fields {
+			rules = append(rules, fe.Rule)
+		}
+		return rules
+	}
+	switch {
+	case errors.Is(err, ErrMissingBody):
+		return []string{"required_body"}
+	case errors.As(err, new(*UnknownFieldsError)):
+		return []string{"unknown_field"}
+	case errors.As(err, new(*http.MaxBytesError)):
+		return []string{"max_body_size"}
+	case errors.As(err, new(*ReflectionError)):
+		return []string{"reflection"}
+	}
+	return []string{"unknown"}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// OverrideMode selects how a struct override combines with the tag validation.
+type OverrideMode int
+
+const (
+	// Augment runs the override once the tag validation of the struct succeeded.
+	Augment OverrideMode = iota
+	// Replace runs the override instead of the tag validation of the struct.
+	Replace
+)
+
+type override struct {
+	fn   func(context.Context, any) error
+	mode OverrideMode
+}
+
+// WithStructOverride validates request bodies and responses of the struct type T with fn,
+// for invariants tags cannot express. Errors returned by fn are reported as is; return a
+// *ValidationError to describe them field by field. Other types keep tag validation.
+func WithStructOverride[T any](fn func(context.Context, T) error, mode OverrideMode) Option {
+	return func(o *options) {
+		if o.overrides == nil {
+			o.overrides = make(map[reflect.Type]override)
+		}
+		o.overrides[reflect.TypeFor[T]()] = override{
+			fn:   func(ctx context.Context, v any) error { return fn(ctx, v.(T)) },
+			mode: mode,
+		}
+	}
+}
+
+// validateStruct validates v, a struct or a pointer to one, with its tags and overrides.
+func (o *options) validateStruct(ctx context.Context, val *validator.Validate, operationID string, v reflect.Value) error {
+	v = reflect.Indirect(v)
+	ov, ok := o.overrides[v.Type()]
+	if !ok || ov.mode == Augment {
+		if err := val.StructCtx(ctx, v.Interface()); err != nil {
+			return o.validationError(operationID, v.Type(), err)
+		}
+	}
+	if ok {
+		return ov.fn(ctx, v.Interface())
+	}
+	return nil
+}
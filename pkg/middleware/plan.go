@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"reflect"
+	"sync"
+)
+
+// plan caches what the middleware needs to know about a request object type, so that
+// requests do not have to look it up by name.
+type plan struct {
+	argsType reflect.Type
+	// pointer reports whether the request object is passed by pointer.
+	pointer bool
+	// body is the index of the Body field, nil when there is none.
+	body []int
+	// structBody reports whether the body is decoded into a struct worth validating.
+	structBody bool
+	// streamBody reports whether the body is a stream limitBody can wrap.
+	streamBody bool
+	// bodyType is the type of the Body field.
+	bodyType reflect.Type
+	// ruleFree reports whether no rule applies to the body, which is then not handed to
+	// the validator. It is only set on the plans of WithOperationTypes.
+	ruleFree bool
+}
+
+// plans caches the plans of the request object types of the operations registered with
+// no WithOperationTypes, by type.
+var plans sync.Map // reflect.Type -> *plan
+
+// WithOperationTypes registers the request object type of each operation ID, e.g.
+// {"CreateUser": CreateUserRequestObject{}}, so that their reflection plans are computed
+// once at construction instead of on every request. The bodies of the operations whose
+// types carry no validate tags, struct validations or overrides skip the validator.
+func WithOperationTypes(types map[string]any) Option {
+	return func(o *options) {
+		if o.plans == nil {
+			o.plans = make(map[string]*plan)
+		}
+		for id, v := range types {
+			o.plans[id] = newPlan(reflect.TypeOf(v))
+		}
+	}
+}
+
+func newPlan(t reflect.Type) *plan {
+	p := &plan{argsType: t}
+	if t == nil {
+		return p
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		p.pointer = true
+	}
+	if t.Kind() != reflect.Struct {
+		return p
+	}
+	if f, ok := t.FieldByName("Body"); ok {
+		p.body, p.bodyType = f.Index, f.Type
+		p.structBody = decodesToStruct(f.Type)
+		p.streamBody = !p.pointer && f.Type.Kind() == reflect.Interface && readCloserType.AssignableTo(f.Type)
+	}
+	return p
+}
+
+// planFor returns the plan of args, the one computed for its type by the first request
+// when the operation has none or was registered with another type.
+func (o *options) planFor(operationID string, args any) *plan {
+	t := reflect.TypeOf(args)
+	if p, ok := o.plans[operationID]; ok && p.argsType == t {
+		return p
+	}
+	if p, ok := plans.Load(t); ok {
+		return p.(*plan)
+	}
+	p, _ := plans.LoadOrStore(t, newPlan(t))
+	return p.(*plan)
+}
+
+// responsePlan caches the fields of a response object type validated separately.
+type responsePlan struct {
+	// headers and body are the indexes of the Headers and Body fields of the typed
+	// responses, nil when there are no typed headers.
+	headers, body []int
+}
+
+var responsePlans sync.Map // reflect.Type -> *responsePlan
+
+func responsePlanFor(t reflect.Type) *responsePlan {
+	if p, ok := responsePlans.Load(t); ok {
+		return p.(*responsePlan)
+	}
+	p := &responsePlan{}
+	if f, ok := t.FieldByName("Headers"); ok {
+		p.headers = f.Index
+		if f, ok := t.FieldByName("Body"); ok {
+			p.body = f.Index
+		}
+	}
+	cached, _ := responsePlans.LoadOrStore(t, p)
+	return cached.(*responsePlan)
+}
+
+// decodesToStruct reports whether a body of type t was decoded into a struct. Streams
+// (octet-stream uploads, multipart readers) and scalar bodies such as text/plain strings
+// are not.
+func decodesToStruct(t reflect.Type) bool {
+	if t.Implements(readerType) {
+		return false
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != multipartReaderType
+}
+
+// markRuleFree sets ruleFree on the plans of WithOperationTypes whose bodies no rule
+// applies to. The rules of a custom validator are unknown: none is then rule-free.
+func (o *options) markRuleFree() {
+	if o.customValidator {
+		return
+	}
+	config := o
+	if o.shared != nil {
+		config = o.shared.o
+	}
+	tag := config.tagNames[Request]
+	if tag == "" {
+		tag = "validate"
+	}
+plans:
+	for _, p := range o.plans {
+		if !p.structBody {
+			continue
+		}
+		types := make(map[reflect.Type]bool)
+		structTypes(p.bodyType, types)
+		for t := range types {
+			if o.hasRules(config, t, tag) {
+				continue plans
+			}
+		}
+		p.ruleFree = true
+	}
+}
+
+// hasRules reports whether the struct type t has rules of its own: tagged fields, a
+// struct validation registered on config, or an override.
+func (o *options) hasRules(config *options, t reflect.Type, tag string) bool {
+	if _, ok := o.overrides[t]; ok {
+		return true
+	}
+	if _, ok := config.structValidations[t]; ok {
+		return true
+	}
+	for i := range t.NumField() {
+		if rule := t.Field(i).Tag.Get(tag); rule != "" && rule != "-" {
+			return true
+		}
+	}
+	return false
+}
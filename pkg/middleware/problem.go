@@ -0,0 +1,266 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Problem is an RFC 9457 problem details document describing a rejected request.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// RequestID correlates the response with server logs, see WithRequestID.
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+type problemOptions struct {
+	requestID func(context.Context) string
+}
+
+// ProblemOption configures the handler returned by NewProblemHandler.
+type ProblemOption func(*problemOptions)
+
+// WithRequestID includes the ID returned by fn for the request context in every problem,
+// so client-reported errors can be matched with server logs. Empty IDs are omitted.
+func WithRequestID(fn func(context.Context) string) ProblemOption {
+	return func(o *problemOptions) {
+		o.requestID = fn
+	}
+}
+
+// NewProblem describes err as a problem. Values of sensitive fields are never included.
+func NewProblem(err error) *Problem {
+	status := statusOf(err)
+	p := &Problem{Type: "about:blank", Title: http.StatusText(status), Status: status}
+
+	if ve, ok := as[*ValidationError](err); ok {
+		p.Title = "Validation failed"
+		p.Errors = ve.Fields
+		p.Detail = omitted(ve.Omitted)
+	} else if unknown, ok := as[*UnknownFieldsError](err); ok {
+		p.Title = "Validation failed"
+		for _, f := range unknown.Fields {
+			p.Errors = append(p.Errors, FieldError{Field: f, Rule: "unknown_field", Message: Message("unknown_field", "", reflect.Invalid)})
+		}
+		p.Detail = omitted(unknown.Omitted)
+	} else if errors.Is(err, ErrMissingBody) {
+		p.Title = "Validation failed"
+		p.Detail = err.Error()
+	}
+	return p
+}
+
+// textHandler is the default ErrorHandler, writing the plain text summary of the problem,
+// e.g. "Validation failed\nname must be at least 3 characters\n".
+func textHandler(w http.ResponseWriter, r *http.Request, err error) {
+	p := NewProblem(err)
+	buf := getBuffer()
+	defer putBuffer(buf)
+	p.writeText(buf)
+	w.Header()["X-Content-Type-Options"] = nosniff
+	writeProblem(w, textPlain, p.Status, buf)
+}
+
+// ProblemHandler is an ErrorHandler writing the error as a problem, see NewProblemHandler.
+func ProblemHandler(w http.ResponseWriter, r *http.Request, err error) {
+	problemHandler(w, r, err)
+}
+
+var problemHandler = NewProblemHandler()
+
+// The header values of the problems, shared rather than allocated for every rejected
+// request.
+var (
+	textPlain   = []string{"text/plain; charset=utf-8"}
+	problemJSON = []string{"application/problem+json"}
+	problemXML  = []string{"application/problem+xml"}
+	nosniff     = []string{"nosniff"}
+)
+
+// buffers are the encoding buffers of the problems, pooled so that services rejecting a
+// lot of traffic do not allocate one per request.
+var buffers = sync.Pool{New: func() any { return bytes.NewBuffer(make([]byte, 0, 1024)) }}
+
+func getBuffer() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	// Oversized buffers, e.g. of a problem listing every field, are left to the GC.
+	if buf.Cap() <= 64<<10 {
+		buf.Reset()
+		buffers.Put(buf)
+	}
+}
+
+// writeProblem writes the encoded problem in buf.
+func writeProblem(w http.ResponseWriter, contentType []string, status int, buf *bytes.Buffer) {
+	h := w.Header()
+	h["Content-Type"] = contentType
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// NewProblemHandler returns an ErrorHandler writing the error as a problem. The format
+// follows the Accept header of the request: application/problem+json by default,
+// application/problem+xml for XML clients or a plain text summary.
+func NewProblemHandler(opts ...ProblemOption) ErrorHandler {
+	o := &problemOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		p := NewProblem(err)
+		if o.requestID != nil {
+			p.RequestID = o.requestID(r.Context())
+		}
+		buf := getBuffer()
+		defer putBuffer(buf)
+		switch negotiate(r.Header.Get("Accept")) {
+		case formatXML:
+			buf.WriteString(xml.Header)
+			_ = xml.NewEncoder(buf).Encode(p.xml())
+			writeProblem(w, problemXML, p.Status, buf)
+		case formatText:
+			p.writeText(buf)
+			writeProblem(w, textPlain, p.Status, buf)
+		default:
+			_ = json.NewEncoder(buf).Encode(p)
+			writeProblem(w, problemJSON, p.Status, buf)
+		}
+	}
+}
+
+type format int
+
+const (
+	formatJSON format = iota
+	formatXML
+	formatText
+)
+
+// negotiate picks the problem format preferred by an Accept header, defaulting to JSON.
+func negotiate(accept string) format {
+	if accept == "" || accept == "*/*" {
+		return formatJSON
+	}
+	best, bestQ := formatJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		f, ok := formats[mt]
+		if ok && q > bestQ {
+			best, bestQ = f, q
+		}
+	}
+	return best
+}
+
+var formats = map[string]format{
+	"*/*":                      formatJSON,
+	"application/*":            formatJSON,
+	"application/json":         formatJSON,
+	"application/problem+json": formatJSON,
+	"application/xml":          formatXML,
+	"application/problem+xml":  formatXML,
+	"text/xml":                 formatXML,
+	"text/*":                   formatText,
+	"text/plain":               formatText,
+}
+
+// xmlProblem is the RFC 9457 XML representation of a problem.
+type xmlProblem struct {
+	XMLName   xml.Name   `xml:"urn:ietf:rfc:7807 problem"`
+	Type      string     `xml:"type"`
+	Title     string     `xml:"title"`
+	Status    int        `xml:"status"`
+	Detail    string     `xml:"detail,omitempty"`
+	RequestID string     `xml:"requestId,omitempty"`
+	Errors    []xmlField `xml:"errors>error,omitempty"`
+}
+
+type xmlField struct {
+	Field       string `xml:"field"`
+	Rule        string `xml:"rule"`
+	Param       string `xml:"param,omitempty"`
+	Value       string `xml:"value,omitempty"`
+	Redacted    bool   `xml:"redacted,omitempty"`
+	Message     string `xml:"message,omitempty"`
+	Title       string `xml:"title,omitempty"`
+	Description string `xml:"description,omitempty"`
+	Docs        string `xml:"docs,omitempty"`
+}
+
+func (p *Problem) xml() xmlProblem {
+	x := xmlProblem{Type: p.Type, Title: p.Title, Status: p.Status, Detail: p.Detail, RequestID: p.RequestID}
+	for _, fe := range p.Errors {
+		f := xmlField{Field: fe.Field, Rule: fe.Rule, Param: fe.Param, Redacted: fe.Redacted, Message: fe.Message,
+			Title: fe.Title, Description: fe.Description, Docs: fe.Docs}
+		if fe.Value != nil {
+			f.Value = fmt.Sprint(fe.Value)
+		}
+		x.Errors = append(x.Errors, f)
+	}
+	return x
+}
+
+// writeText writes the plain text summary of p to b.
+func (p *Problem) writeText(b *bytes.Buffer) {
+	b.WriteString(p.Title)
+	if p.Detail != "" {
+		b.WriteString(": ")
+		b.WriteString(p.Detail)
+	}
+	b.WriteByte('\n')
+	for _, fe := range p.Errors {
+		b.WriteString(fe.Field)
+		switch {
+		case fe.Message != "":
+			b.WriteByte(' ')
+			b.WriteString(fe.Message)
+		case fe.Param != "":
+			b.WriteString(": ")
+			b.WriteString(fe.Rule)
+			b.WriteByte('=')
+			b.WriteString(fe.Param)
+		default:
+			b.WriteString(": ")
+			b.WriteString(fe.Rule)
+		}
+		b.WriteByte('\n')
+	}
+	if p.RequestID != "" {
+		b.WriteString("request id: ")
+		b.WriteString(p.RequestID)
+		b.WriteByte('\n')
+	}
+}
+
+func omitted(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d more errors omitted", n)
+}
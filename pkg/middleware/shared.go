@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// Validator holds configured validators that any number of middlewares can share, e.g.
+// across routers or API versions. It is built once by NewValidator and cannot be changed
+// afterwards, so validations cannot be registered while requests are being validated.
+type Validator struct {
+	o *options
+}
+
+// NewValidator builds a Validator from opts. The middlewares sharing it take the
+// validators configured by WithValidator, WithTagName, WithValidation, WithFieldNameTag,
+// WithFieldNameFunc and WithWarmup; the other options only apply to Validate. The
+// instance given to WithValidator must not be modified afterwards.
+func NewValidator(opts ...Option) *Validator {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	o.customValidator = o.validator != nil
+	o.buildValidators()
+	o.warmUp()
+	return &Validator{o: o}
+}
+
+// WithSharedValidator validates with v instead of building validators for the middleware.
+// The options configuring validators given to New are then ignored.
+func WithSharedValidator(v *Validator) Option {
+	return func(o *options) {
+		o.shared = v
+	}
+}
+
+// Validate validates i, a struct or a pointer to one, as the middleware validates request
+// bodies, reporting broken rules as a *ValidationError. It implements the Validator
+// interface of Echo, so that handlers calling c.Validate behave like strict handlers:
+//
+//	e.Validator = middleware.NewValidator(middleware.WithSpec(doc))
+func (v *Validator) Validate(i any) error {
+	val := reflect.ValueOf(i)
+	if !val.IsValid() || val.Kind() == reflect.Pointer && val.IsNil() {
+		// Reported by the validator as an invalid argument.
+		return v.o.validator.Struct(i)
+	}
+	return v.o.validateStruct(context.Background(), v.o.validator, "", val)
+}
+
+// ValidateStruct validates obj like Validate, and each element of slices and arrays; other
+// values are ignored. Together with Engine it implements the StructValidator interface of
+// Gin, so that ShouldBindJSON enforces the same rules as the strict middleware:
+//
+//	binding.Validator = middleware.NewValidator(middleware.WithSpec(doc))
+func (v *Validator) ValidateStruct(obj any) error {
+	val := reflect.ValueOf(obj)
+	for val.Kind() == reflect.Pointer {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	switch val.Kind() {
+	case reflect.Struct:
+		return v.o.validateStruct(context.Background(), v.o.validator, "", val)
+	case reflect.Slice, reflect.Array:
+		var errs []error
+		for i := range val.Len() {
+			errs = append(errs, v.ValidateStruct(val.Index(i).Interface()))
+		}
+		return errors.Join(errs...)
+	default:
+		return nil
+	}
+}
+
+// Engine returns the underlying *validator.Validate, for Gin. It must not be modified.
+func (v *Validator) Engine() any {
+	return v.o.validator
+}
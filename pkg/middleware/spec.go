@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"iter"
+	"maps"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/naming"
+)
+
+// WithSpec applies every option derived from the spec: required bodies, body size limits,
+// sensitive fields, custom messages, field documentation and, for bodies captured with
+// CaptureBody, the rejection of unknown fields. See WithArtifact to skip parsing the spec
+// at startup.
+func WithSpec(doc *openapi3.T) Option {
+	return WithArtifact(NewArtifact(doc))
+}
+
+// RequiredBodies returns the IDs of the operations whose request body is marked as required.
+// The document is typically the one returned by the generated GetSwagger function.
+func RequiredBodies(doc *openapi3.T) []string {
+	var ids []string
+	for id, op := range operations(doc) {
+		if op.RequestBody != nil && op.RequestBody.Value != nil && op.RequestBody.Value.Required {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// MaxBodySizes returns, per operation ID, the largest maxLength declared on a binary
+// request body schema (type: string, format: binary). Operations without such a
+// hint are omitted.
+func MaxBodySizes(doc *openapi3.T) map[string]int64 {
+	limits := make(map[string]int64)
+	for id, op := range operations(doc) {
+		if op.RequestBody == nil || op.RequestBody.Value == nil {
+			continue
+		}
+		for _, mt := range op.RequestBody.Value.Content {
+			if mt.Schema == nil || mt.Schema.Value == nil {
+				continue
+			}
+			s := mt.Schema.Value
+			if s.Format != "binary" || s.MaxLength == nil {
+				continue
+			}
+			if limit := int64(*s.MaxLength); limit > limits[id] {
+				limits[id] = limit
+			}
+		}
+	}
+	return limits
+}
+
+// SensitiveFields returns the fields of the component schemas whose values must never be
+// echoed back: passwords (format: password) and properties flagged with x-sensitive: true.
+// They are named TypeName.jsonName, as expected by WithSensitiveFields.
+func SensitiveFields(doc *openapi3.T) []string {
+	var fields []string
+	if doc == nil || doc.Components == nil {
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(doc.Components.Schemas)) {
+		ref := doc.Components.Schemas[name]
+		if ref.Value == nil {
+			continue
+		}
+		for _, prop := range slices.Sorted(maps.Keys(ref.Value.Properties)) {
+			s := ref.Value.Properties[prop].Value
+			if s == nil {
+				continue
+			}
+			if sensitive, _ := s.Extensions["x-sensitive"].(bool); sensitive || s.Format == "password" {
+				fields = append(fields, naming.TypeName(name)+"."+prop)
+			}
+		}
+	}
+	return fields
+}
+
+// componentTypes returns the types oapi-codegen generates from the component schemas of
+// doc, by schema.
+func componentTypes(doc *openapi3.T) map[*openapi3.Schema]string {
+	types := make(map[*openapi3.Schema]string)
+	if doc == nil || doc.Components == nil {
+		return types
+	}
+	for name, ref := range doc.Components.Schemas {
+		if ref.Value != nil {
+			types[ref.Value] = naming.TypeName(name)
+		}
+	}
+	return types
+}
+
+// ErrorMessages returns the custom messages that the properties of the component schemas
+// define with x-error-message, for WithMessageCatalog. The extension holds either the
+// message of every rule of the property or a map from rule to message.
+func ErrorMessages(doc *openapi3.T) MessageCatalog {
+	catalog := make(MessageCatalog)
+	if doc == nil || doc.Components == nil {
+		return catalog
+	}
+	for name, ref := range doc.Components.Schemas {
+		if ref.Value == nil {
+			continue
+		}
+		for prop, p := range ref.Value.Properties {
+			if p.Value == nil {
+				continue
+			}
+			switch msg := p.Value.Extensions["x-error-message"].(type) {
+			case string:
+				catalog[naming.TypeName(name)+"."+prop] = map[string]string{"": msg}
+			case map[string]any:
+				rules := make(map[string]string)
+				for rule, m := range msg {
+					if m, ok := m.(string); ok {
+						rules[rule] = m
+					}
+				}
+				catalog[naming.TypeName(name)+"."+prop] = rules
+			}
+		}
+	}
+	return catalog
+}
+
+// FieldDocumentation returns the title and description of the properties of the
+// component schemas, for WithFieldDocs. The link is the externalDocs URL of the property,
+// or else of its schema.
+func FieldDocumentation(doc *openapi3.T) FieldDocs {
+	docs := make(FieldDocs)
+	if doc == nil || doc.Components == nil {
+		return docs
+	}
+	for name, ref := range doc.Components.Schemas {
+		if ref.Value == nil {
+			continue
+		}
+		for prop, p := range ref.Value.Properties {
+			if p.Value == nil {
+				continue
+			}
+			d := FieldDoc{Title: p.Value.Title, Description: p.Value.Description}
+			if ed := p.Value.ExternalDocs; ed != nil {
+				d.URL = ed.URL
+			} else if ed := ref.Value.ExternalDocs; ed != nil {
+				d.URL = ed.URL
+			}
+			if d != (FieldDoc{}) {
+				docs[naming.TypeName(name)+"."+prop] = d
+			}
+		}
+	}
+	return docs
+}
+
+// operations yields every operation of the document that has an operationId, under the
+// name oapi-codegen passes to the strict middlewares.
+func operations(doc *openapi3.T) iter.Seq2[string, *openapi3.Operation] {
+	return func(yield func(string, *openapi3.Operation) bool) {
+		if doc == nil || doc.Paths == nil {
+			return
+		}
+		for _, item := range doc.Paths.Map() {
+			for _, op := range item.Operations() {
+				if op.OperationID == "" {
+					continue
+				}
+				if !yield(naming.TypeName(op.OperationID), op) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -2,12 +2,29 @@ package middleware
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"reflect"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
 	"github.com/go-playground/validator/v10"
+	"github.com/hadrienk/oapi-codegen-validator/internal/patterns"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
 )
 
 // StrictHandlerFunc matches the signature of the generated strict handler.
@@ -20,10 +37,54 @@ type StrictMiddlewareFunc func(f StrictHandlerFunc, operationID string) StrictHa
 type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
 
 type options struct {
-	validator    *validator.Validate
-	errorHandler ErrorHandler
+	validator      *validator.Validate
+	errorHandler   ErrorHandler
+	requiredBodies map[string]bool
+	skippedTypes   map[string]bool
+	maxBodySizes   map[string]int64
+	bodies         BodyShapes
+	metrics        *metrics
+	logger         *slog.Logger
+	logLevel       slog.Level
+	failOpen       bool
+	sensitive      map[string]bool
+	maxErrors      int
+	responses      bool
+	overrides      map[reflect.Type]override
+	tagNames       map[Direction]string
+	validations    map[string]validator.Func
+	// structValidations are registered on the struct type they are keyed by.
+	structValidations map[reflect.Type]validator.StructLevelFunc
+
+	responseValidator *validator.Validate
+	plans             map[string]*plan
+	generated         map[string]func(context.Context, any) error
+	fieldName         validator.TagNameFunc
+	allowBypass       bool
+	warmup            []any
+	messages          MessageCatalog
+	docs              FieldDocs
+	propagate         bool
+	router            routers.Router
+	specOperations    map[string]bool
+	specOwners        map[*openapi3.Schema]string
+	cel               *celChecks
+	filter            func(*http.Request) bool
+	shared            *Validator
+	errorHandlers     map[string]ErrorHandler
+	// customValidator reports whether the validator was given by WithValidator, with
+	// rules the middleware cannot see.
+	customValidator bool
 }
 
+// Direction tells requests from responses.
+type Direction int
+
+const (
+	Request Direction = iota
+	Response
+)
+
 type Option func(*options)
 
 // WithValidator sets a custom validator instance.
@@ -40,57 +101,611 @@ func WithErrorHandler(h ErrorHandler) Option {
 	}
 }
 
-// New creates a new strict middleware that validates the request body.
+// WithOperationErrorHandler reports the errors of the listed operations with their own
+// handler, e.g. to answer a webhook sender in the envelope it expects. Other operations
+// keep the handler set by WithErrorHandler.
+func WithOperationErrorHandler(handlers map[string]ErrorHandler) Option {
+	return func(o *options) {
+		o.errorHandlers = handlers
+	}
+}
+
+// WithRequiredBodies rejects requests to the given operations when the body is absent.
+// See RequiredBodies to derive the operation IDs from the spec.
+func WithRequiredBodies(operationIDs ...string) Option {
+	return func(o *options) {
+		if o.requiredBodies == nil {
+			o.requiredBodies = make(map[string]bool)
+		}
+		for _, id := range operationIDs {
+			o.requiredBodies[id] = true
+		}
+	}
+}
+
+// WithSkippedContentTypes sets the request media types whose body is never validated.
+// It replaces the default list of application/octet-stream, text/plain and multipart/form-data.
+func WithSkippedContentTypes(mediaTypes ...string) Option {
+	return func(o *options) {
+		o.skippedTypes = make(map[string]bool)
+		for _, mt := range mediaTypes {
+			o.skippedTypes[strings.ToLower(mt)] = true
+		}
+	}
+}
+
+// WithMaxBodySizes limits the size in bytes of streamed request bodies per operation ID.
+// Requests announcing a larger Content-Length are rejected up front; otherwise reading
+// past the limit fails with an *http.MaxBytesError. See MaxBodySizes to derive the
+// limits from the spec.
+func WithMaxBodySizes(limits map[string]int64) Option {
+	return func(o *options) {
+		o.maxBodySizes = limits
+	}
+}
+
+// WithFailOpen lets requests through when their arguments cannot be inspected, instead of
+// rejecting them. Either way the unexpected shape is logged.
+func WithFailOpen(failOpen bool) Option {
+	return func(o *options) {
+		o.failOpen = failOpen
+	}
+}
+
+// WithSensitiveFields masks the submitted value of the given fields in every error
+// reported by the middleware. A field is named either by its JSON name, matching it in
+// any type, or as TypeName.jsonName. See SensitiveFields to derive them from the spec.
+func WithSensitiveFields(fields ...string) Option {
+	return func(o *options) {
+		if o.sensitive == nil {
+			o.sensitive = make(map[string]bool)
+		}
+		for _, f := range fields {
+			o.sensitive[f] = true
+		}
+	}
+}
+
+// WithMaxErrors caps the number of field errors reported for a single request; the
+// number of omitted errors is still reported. Zero, the default, reports every error.
+func WithMaxErrors(n int) Option {
+	return func(o *options) {
+		o.maxErrors = n
+	}
+}
+
+// WithFailFast reports only the first broken rule of a request instead of collecting all
+// of them. It is shorthand for WithMaxErrors(1).
+func WithFailFast() Option {
+	return WithMaxErrors(1)
+}
+
+// WithErrorPropagation returns request validation errors to the strict handler instead of
+// writing the response with the ErrorHandler, so that the ResponseErrorHandlerFunc of the
+// generated strict handler and the middlewares wrapping this one see them. Broken rules
+// are returned as a *ValidationError; the other errors are those the ErrorHandler would
+// receive, such as ErrMissingBody or an *UnknownFieldsError. NewProblem maps any of them
+// to a response.
+func WithErrorPropagation() Option {
+	return func(o *options) {
+		o.propagate = true
+	}
+}
+
+// WithRequestFilter only validates the requests for which fn returns true, e.g. to save
+// the cost of validation on read-heavy APIs. Responses are validated regardless.
+func WithRequestFilter(fn func(*http.Request) bool) Option {
+	return func(o *options) {
+		o.filter = fn
+	}
+}
+
+// WithMethods only validates the requests using one of the given HTTP methods, e.g.
+// POST, PUT and PATCH to check request bodies but not the parameters of reads.
+func WithMethods(methods ...string) Option {
+	allowed := make(map[string]bool)
+	for _, m := range methods {
+		allowed[strings.ToUpper(m)] = true
+	}
+	return WithRequestFilter(func(r *http.Request) bool {
+		return allowed[r.Method]
+	})
+}
+
+// WithResponseValidation also validates the responses returned by the handlers: the
+// response object itself or, for responses declaring headers, its Body and Headers
+// fields. An invalid response is returned to the strict handler as a *ValidationError
+// so that its response error handler reports it.
+func WithResponseValidation() Option {
+	return func(o *options) {
+		o.responses = true
+	}
+}
+
+// WithTagName sets the struct tag holding the rules of one direction, e.g. to validate
+// requests and responses with the profiles generated for readOnly and writeOnly
+// properties. Defaults to "validate". When the directions use different tags,
+// responses get a validator of their own: register custom validations with
+// WithValidation so both validators have them.
+func WithTagName(d Direction, tag string) Option {
+	return func(o *options) {
+		if o.tagNames == nil {
+			o.tagNames = make(map[Direction]string)
+		}
+		o.tagNames[d] = tag
+	}
+}
+
+// WithDirectionalTags validates requests and responses with the tags the directional
+// profiles generate, validateRequest and validateResponse, so that readOnly fields are
+// optional in requests and writeOnly fields unchecked in responses.
+func WithDirectionalTags() Option {
+	request, response := WithTagName(Request, rules.RequestTag), WithTagName(Response, rules.ResponseTag)
+	return func(o *options) {
+		request(o)
+		response(o)
+	}
+}
+
+// WithValidation registers a custom validation under tag on every validator used by
+// the middleware.
+func WithValidation(tag string, fn validator.Func) Option {
+	return func(o *options) {
+		if o.validations == nil {
+			o.validations = make(map[string]validator.Func)
+		}
+		o.validations[tag] = fn
+	}
+}
+
+// WithValidations registers each custom validation of fns under its tag, e.g. the
+// GeneratedValidations table emitted by the registrations command. They take precedence
+// over the validations the middleware registers itself, such as regex.
+func WithValidations(fns map[string]validator.Func) Option {
+	return func(o *options) {
+		for tag, fn := range fns {
+			WithValidation(tag, fn)(o)
+		}
+	}
+}
+
+// WithStructValidations registers each struct-level validation of fns on the struct type
+// it is keyed by, on every validator used by the middleware, e.g. the
+// GeneratedStructValidations table emitted by the registrations command for the
+// x-validate-expr rules of a spec.
+func WithStructValidations(fns map[reflect.Type]validator.StructLevelFunc) Option {
+	return func(o *options) {
+		if o.structValidations == nil {
+			o.structValidations = make(map[reflect.Type]validator.StructLevelFunc)
+		}
+		maps.Copy(o.structValidations, fns)
+	}
+}
+
+// WithFieldNameTag names the fields of reported errors after the first of the given
+// struct tags a field has, e.g. "form" to match the names of query and form parameters.
+// Defaults to "json"; fields without any of the tags are named after the Go field.
+func WithFieldNameTag(tags ...string) Option {
+	return WithFieldNameFunc(func(fld reflect.StructField) string {
+		for _, tag := range tags {
+			if name := strings.SplitN(fld.Tag.Get(tag), ",", 2)[0]; name != "" && name != "-" {
+				return name
+			}
+		}
+		return ""
+	})
+}
+
+// WithFieldNameFunc names the fields of reported errors with fn, for naming schemes a
+// struct tag cannot express. An empty name falls back to the Go field name.
+func WithFieldNameFunc(fn validator.TagNameFunc) Option {
+	return func(o *options) {
+		o.fieldName = fn
+	}
+}
+
+// WithOperationValidators validates the request objects of the listed operations with
+// the given functions instead of the struct tags, typically the OperationValidators
+// table emitted by the validators command. Other operations keep tag validation.
+func WithOperationValidators(validators map[string]func(context.Context, any) error) Option {
+	return func(o *options) {
+		o.generated = validators
+	}
+}
+
+// New creates a new strict middleware that validates the request body, the Body field of
+// the request objects of the operations. Their Params field is never validated, its tags
+// included: parameters are checked against the spec by WithSpecValidation.
 func New(opts ...Option) StrictMiddlewareFunc {
-	o := &options{}
+	o := newOptions(opts...)
+
+	return func(f StrictHandlerFunc, operationID string) StrictHandlerFunc {
+		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, args any) (any, error) {
+			if o.bypassed(ctx) {
+				return f(ctx, w, r, args)
+			}
+			validated := args
+			if o.filter == nil || o.filter(r) {
+				start := time.Now()
+				var err error
+				validated, err = o.validateRequest(w, r, operationID, args)
+				o.metrics.observe(operationID, time.Since(start), err)
+				if reflErr, ok := as[*ReflectionError](err); ok {
+					o.logReflectionError(ctx, operationID, reflErr)
+					if o.failOpen {
+						return f(ctx, w, r, args)
+					}
+				}
+				if err != nil {
+					o.logFailure(ctx, operationID, err)
+					if o.propagate {
+						return nil, err
+					}
+					o.handlerFor(operationID)(w, r, err)
+					return nil, nil
+				}
+			}
+
+			resp, err := f(ctx, w, r, validated)
+			if err == nil && o.responses {
+				if err := o.validateResponse(ctx, operationID, resp); err != nil {
+					o.logFailure(ctx, operationID, err)
+					return nil, err
+				}
+			}
+			return resp, err
+		}
+	}
+}
+
+// newOptions applies opts over the defaults and sets up the validators.
+func newOptions(opts ...Option) *options {
+	o := &options{
+		skippedTypes: map[string]bool{
+			"application/octet-stream": true,
+			"text/plain":               true,
+			"multipart/form-data":      true,
+		},
+	}
 	for _, opt := range opts {
 		opt(o)
 	}
 
+	o.customValidator = o.validator != nil
+	if o.shared != nil {
+		// The shared validators are already configured; only read them.
+		o.validator, o.responseValidator = o.shared.o.validator, o.shared.o.responseValidator
+		o.customValidator = o.shared.o.customValidator
+	} else {
+		o.buildValidators()
+	}
+	o.warmUp()
+	o.markRuleFree()
+
+	if o.errorHandler == nil {
+		o.errorHandler = textHandler
+	}
+
+	return o
+}
+
+// handlerFor returns the ErrorHandler of operationID.
+func (o *options) handlerFor(operationID string) ErrorHandler {
+	if h, ok := o.errorHandlers[operationID]; ok {
+		return h
+	}
+	return o.errorHandler
+}
+
+// validateRequest checks the request to operationID, returning the args to hand to the
+// next handler or the error to report to the client.
+func (o *options) validateRequest(w http.ResponseWriter, r *http.Request, operationID string, args any) (any, error) {
+	validated, err := o.validateArgs(w, r, operationID, args)
+	if o.specOperations[operationID] {
+		err = o.withSpecErrors(r, operationID, err)
+	}
+	if o.cel != nil && o.cel.bodies[operationID] != nil {
+		err = o.withCELErrors(r, operationID, args, err)
+	}
+	return validated, err
+}
+
+// validateArgs checks the request to operationID against the struct tags or generated
+// validators. Panics raised while inspecting args are recovered and reported as a
+// *ReflectionError.
+func (o *options) validateArgs(w http.ResponseWriter, r *http.Request, operationID string, args any) (_ any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = &ReflectionError{Type: reflect.TypeOf(args), Reason: fmt.Sprint(p)}
+		}
+	}()
+
+	p := o.planFor(operationID, args)
+	if limit, ok := o.maxBodySizes[operationID]; ok {
+		if r.ContentLength > limit {
+			return nil, &http.MaxBytesError{Limit: limit}
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		args = limitBody(w, p, args, limit)
+	}
+
+	if err := o.checkUnknownFields(r, operationID); err != nil {
+		return nil, err
+	}
+
+	if fn, ok := o.generated[operationID]; ok {
+		if err := fn(r.Context(), args); err != nil {
+			return nil, o.completeError(operationID, err)
+		}
+		return args, nil
+	}
+
+	if p.body == nil {
+		return args, nil
+	}
+	val := reflect.ValueOf(args)
+	if p.pointer {
+		if val.IsNil() {
+			return args, nil
+		}
+		val = val.Elem()
+	}
+	bodyField := val.FieldByIndex(p.body)
+	if bodyField.IsZero() {
+		if o.requiredBodies[operationID] {
+			return nil, ErrMissingBody
+		}
+		return args, nil
+	}
+	if p.structBody && !p.ruleFree && o.validatesContentType(r) {
+		if err := o.validateStruct(r.Context(), o.validator, operationID, bodyField); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// buildValidators creates and configures the request and response validators.
+func (o *options) buildValidators() {
 	if o.validator == nil {
 		o.validator = validator.New()
 	}
+	if o.fieldName == nil {
+		WithFieldNameTag("json")(o)
+	}
+	o.configure(o.validator)
+	if tag := o.tagNames[Request]; tag != "" {
+		o.validator.SetTagName(tag)
+	}
 
-	// Get the name from the json tag.
-	o.validator.RegisterTagNameFunc(func(fld reflect.StructField) string {
-		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
-		if name == "-" {
-			return ""
+	o.responseValidator = o.validator
+	if o.tagNames[Response] != o.tagNames[Request] {
+		o.responseValidator = validator.New()
+		o.configure(o.responseValidator)
+		if tag := o.tagNames[Response]; tag != "" {
+			o.responseValidator.SetTagName(tag)
 		}
-		return name
-	})
+	}
+}
+
+// configure registers the field names and custom validations on v.
+func (o *options) configure(v *validator.Validate) {
+	v.RegisterTagNameFunc(o.fieldName)
 
 	// Custom validator for regexp
-	_ = o.validator.RegisterValidation("regex", func(fl validator.FieldLevel) bool {
-		pattern := fl.Param()
-		value := fl.Field().String()
-		match, err := regexp.MatchString(pattern, value)
+	_ = v.RegisterValidation("regex", func(fl validator.FieldLevel) bool {
+		// Compiled once per pattern rather than on every request.
+		re, err := patterns.Compile(fl.Param())
 		if err != nil {
 			return false
 		}
-		return match
+		return re.MatchString(fl.Field().String())
+	})
+	_ = v.RegisterValidation("multipleof", multipleOf)
+	_ = v.RegisterValidation("oneofnumber", oneOfNumber)
+	// datetime replaces the validation of validator/v10, which panics on the time.Time
+	// and openapi_types.Date fields of the date formats.
+	_ = v.RegisterValidation("datetime", func(fl validator.FieldLevel) bool {
+		return parsesAs(fl, fl.Param())
+	})
+	_ = v.RegisterValidation("rfc3339", func(fl validator.FieldLevel) bool {
+		return parsesAs(fl, time.RFC3339)
+	})
+	// base64 and base64url replace the validations of validator/v10, which fail on the
+	// []byte fields of format: byte, decoded by encoding/json already.
+	_ = v.RegisterValidation("base64", func(fl validator.FieldLevel) bool {
+		return encoded(fl, base64.StdEncoding)
+	})
+	_ = v.RegisterValidation("base64url", func(fl validator.FieldLevel) bool {
+		return encoded(fl, base64.URLEncoding, base64.RawURLEncoding)
+	})
+	_ = v.RegisterValidation("iso8601duration", func(fl validator.FieldLevel) bool {
+		return durationPattern.MatchString(fl.Field().String())
+	})
+	_ = v.RegisterValidation("idn_hostname", func(fl validator.FieldLevel) bool {
+		return idnHostname(fl.Field().String())
 	})
 
-	if o.errorHandler == nil {
-		o.errorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-			http.Error(w, "Validation failed", http.StatusBadRequest)
+	for tag, fn := range o.validations {
+		_ = v.RegisterValidation(tag, fn)
+	}
+	for t, fn := range o.structValidations {
+		v.RegisterStructValidation(fn, reflect.New(t).Elem().Interface())
+	}
+}
+
+// multipleOf validates the multipleof rule generated from multipleOf: the number must be
+// an integral multiple of the param, within the precision of a float64.
+func multipleOf(fl validator.FieldLevel) bool {
+	n, err := strconv.ParseFloat(fl.Param(), 64)
+	if err != nil || n <= 0 {
+		return false
+	}
+	var x float64
+	switch f := fl.Field(); f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x = float64(f.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x = float64(f.Uint())
+	case reflect.Float32, reflect.Float64:
+		x = f.Float()
+	default:
+		return false
+	}
+	q := x / n
+	return math.Abs(q-math.Round(q)) < 1e-9
+}
+
+// oneOfNumber validates the oneofnumber rule generated from the enum of a number, which
+// the oneof of validator/v10 rejects on floats: the number must be one of the space
+// separated values of the param, parsed to the precision of the field.
+func oneOfNumber(fl validator.FieldLevel) bool {
+	var x float64
+	bits := 64
+	switch f := fl.Field(); f.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x = float64(f.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x = float64(f.Uint())
+	case reflect.Float32:
+		x, bits = f.Float(), 32
+	case reflect.Float64:
+		x = f.Float()
+	default:
+		return false
+	}
+	for value := range strings.FieldsSeq(fl.Param()) {
+		if n, err := strconv.ParseFloat(value, bits); err == nil && n == x {
+			return true
 		}
 	}
+	return false
+}
 
-	return func(f StrictHandlerFunc, operationID string) StrictHandlerFunc {
-		return func(ctx context.Context, w http.ResponseWriter, r *http.Request, args any) (any, error) {
-			val := reflect.ValueOf(args)
-			if val.Kind() == reflect.Struct {
-				bodyField := val.FieldByName("Body")
-				if bodyField.IsValid() && !bodyField.IsZero() {
-					if err := o.validator.Struct(bodyField.Interface()); err != nil {
-						o.errorHandler(w, r, err)
-						return nil, nil
-					}
-				}
+var timeType = reflect.TypeFor[time.Time]()
+
+// durationPattern matches the ISO 8601 durations of the duration format.
+var durationPattern = regexp.MustCompile(patterns.Duration)
+
+// parsesAs reports whether the string field of fl is a time in layout. RFC 3339 allows the
+// T and Z of date-times in lower case, which time.Parse does not. A time.Time field, or a
+// struct embedding one such as openapi_types.Date, is parsed already.
+func parsesAs(fl validator.FieldLevel, layout string) bool {
+	f := fl.Field()
+	if t := f.Type(); f.Kind() == reflect.Struct {
+		return t == timeType || t.NumField() == 1 && t.Field(0).Anonymous && t.Field(0).Type == timeType
+	}
+	if f.Kind() != reflect.String {
+		return false
+	}
+	_, err := time.Parse(layout, strings.ToUpper(f.String()))
+	return err == nil
+}
+
+// encoded reports whether the string field of fl is encoded with one of encodings. A
+// []byte field is decoded already.
+func encoded(fl validator.FieldLevel, encodings ...*base64.Encoding) bool {
+	f := fl.Field()
+	if f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Uint8 {
+		return true
+	}
+	if f.Kind() != reflect.String {
+		return false
+	}
+	return slices.ContainsFunc(encodings, func(enc *base64.Encoding) bool {
+		_, err := enc.DecodeString(f.String())
+		return err == nil
+	})
+}
+
+// idnHostname reports whether s is an internationalized host name, best-effort: labels of
+// letters, marks, digits and inner hyphens, checked without the IDNA 2008 rules. Lengths
+// are counted in characters, which their punycode never has fewer of.
+func idnHostname(s string) bool {
+	if s == "" || utf8.RuneCountInString(s) > 253 {
+		return false
+	}
+	for label := range strings.SplitSeq(strings.TrimSuffix(s, "."), ".") {
+		if label == "" || utf8.RuneCountInString(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if r != '-' && !unicode.In(r, unicode.L, unicode.M, unicode.Nd) {
+				return false
 			}
+		}
+	}
+	return true
+}
 
-			return f(ctx, w, r, args)
+// validateResponse checks the response returned for operationID.
+func (o *options) validateResponse(ctx context.Context, operationID string, resp any) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = &ReflectionError{Type: reflect.TypeOf(resp), Reason: fmt.Sprint(p)}
+		}
+	}()
+
+	val := reflect.Indirect(reflect.ValueOf(resp))
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+	targets := [2]reflect.Value{val}
+	if p := responsePlanFor(val.Type()); p.headers != nil {
+		targets[0] = val.FieldByIndex(p.headers)
+		if p.body != nil {
+			targets[1] = val.FieldByIndex(p.body)
 		}
 	}
+	for _, target := range targets {
+		target = reflect.Indirect(target)
+		if target.Kind() != reflect.Struct {
+			continue
+		}
+		if err := o.validateStruct(ctx, o.responseValidator, operationID, target); err != nil {
+			if ve, ok := err.(*ValidationError); ok {
+				ve.Response = true
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	readerType          = reflect.TypeFor[io.Reader]()
+	readCloserType      = reflect.TypeFor[io.ReadCloser]()
+	multipartReaderType = reflect.TypeFor[multipart.Reader]()
+)
+
+// validatesContentType reports whether the media type of the request is validated.
+func (o *options) validatesContentType(r *http.Request) bool {
+	ct := r.Header.Get("Content-Type")
+	if ct == "application/json" {
+		return !o.skippedTypes[ct]
+	}
+	mt, _, err := mime.ParseMediaType(ct)
+	return err != nil || !o.skippedTypes[mt]
+}
+
+// limitBody returns a copy of args, of plan p, whose streamed Body fails once more than
+// limit bytes are read.
+func limitBody(w http.ResponseWriter, p *plan, args any, limit int64) any {
+	if !p.streamBody {
+		return args
+	}
+	val := reflect.ValueOf(args)
+	body := val.FieldByIndex(p.body)
+	if body.IsNil() {
+		return args
+	}
+	rc, ok := body.Interface().(io.ReadCloser)
+	if !ok {
+		rc = io.NopCloser(body.Interface().(io.Reader))
+	}
+
+	cp := reflect.New(val.Type()).Elem()
+	cp.Set(val)
+	cp.FieldByIndex(p.body).Set(reflect.ValueOf(http.MaxBytesReader(w, rc, limit)))
+	return cp.Interface()
 }
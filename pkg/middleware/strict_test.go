@@ -0,0 +1,1402 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-playground/validator/v10"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBody struct {
+	Name string `json:"name" validate:"required,min=3"`
+}
+
+type testRequest struct {
+	Body *testBody
+}
+
+// serve runs args through the middleware and reports whether the handler was reached.
+func serve(t *testing.T, mw StrictMiddlewareFunc, operationID string, args any) (*httptest.ResponseRecorder, bool) {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Content-Type", "application/json")
+	return serveRequest(t, mw, operationID, r, args)
+}
+
+func serveRequest(t *testing.T, mw StrictMiddlewareFunc, operationID string, r *http.Request, args any) (*httptest.ResponseRecorder, bool) {
+	t.Helper()
+	called := false
+	handler := mw(func(ctx context.Context, w http.ResponseWriter, r *http.Request, args any) (any, error) {
+		called = true
+		return nil, nil
+	}, operationID)
+
+	w := httptest.NewRecorder()
+	_, err := handler(r.Context(), w, r, args)
+	require.NoError(t, err)
+	return w, called
+}
+
+func TestValidBody(t *testing.T) {
+	w, called := serve(t, New(), "CreateTest", testRequest{Body: &testBody{Name: "valid"}})
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestInvalidBody(t *testing.T) {
+	w, called := serve(t, New(), "CreateTest", testRequest{Body: &testBody{Name: "x"}})
+	assert.False(t, called)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRequiredBodies(t *testing.T) {
+	mw := New(WithRequiredBodies("CreateTest"))
+
+	w, called := serve(t, mw, "CreateTest", testRequest{})
+	assert.False(t, called)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	_, called = serve(t, mw, "UpdateTest", testRequest{})
+	assert.True(t, called)
+}
+
+func TestNonJSONBodiesAreSkipped(t *testing.T) {
+	text := "plain"
+	_, called := serve(t, New(), "PostText", struct{ Body *string }{Body: &text})
+	assert.True(t, called, "scalar bodies are not structs")
+
+	_, called = serve(t, New(), "Upload", struct{ Body io.Reader }{Body: strings.NewReader("data")})
+	assert.True(t, called, "streams are never decoded")
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	_, called = serveRequest(t, New(), "CreateTest", r, testRequest{Body: &testBody{Name: "x"}})
+	assert.True(t, called, "skipped media type")
+
+	_, called = serveRequest(t, New(WithSkippedContentTypes()), "CreateTest", r, testRequest{Body: &testBody{Name: "x"}})
+	assert.False(t, called)
+}
+
+func TestMaxBodySizes(t *testing.T) {
+	mw := New(WithMaxBodySizes(map[string]int64{"Upload": 4}))
+
+	var readErr error
+	handler := mw(func(ctx context.Context, w http.ResponseWriter, r *http.Request, args any) (any, error) {
+		_, readErr = io.ReadAll(args.(struct{ Body io.Reader }).Body)
+		return nil, nil
+	}, "Upload")
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("too large"))
+	r.Header.Set("Content-Type", "application/octet-stream")
+	r.ContentLength = -1
+	_, err := handler(r.Context(), httptest.NewRecorder(), r, struct{ Body io.Reader }{Body: r.Body})
+	require.NoError(t, err)
+	var tooLarge *http.MaxBytesError
+	assert.ErrorAs(t, readErr, &tooLarge)
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("too large"))
+	w, called := serveRequest(t, mw, "Upload", r, struct{ Body io.Reader }{Body: r.Body})
+	assert.False(t, called)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+}
+
+func TestMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	mw := New(WithMetrics(reg))
+	serve(t, mw, "CreateTest", testRequest{Body: &testBody{Name: "x"}})
+	serve(t, New(WithMetrics(reg)), "CreateTest", testRequest{Body: &testBody{Name: "valid"}})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP oapi_validator_failures_total Number of requests rejected by the validation middleware.
+# TYPE oapi_validator_failures_total counter
+oapi_validator_failures_total{operation="CreateTest",rule="min"} 1
+`), "oapi_validator_failures_total"))
+	assert.Equal(t, 1, testutil.CollectAndCount(reg, "oapi_validator_validation_duration_seconds"))
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	serve(t, New(WithLogger(logger), WithLogLevel(slog.LevelWarn)), "CreateTest", testRequest{Body: &testBody{Name: "x"}})
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "WARN", entry["level"])
+	assert.Equal(t, "CreateTest", entry["operation_id"])
+	assert.Equal(t, []any{"name"}, entry["fields"])
+	assert.Equal(t, []any{"min"}, entry["rules"])
+	assert.NotContains(t, buf.String(), `"x"`)
+}
+
+func TestPointerArgs(t *testing.T) {
+	_, called := serve(t, New(), "CreateTest", &testRequest{Body: &testBody{Name: "x"}})
+	assert.False(t, called)
+}
+
+func TestUnexpectedArgsShape(t *testing.T) {
+	// Body is promoted through a nil embedded pointer: reaching it panics.
+	type embedded struct{ Body *testBody }
+	args := struct{ *embedded }{}
+
+	w, called := serve(t, New(), "CreateTest", args)
+	assert.False(t, called)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	_, called = serve(t, New(WithFailOpen(true)), "CreateTest", args)
+	assert.True(t, called)
+}
+
+func TestSensitiveFieldsAreRedacted(t *testing.T) {
+	type credentials struct {
+		Login    string `json:"login" validate:"min=3"`
+		Password string `json:"password" validate:"min=8"`
+	}
+	var buf bytes.Buffer
+	mw := New(
+		WithSensitiveFields("credentials.password"),
+		WithErrorHandler(ProblemHandler),
+		WithLogger(slog.New(slog.NewJSONHandler(&buf, nil))),
+	)
+	w, _ := serve(t, mw, "Login", struct{ Body *credentials }{Body: &credentials{Login: "me", Password: "hunter2"}})
+
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Validation failed",
+		"status": 400,
+		"errors": [
+			{"field": "login", "rule": "min", "param": "3", "value": "me", "message": "must be at least 3 characters"},
+			{"field": "password", "rule": "min", "param": "8", "redacted": true, "message": "must be at least 8 characters"}
+		]
+	}`, w.Body.String())
+	assert.NotContains(t, buf.String(), "hunter2")
+}
+
+func TestMaxErrors(t *testing.T) {
+	type many struct {
+		A string `json:"a" validate:"required"`
+		B string `json:"b" validate:"required"`
+		C string `json:"c" validate:"required"`
+	}
+	args := struct{ Body *many }{Body: &many{}}
+
+	var gotErr error
+	handler := WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) { gotErr = err })
+
+	serve(t, New(handler, WithMaxErrors(2)), "Create", args)
+	var ve *ValidationError
+	require.ErrorAs(t, gotErr, &ve)
+	assert.Len(t, ve.Fields, 2)
+	assert.Equal(t, 1, ve.Omitted)
+	assert.Equal(t, "1 more errors omitted", NewProblem(gotErr).Detail)
+
+	serve(t, New(handler, WithFailFast()), "Create", args)
+	require.ErrorAs(t, gotErr, &ve)
+	assert.Equal(t, []FieldError{{Field: "a", Rule: "required", Value: "", Message: "is required"}}, ve.Fields)
+
+	serve(t, New(handler), "Create", args)
+	require.ErrorAs(t, gotErr, &ve)
+	assert.Len(t, ve.Fields, 3)
+	assert.Zero(t, ve.Omitted)
+}
+
+func TestProblemRequestID(t *testing.T) {
+	type requestIDKey struct{}
+	handler := NewProblemHandler(WithRequestID(func(ctx context.Context) string {
+		id, _ := ctx.Value(requestIDKey{}).(string)
+		return id
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, "req-42"))
+	w := httptest.NewRecorder()
+	handler(w, r, ErrMissingBody)
+
+	var p Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, "req-42", p.RequestID)
+	assert.Equal(t, http.StatusBadRequest, p.Status)
+}
+
+// strictEmail fails to encode invalid addresses, like openapi_types.Email.
+type strictEmail string
+
+func (e strictEmail) MarshalJSON() ([]byte, error) {
+	if !strings.Contains(string(e), "@") {
+		return nil, errors.New("invalid email")
+	}
+	return json.Marshal(string(e))
+}
+
+func TestProblemNamedValue(t *testing.T) {
+	err := NewValidator().Validate(&struct {
+		Email strictEmail `json:"email" validate:"email"`
+	}{Email: "jane"})
+
+	w := httptest.NewRecorder()
+	ProblemHandler(w, httptest.NewRequest(http.MethodPost, "/", nil), err)
+
+	var p Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	require.Len(t, p.Errors, 1)
+	assert.Equal(t, "jane", p.Errors[0].Value)
+}
+
+func TestProblemContentNegotiation(t *testing.T) {
+	err := &ValidationError{Fields: []FieldError{{Field: "name", Rule: "min", Param: "3", Value: "x"}}}
+
+	for _, tc := range []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "application/problem+json", `"errors":[{"field":"name","rule":"min","param":"3","value":"x"}]`},
+		{"application/xml", "application/problem+xml", `<error><field>name</field><rule>min</rule><param>3</param><value>x</value></error>`},
+		{"text/plain;q=0.5, application/xml;q=0.1", "text/plain; charset=utf-8", "Validation failed\nname: min=3\n"},
+		{"image/png", "application/problem+json", `"status":400`},
+	} {
+		t.Run(tc.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set("Accept", tc.accept)
+			w := httptest.NewRecorder()
+			ProblemHandler(w, r, err)
+			assert.Equal(t, tc.contentType, w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Body.String(), tc.body)
+		})
+	}
+}
+
+func TestResponseValidation(t *testing.T) {
+	type headers struct {
+		XRateLimit int `validate:"max=1000"`
+	}
+	type response struct {
+		Body    testBody
+		Headers headers
+	}
+	mw := New(WithResponseValidation())
+
+	respond := func(resp any) error {
+		handler := mw(func(ctx context.Context, w http.ResponseWriter, r *http.Request, args any) (any, error) {
+			return resp, nil
+		}, "GetTest")
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		_, err := handler(r.Context(), httptest.NewRecorder(), r, struct{}{})
+		return err
+	}
+
+	assert.NoError(t, respond(response{Body: testBody{Name: "valid"}, Headers: headers{XRateLimit: 10}}))
+	assert.NoError(t, respond(testBody{Name: "valid"}))
+
+	var ve *ValidationError
+	require.ErrorAs(t, respond(response{Body: testBody{Name: "valid"}, Headers: headers{XRateLimit: 5000}}), &ve)
+	assert.True(t, ve.Response)
+	assert.Equal(t, "XRateLimit", ve.Fields[0].Field)
+	assert.Equal(t, http.StatusInternalServerError, NewProblem(ve).Status)
+
+	require.ErrorAs(t, respond(&testBody{Name: "x"}), &ve)
+	assert.Equal(t, "name", ve.Fields[0].Field)
+}
+
+func TestStructOverride(t *testing.T) {
+	errReserved := errors.New("reserved name")
+	reserved := func(ctx context.Context, b testBody) error {
+		if b.Name == "admin" || b.Name == "x" {
+			return errReserved
+		}
+		return nil
+	}
+	var gotErr error
+	handler := WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) { gotErr = err })
+
+	mw := New(handler, WithStructOverride(reserved, Augment))
+	serve(t, mw, "CreateTest", testRequest{Body: &testBody{Name: "admin"}})
+	assert.ErrorIs(t, gotErr, errReserved)
+	serve(t, mw, "CreateTest", testRequest{Body: &testBody{Name: "x"}})
+	assert.ErrorAs(t, gotErr, new(*ValidationError), "tags run first")
+
+	mw = New(handler, WithStructOverride(reserved, Replace))
+	serve(t, mw, "CreateTest", testRequest{Body: &testBody{Name: "x"}})
+	assert.ErrorIs(t, gotErr, errReserved)
+	_, called := serve(t, mw, "CreateTest", testRequest{Body: &testBody{Name: ""}})
+	assert.True(t, called, "tags are skipped")
+}
+
+func TestTagNamePerDirection(t *testing.T) {
+	type user struct {
+		ID   string `json:"id" validateRequest:"omitempty" validateResponse:"required"`
+		Name string `json:"name" validateRequest:"required,notadmin" validateResponse:"required"`
+	}
+	notAdmin := func(fl validator.FieldLevel) bool { return fl.Field().String() != "admin" }
+	mw := New(
+		WithTagName(Request, "validateRequest"),
+		WithTagName(Response, "validateResponse"),
+		WithValidation("notadmin", notAdmin),
+		WithResponseValidation(),
+	)
+
+	call := func(req, resp user) (bool, error) {
+		called := false
+		handler := mw(func(ctx context.Context, w http.ResponseWriter, r *http.Request, args any) (any, error) {
+			called = true
+			return resp, nil
+		}, "CreateUser")
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		_, err := handler(r.Context(), httptest.NewRecorder(), r, struct{ Body *user }{Body: &req})
+		return called, err
+	}
+
+	called, err := call(user{Name: "new"}, user{ID: "1", Name: "new"})
+	assert.True(t, called)
+	assert.NoError(t, err)
+
+	called, _ = call(user{Name: "admin"}, user{})
+	assert.False(t, called)
+
+	_, err = call(user{Name: "new"}, user{Name: "new"})
+	var ve *ValidationError
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, "id", ve.Fields[0].Field)
+}
+
+func TestOperationTypes(t *testing.T) {
+	mw := New(WithOperationTypes(map[string]any{"CreateTest": testRequest{}}))
+
+	_, called := serve(t, mw, "CreateTest", testRequest{Body: &testBody{Name: "x"}})
+	assert.False(t, called)
+	_, called = serve(t, mw, "CreateTest", testRequest{Body: &testBody{Name: "valid"}})
+	assert.True(t, called)
+	_, called = serve(t, mw, "CreateTest", &testRequest{Body: &testBody{Name: "x"}})
+	assert.False(t, called, "unregistered types are still inspected")
+}
+
+func TestValidate(t *testing.T) {
+	handler := Validate(
+		func(req testRequest) *testBody { return req.Body },
+		func(ctx context.Context, req testRequest) (string, error) { return "created", nil },
+	)
+
+	resp, err := handler(context.Background(), testRequest{Body: &testBody{Name: "valid"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "created", resp)
+
+	_, err = handler(context.Background(), testRequest{Body: &testBody{Name: "x"}})
+	var ve *ValidationError
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, "name", ve.Fields[0].Field)
+
+	_, err = handler(context.Background(), testRequest{})
+	assert.NoError(t, err)
+}
+
+func TestRequiredBodiesFromSpec(t *testing.T) {
+	doc := loadSpec(t, `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /tests:
+    post:
+      operationId: CreateTest
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {type: object}
+      responses: {"201": {description: created}}
+    put:
+      operationId: UpdateTest
+      requestBody:
+        content:
+          application/json:
+            schema: {type: object}
+      responses: {"200": {description: ok}}
+`)
+	assert.Equal(t, []string{"CreateTest"}, RequiredBodies(doc))
+}
+
+func TestMaxBodySizesFromSpec(t *testing.T) {
+	doc := loadSpec(t, `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /files:
+    post:
+      operationId: Upload
+      requestBody:
+        content:
+          application/octet-stream:
+            schema: {type: string, format: binary, maxLength: 1048576}
+      responses: {"201": {description: created}}
+`)
+	assert.Equal(t, map[string]int64{"Upload": 1048576}, MaxBodySizes(doc))
+}
+
+func loadSpec(t *testing.T, data string) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(strings.TrimSpace(data)))
+	require.NoError(t, err)
+	return doc
+}
+
+func TestUnknownFieldRejection(t *testing.T) {
+	doc := loadSpec(t, `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /tests:
+    post:
+      operationId: CreateTest
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              additionalProperties: false
+              properties:
+                name: {type: string}
+                tags:
+                  type: array
+                  items:
+                    type: object
+                    additionalProperties: false
+                    properties:
+                      key: {type: string}
+      responses: {"201": {description: created}}
+`)
+	var gotErr error
+	mw := New(WithUnknownFieldRejection(doc), WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+	}))
+	handler := CaptureBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveRequest(t, mw, "CreateTest", r, testRequest{Body: &testBody{Name: "valid"}})
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"valid","admin":true,"tags":[{"key":"a","value":"b"}]}`))
+	r.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var unknown *UnknownFieldsError
+	require.ErrorAs(t, gotErr, &unknown)
+	assert.Equal(t, []string{"/admin", "/tags/0/value"}, unknown.Fields)
+}
+
+func TestSensitiveFieldsFromSpec(t *testing.T) {
+	doc := loadSpec(t, `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    user-account:
+      type: object
+      properties:
+        login: {type: string}
+        password: {type: string, format: password}
+        apiToken: {type: string, x-sensitive: true}
+`)
+	assert.Equal(t, []string{"UserAccount.apiToken", "UserAccount.password"}, SensitiveFields(doc))
+}
+
+func TestOperationValidators(t *testing.T) {
+	var got *ValidationError
+	mw := New(
+		WithOperationValidators(map[string]func(context.Context, any) error{
+			"CreateTest": func(_ context.Context, args any) error {
+				if args.(testRequest).Body.Name == "valid" {
+					return nil
+				}
+				return &ValidationError{Fields: []FieldError{{Field: "name", Rule: "min", Param: "3"}}}
+			},
+		}),
+		WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			require.ErrorAs(t, err, &got)
+			w.WriteHeader(http.StatusBadRequest)
+		}),
+	)
+
+	_, called := serve(t, mw, "CreateTest", testRequest{Body: &testBody{Name: "valid"}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "CreateTest", testRequest{Body: &testBody{Name: "invalid but long enough"}})
+	assert.False(t, called)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "CreateTest", got.OperationID)
+
+	// Operations without a generated validator keep tag validation.
+	_, called = serve(t, mw, "UpdateTest", testRequest{Body: &testBody{Name: "x"}})
+	assert.False(t, called)
+}
+
+func TestFieldNameTag(t *testing.T) {
+	type params struct {
+		Limit int    `form:"limit" json:"limit" validate:"max=10"`
+		Sort  string `form:"sort_by" json:"sortBy" validate:"oneof=asc desc"`
+	}
+	var got *ValidationError
+	handler := WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		require.ErrorAs(t, err, &got)
+	})
+	args := struct{ Body *params }{Body: &params{Limit: 20, Sort: "up"}}
+
+	serve(t, New(handler), "ListTests", args)
+	assert.Equal(t, "sortBy", got.Fields[1].Field)
+
+	serve(t, New(handler, WithFieldNameTag("form")), "ListTests", args)
+	assert.Equal(t, "sort_by", got.Fields[1].Field)
+
+	serve(t, New(handler, WithFieldNameFunc(func(fld reflect.StructField) string {
+		return strings.ToUpper(fld.Name)
+	})), "ListTests", args)
+	assert.Equal(t, "LIMIT", got.Fields[0].Field)
+}
+
+func TestBypass(t *testing.T) {
+	args := testRequest{Body: &testBody{Name: "x"}}
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r = r.WithContext(SkipValidation(r.Context()))
+
+	_, called := serveRequest(t, New(), "CreateTest", r, args)
+	assert.False(t, called, "bypass must be explicitly allowed")
+
+	_, called = serveRequest(t, New(AllowBypass(true)), "CreateTest", r, args)
+	assert.True(t, called)
+
+	_, called = serve(t, New(AllowBypass(true)), "CreateTest", args)
+	assert.False(t, called)
+}
+
+func TestWarmup(t *testing.T) {
+	type address struct {
+		Street string `json:"street" validate:"required"`
+	}
+	type user struct {
+		Addresses []address `json:"addresses" validate:"dive"`
+	}
+	type broken struct {
+		Street string `json:"street" validate:"no_such_rule"`
+	}
+
+	assert.NotPanics(t, func() {
+		New(WithWarmup(struct{ Body *user }{}))
+	})
+	assert.Panics(t, func() {
+		New(WithWarmup(struct{ Body *[]broken }{}))
+	})
+	assert.Panics(t, func() {
+		New(WithOperationTypes(map[string]any{"CreateBroken": struct{ Body *broken }{}}))
+	})
+}
+
+func TestDefaultErrorMessages(t *testing.T) {
+	type order struct {
+		Items    []string `json:"items" validate:"min=1"`
+		Quantity int      `json:"quantity" validate:"max=10"`
+		Status   string   `json:"status" validate:"oneof=new paid shipped"`
+	}
+	w, _ := serve(t, New(), "CreateOrder", struct{ Body *order }{Body: &order{Quantity: 11, Status: "lost"}})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Validation failed\n"+
+		"items must be at least 1 item\n"+
+		"quantity must be at most 10\n"+
+		"status must be one of: new, paid, shipped\n", w.Body.String())
+}
+
+func TestMessageCatalog(t *testing.T) {
+	doc := loadSpec(t, `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    signup:
+      type: object
+      properties:
+        login: {type: string, x-error-message: pick a login of 3 to 20 letters}
+        age: {type: integer, x-error-message: {min: you must be an adult}}
+`)
+	catalog := ErrorMessages(doc)
+	assert.Equal(t, MessageCatalog{
+		"Signup.login": {"": "pick a login of 3 to 20 letters"},
+		"Signup.age":   {"min": "you must be an adult"},
+	}, catalog)
+
+	type Signup struct {
+		Login string `json:"login" validate:"min=3"`
+		Age   int    `json:"age" validate:"min=18,max=130"`
+	}
+	var got *ValidationError
+	mw := New(WithMessageCatalog(catalog), WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		require.ErrorAs(t, err, &got)
+	}))
+
+	serve(t, mw, "Signup", struct{ Body *Signup }{Body: &Signup{Login: "me", Age: 12}})
+	assert.Equal(t, "pick a login of 3 to 20 letters", got.Fields[0].Message)
+	assert.Equal(t, "you must be an adult", got.Fields[1].Message)
+
+	serve(t, mw, "Signup", struct{ Body *Signup }{Body: &Signup{Login: "alice", Age: 200}})
+	assert.Equal(t, "must be at most 130", got.Fields[0].Message)
+}
+
+func TestErrorPropagation(t *testing.T) {
+	mw := New(WithErrorPropagation(), WithRequiredBodies("CreateTest"), WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		t.Error("the error handler must not be called")
+	}))
+	handler := mw(func(ctx context.Context, w http.ResponseWriter, r *http.Request, args any) (any, error) {
+		return "created", nil
+	}, "CreateTest")
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	w := httptest.NewRecorder()
+
+	resp, err := handler(r.Context(), w, r, testRequest{Body: &testBody{Name: "x"}})
+	var ve *ValidationError
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, "CreateTest", ve.OperationID)
+	assert.Nil(t, resp)
+
+	_, err = handler(r.Context(), w, r, testRequest{})
+	assert.ErrorIs(t, err, ErrMissingBody)
+
+	resp, err = handler(r.Context(), w, r, testRequest{Body: &testBody{Name: "valid"}})
+	require.NoError(t, err)
+	assert.Equal(t, "created", resp)
+	assert.Zero(t, w.Body.Len())
+}
+
+func TestSpecValidation(t *testing.T) {
+	doc := loadSpec(t, `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      parameters:
+        - {name: limit, in: query, schema: {type: integer, maximum: 10}}
+      requestBody:
+        content:
+          application/json:
+            schema:
+              oneOf:
+                - {type: object, required: [bark], properties: {bark: {type: boolean}}, additionalProperties: false}
+                - {type: object, required: [meow], properties: {meow: {type: boolean}}, additionalProperties: false}
+      responses:
+        '200': {description: OK}
+`)
+	var got *ValidationError
+	mw := New(WithSpecValidation(doc, "CreatePet"), WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		require.ErrorAs(t, err, &got)
+	}))
+	request := func(query, body string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/pets"+query, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		CaptureBody(http.HandlerFunc(func(w http.ResponseWriter, captured *http.Request) { r = captured })).ServeHTTP(nil, r)
+		return r
+	}
+
+	_, called := serveRequest(t, mw, "CreatePet", request("", `{"bark": true}`), testRequest{Body: &testBody{Name: "valid"}})
+	assert.True(t, called)
+
+	_, called = serveRequest(t, mw, "CreatePet", request("?limit=20", `{"bark": true, "meow": true}`), testRequest{Body: &testBody{Name: "x"}})
+	assert.False(t, called)
+	require.Len(t, got.Fields, 3)
+	assert.Equal(t, "name", got.Fields[0].Field)
+	assert.Equal(t, "limit", got.Fields[1].Field)
+	assert.Equal(t, "maximum", got.Fields[1].Rule)
+	assert.Equal(t, "oneOf", got.Fields[2].Rule)
+
+	// Operations not listed are only validated with tags.
+	_, called = serveRequest(t, mw, "UpdatePet", request("?limit=20", `{}`), testRequest{Body: &testBody{Name: "valid"}})
+	assert.True(t, called)
+}
+
+func TestSpecValidationSensitive(t *testing.T) {
+	doc := loadSpec(t, `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /users:
+    post:
+      operationId: createUser
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/CreateUser'}
+      responses:
+        '201': {description: Created}
+components:
+  schemas:
+    CreateUser:
+      type: object
+      x-validate-cel: ["password != 'hunter2'"]
+      properties:
+        password: {type: string, format: password, minLength: 12}
+`)
+	type createUser struct {
+		Password string `json:"password"`
+	}
+	for name, opt := range map[string]Option{"spec": WithSpecValidation(doc, "CreateUser"), "cel": WithCELValidation(doc)} {
+		t.Run(name, func(t *testing.T) {
+			var got *ValidationError
+			mw := New(WithSpec(doc), opt, WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+				require.ErrorAs(t, err, &got)
+			}))
+			r := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"password": "hunter2"}`))
+			r.Header.Set("Content-Type", "application/json")
+			CaptureBody(http.HandlerFunc(func(w http.ResponseWriter, captured *http.Request) { r = captured })).ServeHTTP(nil, r)
+
+			_, called := serveRequest(t, mw, "CreateUser", r, struct{ Body *createUser }{Body: &createUser{Password: "hunter2"}})
+			assert.False(t, called)
+			require.NotEmpty(t, got.Fields)
+			for _, fe := range got.Fields {
+				assert.Equal(t, "password", fe.Field)
+				assert.True(t, fe.Redacted)
+				assert.Nil(t, fe.Value)
+			}
+		})
+	}
+}
+
+func TestFieldDocs(t *testing.T) {
+	doc := loadSpec(t, `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Shipment:
+      type: object
+      externalDocs: {url: https://docs.example.com/shipments}
+      properties:
+        weight: {type: integer, title: Weight, description: Weight of the parcel in grams.}
+        carrier:
+          type: string
+          description: Carrier code.
+          externalDocs: {url: https://docs.example.com/carriers}
+        note: {type: string}
+`)
+	docs := FieldDocumentation(doc)
+	assert.Equal(t, FieldDocs{
+		"Shipment.weight":  {Title: "Weight", Description: "Weight of the parcel in grams.", URL: "https://docs.example.com/shipments"},
+		"Shipment.carrier": {Description: "Carrier code.", URL: "https://docs.example.com/carriers"},
+		"Shipment.note":    {URL: "https://docs.example.com/shipments"},
+	}, docs)
+
+	type Shipment struct {
+		Weight int `json:"weight" validate:"min=1"`
+	}
+	w, _ := serve(t, New(WithFieldDocs(docs), WithErrorHandler(ProblemHandler)), "Ship", struct{ Body *Shipment }{Body: &Shipment{}})
+	assert.Contains(t, w.Body.String(), `"title":"Weight","description":"Weight of the parcel in grams.","docs":"https://docs.example.com/shipments"`)
+}
+
+func TestMethods(t *testing.T) {
+	mw := New(WithMethods(http.MethodPost, "patch"))
+	args := testRequest{Body: &testBody{Name: "x"}}
+
+	_, called := serveRequest(t, mw, "CreateTest", httptest.NewRequest(http.MethodPost, "/", nil), args)
+	assert.False(t, called)
+	_, called = serveRequest(t, mw, "PatchTest", httptest.NewRequest(http.MethodPatch, "/", nil), args)
+	assert.False(t, called)
+	_, called = serveRequest(t, mw, "DeleteTest", httptest.NewRequest(http.MethodDelete, "/", nil), args)
+	assert.True(t, called)
+
+	mw = New(WithRequestFilter(func(r *http.Request) bool { return r.Header.Get("X-Canary") == "" }))
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-Canary", "1")
+	_, called = serveRequest(t, mw, "CreateTest", r, args)
+	assert.True(t, called)
+}
+
+func TestSharedValidator(t *testing.T) {
+	shared := NewValidator(WithValidation("even", func(fl validator.FieldLevel) bool {
+		return fl.Field().Int()%2 == 0
+	}))
+	type pair struct {
+		Count int `json:"count" validate:"even"`
+	}
+	args := struct{ Body *pair }{Body: &pair{Count: 3}}
+
+	// Building middlewares concurrently with validation must not race on the validator.
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			mw := New(WithSharedValidator(shared), WithFieldNameTag("form"))
+			_, called := serve(t, mw, "CreatePair", args)
+			assert.False(t, called)
+		})
+	}
+	wg.Wait()
+}
+
+func TestOperationErrorHandler(t *testing.T) {
+	mw := New(WithOperationErrorHandler(map[string]ErrorHandler{
+		"ReceiveWebhook": func(w http.ResponseWriter, r *http.Request, err error) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			_, _ = io.WriteString(w, `{"accepted":false}`)
+		},
+	}))
+	args := testRequest{Body: &testBody{Name: "x"}}
+
+	w, _ := serve(t, mw, "ReceiveWebhook", args)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, `{"accepted":false}`, w.Body.String())
+
+	w, _ = serve(t, mw, "CreateTest", args)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWithSpec(t *testing.T) {
+	doc := loadSpec(t, `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /tests:
+    post:
+      operationId: createTest
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/testBody'}
+      responses:
+        '200': {description: OK}
+components:
+  schemas:
+    testBody:
+      type: object
+      properties:
+        name: {type: string, minLength: 3, x-error-message: too short}
+`)
+	type TestBody struct {
+		Name string `json:"name" validate:"min=3"`
+	}
+	type request struct{ Body *TestBody }
+	mw := New(WithSpec(doc), WithErrorHandler(ProblemHandler))
+
+	w, _ := serve(t, mw, "CreateTest", request{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = serve(t, mw, "CreateTest", request{Body: &TestBody{Name: "x"}})
+	assert.Contains(t, w.Body.String(), `"message":"too short"`)
+}
+
+func TestArtifact(t *testing.T) {
+	doc := loadSpec(t, `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /tests:
+    post:
+      operationId: CreateTest
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/testBody'}
+      responses: {"201": {description: created}}
+components:
+  schemas:
+    testBody:
+      type: object
+      additionalProperties: false
+      properties:
+        name: {type: string, minLength: 3, x-error-message: too short}
+        children: {type: array, items: {$ref: '#/components/schemas/testBody'}}
+`)
+	var buf bytes.Buffer
+	require.NoError(t, WriteArtifact(&buf, NewArtifact(doc)))
+	artifact, err := ReadArtifact(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, NewArtifact(doc), artifact)
+
+	type TestBody struct {
+		Name string `json:"name" validate:"min=3"`
+	}
+	type request struct{ Body *TestBody }
+	var gotErr error
+	mw := New(WithArtifact(artifact), WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+		ProblemHandler(w, r, err)
+	}))
+	handler := CaptureBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveRequest(t, mw, "CreateTest", r, request{Body: &TestBody{Name: "valid"}})
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"valid","children":[{"name":"child","admin":true}]}`))
+	r.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	var unknown *UnknownFieldsError
+	require.ErrorAs(t, gotErr, &unknown)
+	assert.Equal(t, []string{"/children/0/admin"}, unknown.Fields)
+
+	w, _ := serve(t, mw, "CreateTest", request{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = serve(t, mw, "CreateTest", request{Body: &TestBody{Name: "x"}})
+	assert.Contains(t, w.Body.String(), `"message":"too short"`)
+
+	_, err = ReadArtifact(strings.NewReader("not an artifact"))
+	assert.Error(t, err)
+}
+
+func TestValidatorValidate(t *testing.T) {
+	// Echo's Validator interface.
+	var v interface{ Validate(i any) error } = NewValidator(WithSensitiveFields("name"))
+
+	assert.NoError(t, v.Validate(&testBody{Name: "valid"}))
+
+	var ve *ValidationError
+	require.ErrorAs(t, v.Validate(testBody{Name: "x"}), &ve)
+	assert.Equal(t, []FieldError{{Field: "name", Rule: "min", Param: "3", Redacted: true, Message: "must be at least 3 characters"}}, ve.Fields)
+
+	var invalid *validator.InvalidValidationError
+	assert.ErrorAs(t, v.Validate((*testBody)(nil)), &invalid)
+}
+
+func TestValidatorValidateStruct(t *testing.T) {
+	// Gin's binding.StructValidator interface.
+	var v interface {
+		ValidateStruct(any) error
+		Engine() any
+	} = NewValidator()
+
+	assert.NoError(t, v.ValidateStruct(&testBody{Name: "valid"}))
+	assert.NoError(t, v.ValidateStruct("not a struct"))
+
+	err := v.ValidateStruct([]*testBody{{Name: "valid"}, {Name: "x"}})
+	var ve *ValidationError
+	require.ErrorAs(t, err, &ve)
+	assert.Equal(t, "name", ve.Fields[0].Field)
+	assert.IsType(t, &validator.Validate{}, v.Engine())
+}
+
+func TestValidations(t *testing.T) {
+	type order struct {
+		SKU string `json:"sku" validate:"regex=^[a-z]+$"`
+	}
+	calls := 0
+	mw := New(WithValidations(map[string]validator.Func{
+		"regex": func(fl validator.FieldLevel) bool {
+			calls++
+			return fl.Field().String() == "abc"
+		},
+	}))
+
+	_, called := serve(t, mw, "CreateOrder", struct{ Body *order }{Body: &order{SKU: "abc"}})
+	assert.True(t, called)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMultipleOf(t *testing.T) {
+	type order struct {
+		Quantity int     `json:"quantity" validate:"multipleof=5"`
+		Price    float64 `json:"price" validate:"multipleof=0.01"`
+	}
+	mw := New()
+
+	_, called := serve(t, mw, "CreateOrder", struct{ Body *order }{Body: &order{Quantity: 10, Price: 19.99}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "CreateOrder", struct{ Body *order }{Body: &order{Quantity: 7, Price: 0.015}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\n"+
+		"quantity must be a multiple of 5\n"+
+		"price must be a multiple of 0.01\n", w.Body.String())
+}
+
+func TestDateFormats(t *testing.T) {
+	type date struct{ time.Time }
+	type order struct {
+		Day      string    `json:"day" validate:"datetime=2006-01-02"`
+		At       string    `json:"at" validate:"rfc3339"`
+		Opens    string    `json:"opens" validate:"datetime=15:04:05Z07:00"`
+		Shipped  time.Time `json:"shipped" validate:"rfc3339"`
+		Delivery date      `json:"delivery" validate:"datetime=2006-01-02"`
+	}
+	mw := New()
+
+	_, called := serve(t, mw, "CreateOrder", struct{ Body *order }{Body: &order{
+		Day: "2024-02-29", At: "2024-01-01t10:00:00.5z", Opens: "09:30:00+01:00",
+	}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "CreateOrder", struct{ Body *order }{Body: &order{
+		Day: "2023-02-29", At: "2024-01-01 10:00", Opens: "25:00:00Z",
+	}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\n"+
+		"day must be a date or time in the layout 2006-01-02\n"+
+		"at must be a valid RFC 3339 date-time\n"+
+		"opens must be a date or time in the layout 15:04:05Z07:00\n", w.Body.String())
+}
+
+func TestHostnameFormats(t *testing.T) {
+	type server struct {
+		Host    string `json:"host" validate:"hostname_rfc1123"`
+		IDNHost string `json:"idnHost" validate:"idn_hostname"`
+	}
+	mw := New()
+
+	_, called := serve(t, mw, "CreateServer", struct{ Body *server }{Body: &server{Host: "api.example.com", IDNHost: "bücher.example"}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "CreateServer", struct{ Body *server }{Body: &server{Host: "api_example.com", IDNHost: "-bücher.example"}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\n"+
+		"host must be a valid host name\n"+
+		"idnHost must be a valid host name\n", w.Body.String())
+}
+
+func TestBase64(t *testing.T) {
+	type upload struct {
+		Data    string `json:"data" validate:"base64"`
+		Token   string `json:"token" validate:"base64url"`
+		Decoded []byte `json:"decoded" validate:"base64"`
+	}
+	mw := New()
+
+	_, called := serve(t, mw, "Upload", struct{ Body *upload }{Body: &upload{Data: "aGk/Pz8=", Token: "aGk_Pz8", Decoded: []byte("hi")}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "Upload", struct{ Body *upload }{Body: &upload{Data: "aGk_Pz8=", Token: "aGk/Pz8="}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\n"+
+		"data must be valid base64\n"+
+		"token must be valid base64\n", w.Body.String())
+}
+
+func TestContent(t *testing.T) {
+	type upload struct {
+		Payload string `json:"payload" validate:"json"`
+		Digest  string `json:"digest" validate:"hexadecimal"`
+		Key     string `json:"key" validate:"base32"`
+	}
+	mw := New()
+
+	_, called := serve(t, mw, "Upload", struct{ Body *upload }{Body: &upload{Payload: `{"a": 1}`, Digest: "0fa9", Key: "NBUQ===="}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "Upload", struct{ Body *upload }{Body: &upload{Payload: `{"a":`, Digest: "0fz9", Key: "nbuq"}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\n"+
+		"payload must be valid JSON\n"+
+		"digest must be hexadecimal\n"+
+		"key must be valid base32\n", w.Body.String())
+}
+
+func TestDuration(t *testing.T) {
+	type job struct {
+		Timeout string `json:"timeout" validate:"iso8601duration"`
+	}
+	mw := New()
+
+	for _, d := range []string{"P1Y2M3D", "PT1H30M", "PT0.5S", "P2W", "P1DT12H", "P1M"} {
+		_, called := serve(t, mw, "CreateJob", struct{ Body *job }{Body: &job{Timeout: d}})
+		assert.True(t, called, d)
+	}
+	for _, d := range []string{"P", "PT", "P1H", "P1W2D", "1D", "PT1S2M", "P1DT"} {
+		w, called := serve(t, mw, "CreateJob", struct{ Body *job }{Body: &job{Timeout: d}})
+		assert.False(t, called, d)
+		assert.Equal(t, "Validation failed\ntimeout must be an ISO 8601 duration\n", w.Body.String())
+	}
+}
+
+func TestDirectionalTags(t *testing.T) {
+	type user struct {
+		ID       string `json:"id" validate:"required" validateRequest:"" validateResponse:"required"`
+		Password string `json:"password" validate:"required" validateRequest:"required" validateResponse:"-"`
+	}
+	mw := New(WithDirectionalTags(), WithResponseValidation())
+	handler := mw(func(ctx context.Context, w http.ResponseWriter, r *http.Request, args any) (any, error) {
+		return user{ID: "1"}, nil
+	}, "CreateUser")
+
+	w := httptest.NewRecorder()
+	_, err := handler(context.Background(), w, httptest.NewRequest(http.MethodPost, "/users", nil), struct{ Body *user }{Body: &user{Password: "secret"}})
+	assert.NoError(t, err, "the request has no id and the response no password")
+}
+
+func TestOmitNil(t *testing.T) {
+	type profile struct {
+		Nickname *string `json:"nickname" validate:"omitnil,min=1"`
+	}
+	mw := New()
+
+	_, called := serve(t, mw, "UpdateProfile", struct{ Body *profile }{Body: &profile{}})
+	assert.True(t, called)
+
+	empty := ""
+	w, called := serve(t, mw, "UpdateProfile", struct{ Body *profile }{Body: &profile{Nickname: &empty}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\nnickname must be at least 1 character\n", w.Body.String())
+}
+
+func TestRequiredIf(t *testing.T) {
+	type pet struct {
+		PetType string  `json:"petType" validate:"required"`
+		Bark    *string `json:"bark" validate:"required_if=PetType dog,omitempty,max=5"`
+	}
+	mw := New()
+
+	_, called := serve(t, mw, "CreatePet", struct{ Body *pet }{Body: &pet{PetType: "cat"}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "CreatePet", struct{ Body *pet }{Body: &pet{PetType: "dog"}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\nbark is required when PetType is dog\n", w.Body.String())
+}
+
+func TestRequiredWith(t *testing.T) {
+	type payment struct {
+		CardNumber     *string `json:"cardNumber"`
+		BillingAddress *string `json:"billingAddress" validate:"required_with=CardNumber,omitempty,max=100"`
+	}
+	mw := New()
+
+	_, called := serve(t, mw, "Pay", struct{ Body *payment }{Body: &payment{}})
+	assert.True(t, called)
+
+	card := "4111"
+	w, called := serve(t, mw, "Pay", struct{ Body *payment }{Body: &payment{CardNumber: &card}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\nbillingAddress is required when CardNumber is present\n", w.Body.String())
+}
+
+func TestOneOfNumber(t *testing.T) {
+	type rating struct {
+		Score float32 `json:"score" validate:"oneofnumber=1 2.5 -3"`
+		Ratio float64 `json:"ratio" validate:"omitempty,oneofnumber=0.1 0.2"`
+	}
+	mw := New()
+
+	_, called := serve(t, mw, "Rate", struct{ Body *rating }{Body: &rating{Score: 2.5, Ratio: 0.1}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "Rate", struct{ Body *rating }{Body: &rating{Score: 2}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\nscore must be one of: 1, 2.5, -3\n", w.Body.String())
+}
+
+func TestIfThenElse(t *testing.T) {
+	type account struct {
+		Kind      string  `json:"kind"`
+		VatNumber *string `json:"vatNumber" validate:"required_if=Kind business,excluded_unless=Kind business,omitempty,max=20"`
+		BirthDate *string `json:"birthDate" validate:"required_unless=Kind business,omitempty"`
+	}
+	mw := New()
+	vat, birth := "FR123", "1990-01-01"
+
+	_, called := serve(t, mw, "Open", struct{ Body *account }{Body: &account{Kind: "business", VatNumber: &vat}})
+	assert.True(t, called)
+	_, called = serve(t, mw, "Open", struct{ Body *account }{Body: &account{Kind: "personal", BirthDate: &birth}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "Open", struct{ Body *account }{Body: &account{Kind: "business"}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\nvatNumber is required when Kind is business\n", w.Body.String())
+
+	w, called = serve(t, mw, "Open", struct{ Body *account }{Body: &account{Kind: "personal", VatNumber: &vat, BirthDate: &birth}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\nvatNumber must be absent unless Kind is business\n", w.Body.String())
+
+	w, called = serve(t, mw, "Open", struct{ Body *account }{Body: &account{Kind: "personal"}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\nbirthDate is required unless Kind is business\n", w.Body.String())
+}
+
+func TestUniqueBy(t *testing.T) {
+	type member struct {
+		Id   string   `json:"id"`
+		Tags []string `json:"tags"`
+	}
+	type team struct {
+		Members []member `json:"members" validate:"required,unique=Id"`
+	}
+	mw := New()
+
+	_, called := serve(t, mw, "CreateTeam", struct{ Body *team }{Body: &team{Members: []member{{Id: "a", Tags: []string{"x"}}, {Id: "b"}}}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "CreateTeam", struct{ Body *team }{Body: &team{Members: []member{{Id: "a"}, {Id: "a"}}}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\nmembers must not contain duplicate items\n", w.Body.String())
+}
+
+type booking struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+func TestStructValidations(t *testing.T) {
+	mw := New(WithStructValidations(map[reflect.Type]validator.StructLevelFunc{
+		reflect.TypeFor[booking](): func(sl validator.StructLevel) {
+			if b := sl.Current().Interface().(booking); b.End <= b.Start {
+				sl.ReportError(b.End, "end", "End", "expr", "end > start")
+			}
+		},
+	}))
+
+	_, called := serve(t, mw, "Book", struct{ Body *booking }{Body: &booking{Start: 1, End: 2}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "Book", struct{ Body *booking }{Body: &booking{Start: 2, End: 1}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\nend must satisfy end > start\n", w.Body.String())
+}
+
+func TestCELValidation(t *testing.T) {
+	spec := `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /bookings:
+    post:
+      operationId: Book
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              x-validate-cel: %s
+              properties:
+                start: {type: integer}
+                end: {type: integer}
+      responses: {"201": {description: created}}
+`
+	mw := New(WithCELValidation(loadSpec(t, fmt.Sprintf(spec, `"end > start"`))))
+
+	_, called := serve(t, mw, "Book", struct{ Body *booking }{Body: &booking{Start: 1, End: 2}})
+	assert.True(t, called)
+
+	w, called := serve(t, mw, "Book", struct{ Body *booking }{Body: &booking{Start: 2, End: 1}})
+	assert.False(t, called)
+	assert.Equal(t, "Validation failed\nend must satisfy end > start\n", w.Body.String())
+
+	assert.Panics(t, func() { WithCELValidation(loadSpec(t, fmt.Sprintf(spec, `"end > stat"`))) })
+}
+
+type benchBody struct {
+	Name  string `json:"name" validate:"required,min=3"`
+	Email string `json:"email" validate:"required,email"`
+	Code  string `json:"code" validate:"omitempty,regex=^[A-Z]{3}-[0-9]{4}$"`
+}
+
+type benchRequest struct {
+	Body *benchBody
+}
+
+type benchResponse struct {
+	Body    benchBody
+	Headers struct {
+		XRateLimit int `validate:"max=1000"`
+	}
+}
+
+// BenchmarkStrictHandler measures the overhead of the middleware on a request and its
+// response, as every request of a service pays it.
+func BenchmarkStrictHandler(b *testing.B) {
+	body := benchBody{Name: "valid", Email: "user@example.com", Code: "ABC-1234"}
+	resp := benchResponse{Body: body}
+	handler := New(WithResponseValidation())(func(ctx context.Context, w http.ResponseWriter, r *http.Request, args any) (any, error) {
+		return resp, nil
+	}, "CreateTest")
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	args := benchRequest{Body: &body}
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := handler(r.Context(), w, r, args); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestRuleFreeOperations(t *testing.T) {
+	type note struct {
+		Text string `json:"text"`
+	}
+	type noteRequest struct {
+		Body *note
+	}
+	mw := New(WithOperationTypes(map[string]any{"CreateNote": noteRequest{}, "CreateTest": testRequest{}}))
+	o := newOptions(WithOperationTypes(map[string]any{"CreateNote": noteRequest{}, "CreateTest": testRequest{}}))
+	assert.True(t, o.plans["CreateNote"].ruleFree)
+	assert.False(t, o.plans["CreateTest"].ruleFree)
+
+	_, called := serve(t, mw, "CreateNote", noteRequest{Body: &note{}})
+	assert.True(t, called)
+	_, called = serve(t, mw, "CreateTest", testRequest{Body: &testBody{Name: "x"}})
+	assert.False(t, called)
+
+	// Struct validations and overrides are rules too.
+	o = newOptions(
+		WithOperationTypes(map[string]any{"CreateNote": noteRequest{}}),
+		WithStructValidations(map[reflect.Type]validator.StructLevelFunc{reflect.TypeFor[note](): func(validator.StructLevel) {}}),
+	)
+	assert.False(t, o.plans["CreateNote"].ruleFree)
+	o = newOptions(
+		WithOperationTypes(map[string]any{"CreateNote": noteRequest{}}),
+		WithValidator(validator.New()),
+	)
+	assert.False(t, o.plans["CreateNote"].ruleFree, "the rules of a custom validator are unknown")
+}
+
+// BenchmarkRejectedRequest measures the cost of rejecting an invalid request, as services
+// pay it under abuse, with the default plain text handler and the problem handler.
+func BenchmarkRejectedRequest(b *testing.B) {
+	for name, h := range map[string]ErrorHandler{"text": nil, "problem": ProblemHandler} {
+		b.Run(name, func(b *testing.B) {
+			var opts []Option
+			if h != nil {
+				opts = append(opts, WithErrorHandler(h))
+			}
+			handler := New(opts...)(func(ctx context.Context, w http.ResponseWriter, r *http.Request, args any) (any, error) {
+				return nil, nil
+			}, "CreateTest")
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.Header.Set("Content-Type", "application/json")
+			args := benchRequest{Body: &benchBody{Name: "x", Email: "not an email", Code: "abc"}}
+
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				w := &discardWriter{header: make(http.Header)}
+				for pb.Next() {
+					if _, err := handler(r.Context(), w, r, args); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
+
+// discardWriter is a ResponseWriter dropping what is written, so that benchmarks only
+// measure the middleware.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardWriter) WriteHeader(int)             {}
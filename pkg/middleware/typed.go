@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"reflect"
+)
+
+// Validate wraps a strict server method so that the body selected by body is validated
+// before next is called, e.g.
+//
+//	createUser := middleware.Validate(
+//		func(req api.CreateUserRequestObject) *api.CreateUserJSONRequestBody { return req.Body },
+//		server.CreateUser,
+//	)
+//
+// Unlike New, it does not inspect the request object at runtime and the compiler checks
+// which field gets validated. A nil body is not validated. An invalid body is returned
+// as a *ValidationError for the strict handler's error handling to report; the options
+// configuring HTTP concerns, such as WithErrorHandler, do not apply.
+func Validate[TReq, TBody, TResp any](body func(TReq) *TBody, next func(context.Context, TReq) (TResp, error), opts ...Option) func(context.Context, TReq) (TResp, error) {
+	o := newOptions(opts...)
+	return func(ctx context.Context, req TReq) (TResp, error) {
+		if b := body(req); b != nil && !o.bypassed(ctx) {
+			if err := o.validateStruct(ctx, o.validator, "", reflect.ValueOf(b)); err != nil {
+				var zero TResp
+				return zero, err
+			}
+		}
+		return next(ctx, req)
+	}
+}
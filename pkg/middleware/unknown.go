@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// UnknownFieldsError lists the JSON Pointers of body properties that the schema does not
+// declare while forbidding additional properties.
+type UnknownFieldsError struct {
+	Fields []string
+	// Omitted is the number of fields left out of Fields, see WithMaxErrors.
+	Omitted int
+}
+
+func (e *UnknownFieldsError) Error() string {
+	msg := "unexpected fields: " + strings.Join(e.Fields, ", ")
+	if e.Omitted > 0 {
+		msg += fmt.Sprintf(" and %d more", e.Omitted)
+	}
+	return msg
+}
+
+// WithUnknownFieldRejection rejects JSON request bodies containing properties that are not
+// declared by a schema with additionalProperties: false. Struct tags cannot express this,
+// so the raw body is checked against the spec; it must be captured with CaptureBody.
+func WithUnknownFieldRejection(doc *openapi3.T) Option {
+	bodies := compileBodies(doc)
+	return func(o *options) {
+		o.bodies = bodies
+	}
+}
+
+// BodyShapes are the layouts of the JSON request bodies, compiled from the spec for the
+// rejection of unknown fields.
+type BodyShapes struct {
+	// Operations maps operation IDs to the index of their body shape in Shapes.
+	Operations map[string]int
+	// Shapes are indexed by Operations and by each other. The first one accepts anything.
+	Shapes []Shape
+}
+
+// Shape is the layout of a JSON value. Properties, Additional and Items are indexes in
+// BodyShapes.Shapes.
+type Shape struct {
+	// Properties are the declared properties, those of allOf members included.
+	Properties map[string]int
+	// Closed forbids undeclared properties; otherwise they have the Additional shape.
+	Closed     bool
+	Additional int
+	Items      int
+}
+
+// compileBodies returns the shapes of the JSON request bodies of doc. Schemas reached
+// more than once, recursive ones included, are compiled into a single shape.
+func compileBodies(doc *openapi3.T) BodyShapes {
+	bodies := BodyShapes{Operations: make(map[string]int), Shapes: []Shape{{}}}
+	index := make(map[*openapi3.Schema]int)
+	var compile func(*openapi3.Schema) int
+	compile = func(s *openapi3.Schema) int {
+		if i, ok := index[s]; ok {
+			return i
+		}
+		i := len(bodies.Shapes)
+		index[s] = i
+		bodies.Shapes = append(bodies.Shapes, Shape{})
+		known, closed, nested := objectShape(s)
+		shape := Shape{Closed: closed}
+		if len(known) > 0 {
+			shape.Properties = make(map[string]int, len(known))
+			// In order, for the same spec to compile to the same artifact.
+			for _, name := range slices.Sorted(maps.Keys(known)) {
+				shape.Properties[name] = compile(known[name])
+			}
+		}
+		if nested != nil {
+			shape.Additional = compile(nested)
+		}
+		if s.Items != nil && s.Items.Value != nil {
+			shape.Items = compile(s.Items.Value)
+		}
+		bodies.Shapes[i] = shape
+		return i
+	}
+	ops := maps.Collect(operations(doc))
+	for _, id := range slices.Sorted(maps.Keys(ops)) {
+		if s := jsonBodySchema(ops[id]); s != nil {
+			bodies.Operations[id] = compile(s)
+		}
+	}
+	return bodies
+}
+
+// valid reports whether every index of b is in range.
+func (b BodyShapes) valid() bool {
+	in := func(i int) bool { return i >= 0 && i < len(b.Shapes) }
+	for _, i := range b.Operations {
+		if !in(i) {
+			return false
+		}
+	}
+	for _, s := range b.Shapes {
+		if !in(s.Additional) || !in(s.Items) {
+			return false
+		}
+		for _, i := range s.Properties {
+			if !in(i) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+type rawBodyKey struct{}
+
+// CaptureBody is a net/http middleware keeping a copy of the request body in the context,
+// for the strict middleware features that need the bytes the client actually sent.
+// It must wrap the generated handler since the strict handler consumes the body.
+func CaptureBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		_ = r.Body.Close()
+		if err != nil {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(data))
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), rawBodyKey{}, data)))
+	})
+}
+
+func rawBody(ctx context.Context) ([]byte, bool) {
+	data, ok := ctx.Value(rawBodyKey{}).([]byte)
+	return data, ok
+}
+
+// jsonBodySchema returns the schema of the operation's JSON request body, if any.
+func jsonBodySchema(op *openapi3.Operation) *openapi3.Schema {
+	if op.RequestBody == nil || op.RequestBody.Value == nil {
+		return nil
+	}
+	for ct, mt := range op.RequestBody.Value.Content {
+		if isJSON(ct) && mt.Schema != nil && mt.Schema.Value != nil {
+			return mt.Schema.Value
+		}
+	}
+	return nil
+}
+
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mt == "application/json" || strings.HasSuffix(mt, "+json")
+}
+
+// unknownFields returns the JSON Pointers of the properties of value undeclared by the
+// shape at index i.
+func (b BodyShapes) unknownFields(i int, value any, pointer string) []string {
+	var fields []string
+	shape := b.Shapes[i]
+	switch v := value.(type) {
+	case map[string]any:
+		for _, name := range slices.Sorted(maps.Keys(v)) {
+			path := pointer + "/" + escapePointer(name)
+			if p, ok := shape.Properties[name]; ok {
+				fields = append(fields, b.unknownFields(p, v[name], path)...)
+			} else if shape.Closed {
+				fields = append(fields, path)
+			} else if shape.Additional != 0 {
+				fields = append(fields, b.unknownFields(shape.Additional, v[name], path)...)
+			}
+		}
+	case []any:
+		if shape.Items != 0 {
+			for i, item := range v {
+				fields = append(fields, b.unknownFields(shape.Items, item, pointer+"/"+strconv.Itoa(i))...)
+			}
+		}
+	}
+	return fields
+}
+
+// objectShape flattens the declared properties of schema and its allOf members. closed
+// reports whether undeclared properties are forbidden; otherwise nested is the schema of
+// additional properties, when one is given.
+func objectShape(schema *openapi3.Schema) (known map[string]*openapi3.Schema, closed bool, nested *openapi3.Schema) {
+	known = make(map[string]*openapi3.Schema)
+	var walk func(*openapi3.Schema)
+	walk = func(s *openapi3.Schema) {
+		for name, ref := range s.Properties {
+			if ref.Value != nil {
+				known[name] = ref.Value
+			}
+		}
+		if s.AdditionalProperties.Has != nil && !*s.AdditionalProperties.Has {
+			closed = true
+		}
+		if ap := s.AdditionalProperties.Schema; ap != nil && ap.Value != nil {
+			nested = ap.Value
+		}
+		for _, ref := range s.AllOf {
+			if ref.Value != nil {
+				walk(ref.Value)
+			}
+		}
+	}
+	walk(schema)
+	return known, closed, nested
+}
+
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// checkUnknownFields re-reads the captured body of a request to operationID.
+func (o *options) checkUnknownFields(r *http.Request, operationID string) error {
+	shape, ok := o.bodies.Operations[operationID]
+	if ct := r.Header.Get("Content-Type"); !ok || (ct != "" && !isJSON(ct)) {
+		return nil
+	}
+	data, ok := rawBody(r.Context())
+	if !ok {
+		return nil
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		// Malformed bodies are reported by the generated decoder.
+		return nil
+	}
+	if fields := o.bodies.unknownFields(shape, value, ""); len(fields) > 0 {
+		err := &UnknownFieldsError{Fields: fields}
+		if o.maxErrors > 0 && len(fields) > o.maxErrors {
+			err.Fields, err.Omitted = fields[:o.maxErrors], len(fields)-o.maxErrors
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// WithWarmup parses the validation rules of the given types, e.g. the generated request
+// and response objects, and of every struct reachable from them when the middleware is
+// built, instead of on the first request using each of them. The types registered with
+// WithOperationTypes are warmed up as well. An invalid rule makes New panic rather than
+// the request exercising it.
+func WithWarmup(types ...any) Option {
+	return func(o *options) {
+		o.warmup = append(o.warmup, types...)
+	}
+}
+
+// warmUp populates the struct caches of the middleware's validators.
+func (o *options) warmUp() {
+	seen := make(map[reflect.Type]bool)
+	for _, v := range o.warmup {
+		structTypes(reflect.TypeOf(v), seen)
+	}
+	for _, p := range o.plans {
+		structTypes(p.argsType, seen)
+	}
+	for t := range seen {
+		warm(o.validator, t)
+		if o.responseValidator != o.validator {
+			warm(o.responseValidator, t)
+		}
+	}
+}
+
+// warm validates the zero value of the struct type t, which caches its rules.
+func warm(v *validator.Validate, t reflect.Type) {
+	_ = v.Struct(reflect.New(t).Interface())
+}
+
+// structTypes adds t and the struct types reachable through its fields to seen.
+func structTypes(t reflect.Type, seen map[reflect.Type]bool) {
+	if t == nil {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		structTypes(t.Elem(), seen)
+	case reflect.Map:
+		structTypes(t.Key(), seen)
+		structTypes(t.Elem(), seen)
+	case reflect.Struct:
+		if seen[t] {
+			return
+		}
+		seen[t] = true
+		for i := range t.NumField() {
+			if f := t.Field(i); f.IsExported() || f.Anonymous {
+				structTypes(f.Type, seen)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Package wireset provides the validation middleware to wire injectors.
+//
+//	func newMiddleware(cfg wireset.Config) middleware.StrictMiddlewareFunc {
+//		wire.Build(wireset.ProviderSet)
+//		return nil
+//	}
+package wireset
+
+import (
+	"log/slog"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/google/wire"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ProviderSet provides a shared *middleware.Validator and the
+// middleware.StrictMiddlewareFunc built from a Config.
+var ProviderSet = wire.NewSet(NewValidator, NewMiddleware)
+
+// Config configures the middleware. Every field is optional.
+type Config struct {
+	// Spec is the document the API was generated from, see middleware.WithSpec.
+	Spec       *openapi3.T
+	Registerer prometheus.Registerer
+	Logger     *slog.Logger
+	Options    []middleware.Option
+}
+
+// NewValidator builds the validator from the options of cfg.
+func NewValidator(cfg Config) *middleware.Validator {
+	return middleware.NewValidator(cfg.Options...)
+}
+
+// NewMiddleware builds the middleware from cfg, validating with v.
+func NewMiddleware(cfg Config, v *middleware.Validator) middleware.StrictMiddlewareFunc {
+	opts := []middleware.Option{middleware.WithSharedValidator(v)}
+	if cfg.Spec != nil {
+		opts = append(opts, middleware.WithSpec(cfg.Spec))
+	}
+	if cfg.Registerer != nil {
+		opts = append(opts, middleware.WithMetrics(cfg.Registerer))
+	}
+	if cfg.Logger != nil {
+		opts = append(opts, middleware.WithLogger(cfg.Logger))
+	}
+	return middleware.New(append(opts, cfg.Options...)...)
+}
@@ -0,0 +1,137 @@
+package oapicodegen
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/patterns"
+	"github.com/hadrienk/oapi-codegen-validator/internal/rules"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/enrich"
+)
+
+// Mismatch is a property whose validate tag disagrees with the Go field oapi-codegen
+// generates for it, so that a rule rejects valid values or accepts invalid ones.
+type Mismatch struct {
+	// Location is the JSON Pointer of the property in the spec, e.g.
+	// "#/components/schemas/User/properties/active".
+	Location string
+	Tag      string
+	Message  string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %s (validate:%q)", m.Location, m.Message, m.Tag)
+}
+
+// Lint enriches doc and returns the properties of its component schemas, nested inline
+// objects included, whose validate tag does not suit the generated field:
+//
+//   - required on a field that is not a pointer, since it is required and not nullable or
+//     has x-go-type-skip-optional-pointer, rejects its zero value, e.g. false, even where
+//     the schema allows it. On a struct, required never fails.
+//   - required on the pointer of a required nullable field rejects null.
+//   - omitempty on a field that is not a pointer skips the zero value, which the other
+//     rules would reject, e.g. 0 for minimum: 1.
+//
+// Fields with x-go-type are skipped, their zero value being unknown.
+func Lint(doc *openapi3.T) ([]Mismatch, error) {
+	if err := enrich.Spec(doc); err != nil {
+		return nil, err
+	}
+	if doc.Components == nil {
+		return nil, nil
+	}
+	var mismatches []Mismatch
+	visited := make(map[*openapi3.Schema]bool)
+	var object func(pointer string, s *openapi3.Schema)
+	object = func(pointer string, s *openapi3.Schema) {
+		if visited[s] {
+			return
+		}
+		visited[s] = true
+		for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+			ref := s.Properties[name]
+			if ref.Value == nil {
+				continue
+			}
+			location := rules.Pointer(pointer, "properties", name)
+			if msg := mismatch(ref.Value, slices.Contains(s.Required, name)); msg != "" {
+				mismatches = append(mismatches, Mismatch{Location: "#" + location, Tag: enrich.Tag(ref.Value), Message: msg})
+			}
+			if ref.Ref == "" {
+				object(location, ref.Value)
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(doc.Components.Schemas)) {
+		if ref := doc.Components.Schemas[name]; ref.Value != nil {
+			object(rules.Pointer("/components/schemas", name), ref.Value)
+		}
+	}
+	return mismatches, nil
+}
+
+// mismatch describes how the validate tag of the property s disagrees with its field, if
+// it does.
+func mismatch(s *openapi3.Schema, required bool) string {
+	if _, ok := s.Extensions["x-go-type"]; ok {
+		return ""
+	}
+	tag := enrich.Tag(s)
+	lead, _, _ := strings.Cut(tag, ",")
+	skip, _ := s.Extensions["x-go-type-skip-optional-pointer"].(bool)
+	pointer := !skip && (!required || s.Nullable)
+	zero, valid, scalar := zeroValue(s)
+
+	switch {
+	case lead == "required" && pointer && required && s.Nullable:
+		return "required rejects null, which nullable allows"
+	case lead == "required" && !pointer && s.Type.Is(openapi3.TypeObject) && s.AdditionalProperties.Schema == nil && s.AdditionalProperties.Has == nil:
+		return "required never fails on the struct field, which is not a pointer: a missing object is accepted"
+	case lead == "required" && !pointer && scalar && valid:
+		msg := fmt.Sprintf("required rejects %s, which the schema allows, since the field is not a pointer", zero)
+		if omit, _ := s.Extensions["x-omitempty"].(bool); omit {
+			msg += ", and x-omitempty drops it from the JSON"
+		}
+		return msg
+	case lead == "omitempty" && !pointer && scalar && !valid:
+		return fmt.Sprintf("omitempty skips %s, which the rules reject, since the field is not a pointer", zero)
+	}
+	return ""
+}
+
+// zeroValue returns the JSON of the zero value of the field of the scalar schema s, and
+// whether s allows it.
+func zeroValue(s *openapi3.Schema) (zero string, valid, scalar bool) {
+	var value any
+	switch {
+	case s.Type.Is(openapi3.TypeBoolean):
+		zero, value = "false", false
+		valid = true
+	case s.Type.Is(openapi3.TypeInteger), s.Type.Is(openapi3.TypeNumber):
+		zero, value = "0", 0.0
+		valid = (s.Min == nil || *s.Min < 0 || *s.Min == 0 && !s.ExclusiveMin) &&
+			(s.Max == nil || *s.Max > 0 || *s.Max == 0 && !s.ExclusiveMax)
+	case s.Type.Is(openapi3.TypeString):
+		zero, value = `""`, ""
+		valid = s.MinLength == 0 && s.Format == ""
+		if s.Pattern != "" {
+			re, err := patterns.CompileECMA(s.Pattern)
+			valid = valid && err == nil && re.MatchString("")
+		}
+	default:
+		return "", false, false
+	}
+	if len(s.Enum) > 0 {
+		valid = valid && slices.ContainsFunc(s.Enum, func(v any) bool {
+			if n, ok := v.(int); ok {
+				v = float64(n)
+			}
+			return v == value
+		})
+	}
+	return zero, valid, true
+}
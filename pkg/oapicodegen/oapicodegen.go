@@ -0,0 +1,150 @@
+// Package oapicodegen enables the enrichment of specs in projects running oapi-codegen
+// from Go rather than from its binary. Either the spec is enriched before generating
+// code:
+//
+//	generate := oapicodegen.WithEnrichment(codegen.Generate)
+//	code, err := generate(spec, cfg)
+//
+// or the validate tags are written to an OpenAPI Overlay applied by oapi-codegen, leaving
+// the spec untouched:
+//
+//	output-options:
+//	  overlay:
+//	    path: validate.overlay.yaml
+package oapicodegen
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/enrich"
+	"gopkg.in/yaml.v3"
+)
+
+// WithEnrichment returns generate, such as codegen.Generate, enriching the spec before
+// generating code. The spec is enriched in place.
+func WithEnrichment[C any](generate func(*openapi3.T, C) (string, error)) func(*openapi3.T, C) (string, error) {
+	return func(doc *openapi3.T, cfg C) (string, error) {
+		if err := enrich.Spec(doc); err != nil {
+			return "", fmt.Errorf("enrichment failed: %w", err)
+		}
+		return generate(doc, cfg)
+	}
+}
+
+// Overlay is an OpenAPI Overlay document.
+type Overlay struct {
+	Overlay string          `yaml:"overlay"`
+	Info    OverlayInfo     `yaml:"info"`
+	Actions []OverlayAction `yaml:"actions"`
+}
+
+// OverlayInfo describes an Overlay.
+type OverlayInfo struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+// OverlayAction updates the node at the JSONPath Target with Update.
+type OverlayAction struct {
+	Target string         `yaml:"target"`
+	Update map[string]any `yaml:"update"`
+}
+
+// NewOverlay enriches doc and returns the overlay setting the validate tags of its
+// schemas, where they are declared, on the original spec.
+func NewOverlay(doc *openapi3.T) (*Overlay, error) {
+	schemas, paths := declarations(doc)
+	if err := enrich.Spec(doc); err != nil {
+		return nil, err
+	}
+	o := &Overlay{Overlay: "1.0.0", Info: OverlayInfo{Title: "Validate tags", Version: "1.0.0"}}
+	if doc.Info != nil {
+		o.Info = OverlayInfo{Title: "Validate tags of " + doc.Info.Title, Version: doc.Info.Version}
+	}
+	for _, s := range schemas {
+		if tags, ok := s.Extensions["x-oapi-codegen-extra-tags"]; ok {
+			o.Actions = append(o.Actions, OverlayAction{
+				Target: paths[s],
+				Update: map[string]any{"x-oapi-codegen-extra-tags": tags},
+			})
+		}
+	}
+	return o, nil
+}
+
+// MarshalOverlay enriches doc and returns its overlay, see NewOverlay, as YAML.
+func MarshalOverlay(doc *openapi3.T) ([]byte, error) {
+	o, err := NewOverlay(doc)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(o)
+}
+
+// declarations returns the schemas of doc the enricher may tag, in a stable order, with
+// the JSONPath of their declaration: component schemas, their properties, the properties
+// of inline objects, and the schemas of headers.
+func declarations(doc *openapi3.T) ([]*openapi3.Schema, map[*openapi3.Schema]string) {
+	var schemas []*openapi3.Schema
+	paths := make(map[*openapi3.Schema]string)
+	declare := func(path string, ref *openapi3.SchemaRef) bool {
+		if ref == nil || ref.Value == nil || paths[ref.Value] != "" {
+			return false
+		}
+		schemas = append(schemas, ref.Value)
+		paths[ref.Value] = path
+		return true
+	}
+	var properties func(path string, s *openapi3.Schema)
+	properties = func(path string, s *openapi3.Schema) {
+		for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+			ref := s.Properties[name]
+			if ref.Ref == "" && declare(path+".properties"+member(name), ref) {
+				properties(path+".properties"+member(name), ref.Value)
+			}
+		}
+	}
+
+	c := doc.Components
+	if c == nil {
+		return nil, paths
+	}
+	// Component schemas first, so that referenced schemas are declared there.
+	for _, name := range slices.Sorted(maps.Keys(c.Schemas)) {
+		declare("$.components.schemas"+member(name), c.Schemas[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Schemas)) {
+		if ref := c.Schemas[name]; ref.Value != nil && ref.Ref == "" {
+			properties("$.components.schemas"+member(name), ref.Value)
+		}
+	}
+	headers := func(path string, hs openapi3.Headers) {
+		for _, name := range slices.Sorted(maps.Keys(hs)) {
+			if h := hs[name]; h.Ref == "" && h.Value != nil && h.Value.Schema != nil && h.Value.Schema.Ref == "" {
+				declare(path+member(name)+".schema", h.Value.Schema)
+			}
+		}
+	}
+	headers("$.components.headers", c.Headers)
+	for _, name := range slices.Sorted(maps.Keys(c.Responses)) {
+		if r := c.Responses[name]; r.Ref == "" && r.Value != nil {
+			headers("$.components.responses"+member(name)+".headers", r.Value.Headers)
+		}
+	}
+	return schemas, paths
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// member returns the JSONPath selector of the member name.
+func member(name string) string {
+	if identifier.MatchString(name) {
+		return "." + name
+	}
+	return "['" + strings.ReplaceAll(name, "'", `\'`) + "']"
+}
@@ -0,0 +1,120 @@
+package oapicodegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/pkg/enrich"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const spec = `
+openapi: 3.0.0
+info: {title: Users, version: 1.2.0}
+paths: {}
+components:
+  schemas:
+    Code:
+      type: string
+      maxLength: 8
+    User:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string, minLength: 3}
+        code: {$ref: '#/components/schemas/Code'}
+        address:
+          type: object
+          properties:
+            zip-code: {type: string, pattern: '^[0-9]{5}$'}
+  headers:
+    X-Request-Id:
+      required: true
+      schema: {type: string, format: uuid}
+`
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	require.NoError(t, err)
+	return doc
+}
+
+func TestWithEnrichment(t *testing.T) {
+	type config struct{ PackageName string }
+	generate := WithEnrichment(func(doc *openapi3.T, cfg config) (string, error) {
+		tags := doc.Components.Schemas["User"].Value.Properties["name"].Value.Extensions["x-oapi-codegen-extra-tags"]
+		return cfg.PackageName + ": " + tags.(map[string]any)["validate"].(string), nil
+	})
+
+	code, err := generate(loadSpec(t), config{PackageName: "api"})
+	require.NoError(t, err)
+	assert.Equal(t, "api: required,min=3", code)
+}
+
+func TestMarshalOverlay(t *testing.T) {
+	data, err := MarshalOverlay(loadSpec(t))
+	require.NoError(t, err)
+	assert.Equal(t, strings.TrimPrefix(`
+overlay: 1.0.0
+info:
+    title: Validate tags of Users
+    version: 1.2.0
+actions:
+    - target: $.components.schemas.Code
+      update:
+        x-oapi-codegen-extra-tags:
+            validate: omitempty,max=8
+    - target: $.components.schemas.User.properties.address.properties['zip-code']
+      update:
+        x-oapi-codegen-extra-tags:
+            validate: omitempty,regex=^[0-9]{5}$
+    - target: $.components.schemas.User.properties.name
+      update:
+        x-oapi-codegen-extra-tags:
+            validate: required,min=3
+    - target: $.components.headers['X-Request-Id'].schema
+      update:
+        x-oapi-codegen-extra-tags:
+            validate: required,uuid
+`, "\n"), string(data))
+}
+
+func TestLint(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(`
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths: {}
+components:
+  schemas:
+    Settings:
+      type: object
+      required: [active, retries, name, nickname, address, code, kind]
+      properties:
+        active: {type: boolean, x-omitempty: true}
+        retries: {type: integer, minimum: 0}
+        name: {type: string, minLength: 1}
+        nickname: {type: string, nullable: true, maxLength: 20}
+        address:
+          type: object
+          properties:
+            floor: {type: integer, minimum: 1, x-go-type-skip-optional-pointer: true}
+            zip: {type: string, pattern: '^[0-9]{5}$'}
+        code: {type: string, x-go-type: Code}
+        kind: {type: string, enum: [a, b]}
+`))
+	require.NoError(t, err)
+
+	mismatches, err := Lint(doc)
+	require.NoError(t, err)
+	assert.Equal(t, []Mismatch{
+		{Location: "#/components/schemas/Settings/properties/active", Tag: "required", Message: "required rejects false, which the schema allows, since the field is not a pointer, and x-omitempty drops it from the JSON"},
+		{Location: "#/components/schemas/Settings/properties/address", Tag: "required", Message: "required never fails on the struct field, which is not a pointer: a missing object is accepted"},
+		{Location: "#/components/schemas/Settings/properties/address/properties/floor", Tag: "omitempty,gte=1", Message: "omitempty skips 0, which the rules reject, since the field is not a pointer"},
+		{Location: "#/components/schemas/Settings/properties/nickname", Tag: "required,max=20", Message: "required rejects null, which nullable allows"},
+	}, mismatches)
+	// retries, whose minimum allows 0, is checked by gte=0 rather than required.
+	assert.Equal(t, "gte=0", enrich.Tag(doc.Components.Schemas["Settings"].Value.Properties["retries"].Value))
+}
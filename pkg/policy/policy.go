@@ -0,0 +1,331 @@
+// Package policy checks the schemas of a spec against organization-wide constraint
+// policies, such as "every string must have a maxLength", defined in a config file:
+//
+//	policies:
+//	  - name: bounded-strings
+//	    message: strings must have a maxLength
+//	    match: {type: string}
+//	    require: [maxLength]
+//	    unless: [enum, format]
+//	  - name: uuid-ids
+//	    match: {type: string, property: "(?i)^id$|Id$"}
+//	    require: [format=uuid]
+//	  - name: bounded-arrays
+//	    match: {type: array}
+//	    require: [maxItems]
+package policy
+
+import (
+	"fmt"
+	"iter"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/hadrienk/oapi-codegen-validator/internal/patterns"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a set of policies.
+type Config struct {
+	Policies []Policy `yaml:"policies"`
+}
+
+// Policy requires keywords on the schemas it matches. Keywords are the names of the
+// constraints of a schema, such as maxLength, pattern, format or maxItems, or a keyword
+// with its value, such as format=uuid.
+type Policy struct {
+	Name string `yaml:"name"`
+	// Message describes the policy in the violations, the missing keywords by default.
+	Message string `yaml:"message"`
+	Match   Match  `yaml:"match"`
+	// Require lists the keywords the matched schemas must all have.
+	Require []string `yaml:"require"`
+	// Unless lists keywords exempting the schemas having any of them, e.g. enum for a
+	// string length policy.
+	Unless []string `yaml:"unless"`
+}
+
+// Match selects schemas. Empty fields match every schema.
+type Match struct {
+	Type   string `yaml:"type"`
+	Format string `yaml:"format"`
+	// Property is a regular expression matching the names of the properties whose
+	// schemas are selected.
+	Property string `yaml:"property"`
+
+	property *regexp.Regexp
+}
+
+// Violation is a schema breaking a policy.
+type Violation struct {
+	Policy string
+	// Location is the JSON Pointer of the schema in the spec, e.g.
+	// "#/components/schemas/User/properties/name".
+	Location string
+	Message  string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s (%s)", v.Location, v.Message, v.Policy)
+}
+
+// LoadConfig reads a Config from a YAML file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Rule is a policy check run on every schema of a spec, see Schemas. Policies of a
+// Config are rules, custom ones can be written in Go.
+type Rule interface {
+	Check(loc Location, s *openapi3.Schema) []Violation
+}
+
+// RuleFunc adapts a function to a Rule.
+type RuleFunc func(loc Location, s *openapi3.Schema) []Violation
+
+func (f RuleFunc) Check(loc Location, s *openapi3.Schema) []Violation {
+	return f(loc, s)
+}
+
+// Rules returns the policies of c as rules, failing on invalid ones.
+func (c *Config) Rules() ([]Rule, error) {
+	rules := make([]Rule, len(c.Policies))
+	for i := range c.Policies {
+		p := &c.Policies[i]
+		if len(p.Require) == 0 {
+			return nil, fmt.Errorf("policy %s: nothing required", p.Name)
+		}
+		if p.Match.Property != "" && p.Match.property == nil {
+			re, err := patterns.Compile(p.Match.Property)
+			if err != nil {
+				return nil, fmt.Errorf("policy %s: %w", p.Name, err)
+			}
+			p.Match.property = re
+		}
+		rules[i] = p
+	}
+	return rules, nil
+}
+
+// Check returns the violations of the policies of cfg, then of the custom rules, by the
+// schemas of doc: its component schemas, the schemas of its parameters and request
+// bodies, and their nested schemas. Referenced schemas are checked where they are
+// declared.
+func Check(doc *openapi3.T, cfg *Config, rules ...Rule) ([]Violation, error) {
+	policies, err := cfg.Rules()
+	if err != nil {
+		return nil, err
+	}
+	return Run(doc, append(policies, rules...)...), nil
+}
+
+// Run returns the violations of rules by the schemas of doc, in a single walk.
+func Run(doc *openapi3.T, rules ...Rule) []Violation {
+	var violations []Violation
+	for loc, s := range Schemas(doc) {
+		for _, r := range rules {
+			violations = append(violations, r.Check(loc, s)...)
+		}
+	}
+	return violations
+}
+
+// Check returns the violation of p by s, at loc, if any. The policy must have been
+// compiled by Config.Rules.
+func (p *Policy) Check(loc Location, s *openapi3.Schema) []Violation {
+	m := p.Match
+	switch {
+	case m.Type != "" && !s.Type.Is(m.Type),
+		m.Format != "" && s.Format != m.Format,
+		m.property != nil && (loc.Property == "" || !m.property.MatchString(loc.Property)):
+		return nil
+	}
+	for _, kw := range p.Unless {
+		if hasKeyword(s, kw) {
+			return nil
+		}
+	}
+	var missing []string
+	for _, kw := range p.Require {
+		if !hasKeyword(s, kw) {
+			missing = append(missing, kw)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	msg := p.Message
+	if msg == "" {
+		msg = "missing " + strings.Join(missing, ", ")
+	}
+	return []Violation{{Policy: p.Name, Location: loc.Pointer, Message: msg}}
+}
+
+// hasKeyword reports whether s has the keyword kw, or its value when kw is of the form
+// keyword=value.
+func hasKeyword(s *openapi3.Schema, kw string) bool {
+	name, want, valued := strings.Cut(kw, "=")
+	var value string
+	switch name {
+	case "type":
+		if valued {
+			return s.Type.Is(want)
+		}
+		return s.Type != nil
+	case "format":
+		value = s.Format
+	case "pattern":
+		value = s.Pattern
+	case "minLength":
+		if s.MinLength > 0 {
+			value = fmt.Sprint(s.MinLength)
+		}
+	case "maxLength":
+		if s.MaxLength != nil {
+			value = fmt.Sprint(*s.MaxLength)
+		}
+	case "minItems":
+		if s.MinItems > 0 {
+			value = fmt.Sprint(s.MinItems)
+		}
+	case "maxItems":
+		if s.MaxItems != nil {
+			value = fmt.Sprint(*s.MaxItems)
+		}
+	case "minimum":
+		if s.Min != nil {
+			value = fmt.Sprint(*s.Min)
+		}
+	case "maximum":
+		if s.Max != nil {
+			value = fmt.Sprint(*s.Max)
+		}
+	case "uniqueItems":
+		if s.UniqueItems {
+			value = "true"
+		}
+	case "enum":
+		if len(s.Enum) > 0 {
+			value = fmt.Sprint(s.Enum...)
+		}
+	case "maxProperties":
+		if s.MaxProps != nil {
+			value = fmt.Sprint(*s.MaxProps)
+		}
+	default:
+		// Extensions, e.g. x-sensitive.
+		v, ok := s.Extensions[name]
+		if !ok {
+			return false
+		}
+		value = fmt.Sprint(v)
+	}
+	if valued {
+		return value == want
+	}
+	return value != ""
+}
+
+// Location locates a schema in a spec.
+type Location struct {
+	// Pointer is the JSON Pointer of the schema.
+	Pointer string
+	// Property is the name of the property the schema is declared for, if any.
+	Property string
+}
+
+// Schemas yields the schemas of doc checked by policies, by location: its component
+// schemas, the schemas of its parameters and request bodies, and their inline nested
+// schemas, in a stable order.
+func Schemas(doc *openapi3.T) iter.Seq2[Location, *openapi3.Schema] {
+	return func(yield func(Location, *openapi3.Schema) bool) {
+		var walk func(loc Location, ref *openapi3.SchemaRef) bool
+		walk = func(loc Location, ref *openapi3.SchemaRef) bool {
+			if ref == nil || ref.Value == nil || ref.Ref != "" {
+				return true
+			}
+			s := ref.Value
+			if !yield(loc, s) {
+				return false
+			}
+			for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+				if !walk(Location{Pointer: loc.Pointer + "/properties/" + escape(name), Property: name}, s.Properties[name]) {
+					return false
+				}
+			}
+			if !walk(Location{Pointer: loc.Pointer + "/items", Property: loc.Property}, s.Items) ||
+				!walk(Location{Pointer: loc.Pointer + "/additionalProperties"}, s.AdditionalProperties.Schema) {
+				return false
+			}
+			for _, group := range []struct {
+				kw   string
+				refs openapi3.SchemaRefs
+			}{{"allOf", s.AllOf}, {"anyOf", s.AnyOf}, {"oneOf", s.OneOf}} {
+				for i, ref := range group.refs {
+					if !walk(Location{Pointer: fmt.Sprintf("%s/%s/%d", loc.Pointer, group.kw, i), Property: loc.Property}, ref) {
+						return false
+					}
+				}
+			}
+			return true
+		}
+
+		if c := doc.Components; c != nil {
+			for _, name := range slices.Sorted(maps.Keys(c.Schemas)) {
+				if !walk(Location{Pointer: "#/components/schemas/" + escape(name)}, c.Schemas[name]) {
+					return
+				}
+			}
+			for _, name := range slices.Sorted(maps.Keys(c.Parameters)) {
+				if p := c.Parameters[name]; p.Ref == "" && p.Value != nil {
+					if !walk(Location{Pointer: "#/components/parameters/" + escape(name) + "/schema", Property: p.Value.Name}, p.Value.Schema) {
+						return
+					}
+				}
+			}
+		}
+		if doc.Paths == nil {
+			return
+		}
+		for _, path := range doc.Paths.InMatchingOrder() {
+			item := doc.Paths.Value(path)
+			ops := item.Operations()
+			for _, method := range slices.Sorted(maps.Keys(ops)) {
+				op := ops[method]
+				base := "#/paths/" + escape(path) + "/" + strings.ToLower(method)
+				for i, p := range op.Parameters {
+					if p.Ref == "" && p.Value != nil {
+						if !walk(Location{Pointer: fmt.Sprintf("%s/parameters/%d/schema", base, i), Property: p.Value.Name}, p.Value.Schema) {
+							return
+						}
+					}
+				}
+				if op.RequestBody == nil || op.RequestBody.Ref != "" || op.RequestBody.Value == nil {
+					continue
+				}
+				content := op.RequestBody.Value.Content
+				for _, ct := range slices.Sorted(maps.Keys(content)) {
+					if !walk(Location{Pointer: base + "/requestBody/content/" + escape(ct) + "/schema"}, content[ct].Schema) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+func escape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,138 @@
+package policy
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const spec = `
+openapi: 3.0.0
+info: {title: Test, version: 1.0.0}
+paths:
+  /users:
+    post:
+      operationId: createUser
+      parameters:
+        - {name: tenantId, in: query, schema: {type: string}}
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: '#/components/schemas/User'}
+      responses:
+        '204': {description: Created}
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        id: {type: string, format: uuid, maxLength: 36}
+        name: {type: string, maxLength: 50}
+        role: {type: string, enum: [admin, user]}
+        groupId: {type: string}
+        tags:
+          type: array
+          items: {type: string}
+`
+
+const config = `
+policies:
+  - name: bounded-strings
+    match: {type: string}
+    require: [maxLength]
+    unless: [enum]
+  - name: uuid-ids
+    message: identifiers must be UUIDs
+    match: {type: string, property: "(?i)^id$|Id$"}
+    require: [format=uuid]
+  - name: bounded-arrays
+    match: {type: array}
+    require: [maxItems]
+`
+
+func TestCheck(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(config), 0644))
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	violations, err := Check(doc, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []Violation{
+		{Policy: "bounded-strings", Location: "#/components/schemas/User/properties/groupId", Message: "missing maxLength"},
+		{Policy: "uuid-ids", Location: "#/components/schemas/User/properties/groupId", Message: "identifiers must be UUIDs"},
+		{Policy: "bounded-arrays", Location: "#/components/schemas/User/properties/tags", Message: "missing maxItems"},
+		{Policy: "bounded-strings", Location: "#/components/schemas/User/properties/tags/items", Message: "missing maxLength"},
+		{Policy: "bounded-strings", Location: "#/paths/~1users/post/parameters/0/schema", Message: "missing maxLength"},
+		{Policy: "uuid-ids", Location: "#/paths/~1users/post/parameters/0/schema", Message: "identifiers must be UUIDs"},
+	}, violations)
+}
+
+func TestCheckInvalidConfig(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	require.NoError(t, err)
+
+	_, err = Check(doc, &Config{Policies: []Policy{{Name: "empty", Match: Match{Type: "string"}}}})
+	assert.EqualError(t, err, "policy empty: nothing required")
+
+	_, err = Check(doc, &Config{Policies: []Policy{{Name: "bad", Match: Match{Property: "("}, Require: []string{"maxLength"}}}})
+	assert.ErrorContains(t, err, "policy bad: error parsing regexp")
+}
+
+func TestCheckCustomRules(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	require.NoError(t, err)
+
+	described := RuleFunc(func(loc Location, s *openapi3.Schema) []Violation {
+		if loc.Property == "" || s.Description != "" {
+			return nil
+		}
+		return []Violation{{Policy: "described", Location: loc.Pointer, Message: "missing description"}}
+	})
+	cfg := &Config{Policies: []Policy{{Name: "bounded-arrays", Match: Match{Type: "array"}, Require: []string{"maxItems"}}}}
+	violations, err := Check(doc, cfg, described)
+	require.NoError(t, err)
+
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.String())
+	}
+	assert.Equal(t, []string{
+		"#/components/schemas/User/properties/groupId: missing description (described)",
+		"#/components/schemas/User/properties/id: missing description (described)",
+		"#/components/schemas/User/properties/name: missing description (described)",
+		"#/components/schemas/User/properties/role: missing description (described)",
+		"#/components/schemas/User/properties/tags: missing maxItems (bounded-arrays)",
+		"#/components/schemas/User/properties/tags: missing description (described)",
+		"#/components/schemas/User/properties/tags/items: missing description (described)",
+		"#/paths/~1users/post/parameters/0/schema: missing description (described)",
+	}, got)
+}
+
+func TestMarshalInput(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	require.NoError(t, err)
+
+	data, err := MarshalInput(doc)
+	require.NoError(t, err)
+	var in struct {
+		Schemas []struct {
+			Location string         `json:"location"`
+			Property string         `json:"property"`
+			Schema   map[string]any `json:"schema"`
+		} `json:"schemas"`
+	}
+	require.NoError(t, json.Unmarshal(data, &in))
+	require.Len(t, in.Schemas, 8)
+	assert.Equal(t, "#/components/schemas/User", in.Schemas[0].Location)
+	assert.Equal(t, "#/components/schemas/User/properties/groupId", in.Schemas[1].Location)
+	assert.Equal(t, "groupId", in.Schemas[1].Property)
+	assert.Equal(t, map[string]any{"type": "string"}, in.Schemas[1].Schema)
+}
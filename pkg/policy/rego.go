@@ -0,0 +1,50 @@
+package policy
+
+import (
+	"encoding/json"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Input is the document given to external policy engines, such as Open Policy Agent, to
+// check the schemas of a spec in a policy language:
+//
+//	package apipolicy
+//
+//	deny contains msg if {
+//		some s in input.schemas
+//		s.schema.type == "string"
+//		not s.schema.maxLength
+//		msg := sprintf("%s: missing maxLength", [s.location])
+//	}
+//
+// evaluated with opa eval -i input.json -d policy.rego data.apipolicy.deny.
+type Input struct {
+	Schemas []InputSchema `json:"schemas"`
+}
+
+// InputSchema is a schema of the spec at its location, see Schemas.
+type InputSchema struct {
+	Location string           `json:"location"`
+	Property string           `json:"property,omitempty"`
+	Schema   *openapi3.Schema `json:"schema"`
+}
+
+// NewInput returns the schemas of doc walked by the rules, as the input of an external
+// policy engine.
+func NewInput(doc *openapi3.T) *Input {
+	in := &Input{Schemas: []InputSchema{}}
+	for loc, s := range Schemas(doc) {
+		in.Schemas = append(in.Schemas, InputSchema{Location: loc.Pointer, Property: loc.Property, Schema: s})
+	}
+	return in
+}
+
+// MarshalInput returns the input of doc for an external policy engine, as JSON.
+func MarshalInput(doc *openapi3.T) ([]byte, error) {
+	data, err := json.MarshalIndent(NewInput(doc), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}